@@ -0,0 +1,83 @@
+// checkMeta.go implements the check-meta subcommand.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/streimelstefan/tyro/operations"
+)
+
+func init() {
+	register(Command{
+		Name:    "check-meta",
+		Summary: "check and repair the file meta information (group 0002)",
+		Run:     checkMeta,
+	})
+}
+
+// checkMeta reports the File Meta Information inconsistencies of every DICOM file given as
+// argument and, if requested, regenerates the File Meta Information of the affected files.
+func checkMeta(args []string) error {
+	flags := flag.NewFlagSet("check-meta", flag.ContinueOnError)
+	fix := flags.Bool("fix", false, "regenerate the file meta information of inconsistent files")
+	backupDir := flags.String("backup-dir", "", "backup location (default $"+operations.BackupDirEnv+" or ~/.tyro/backups)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: tyro check-meta [flags] <file>...")
+		fmt.Fprintln(flags.Output(), "Reports wrong group lengths, missing elements and UIDs contradicting the data set.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no input files given")
+	}
+	if *backupDir != "" {
+		os.Setenv(operations.BackupDirEnv, *backupDir)
+	}
+
+	errs := tyroErrors.New()
+	inconsistent := 0
+	for _, path := range flags.Args() {
+		file := &operations.ParsedDicomFile{Path: path}
+		if err := file.Reload(); err != nil {
+			errs.Add(fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if file.MetaIssues == nil {
+			continue
+		}
+
+		issues := []error{file.MetaIssues}
+		var multi *tyroErrors.MultiError
+		if errors.As(file.MetaIssues, &multi) {
+			issues = multi.Errors()
+		}
+		for _, issue := range issues {
+			fmt.Fprintf(os.Stdout, "%s: %v\n", path, issue)
+		}
+
+		if !*fix {
+			inconsistent++
+			continue
+		}
+		if _, err := operations.FixFileMeta(path); err != nil {
+			errs.Add(fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "%s: regenerated file meta information\n", path)
+	}
+
+	if inconsistent > 0 {
+		errs.Add(fmt.Errorf("%d files have inconsistent file meta information, use -fix to repair them", inconsistent))
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// checkUIDs.go implements the check-uids subcommand.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/streimelstefan/tyro/operations"
+)
+
+func init() {
+	register(Command{
+		Name:    "check-uids",
+		Summary: "report study, series and instance UIDs shared by unrelated files",
+		Run:     checkUIDs,
+	})
+}
+
+// checkUIDs checks all DICOM files given as argument, or found below a directory given as
+// argument, for conflicting UIDs and reports them by level, UID and group of files. It fails if
+// any conflict is found.
+func checkUIDs(args []string) error {
+	flags := flag.NewFlagSet("check-uids", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: tyro check-uids <file or directory>...")
+		fmt.Fprintln(flags.Output(), "Reports Study Instance UIDs shared by patients, Series Instance UIDs shared by studies")
+		fmt.Fprintln(flags.Output(), "and SOP Instance UIDs shared by files of different content. Exits with an error if any")
+		fmt.Fprintln(flags.Output(), "conflict is found. Conflicts are fixed in the interactive TUI with U.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no input files given")
+	}
+
+	errs := tyroErrors.New()
+	var files []*operations.ParsedDicomFile
+	for _, path := range inputFiles(flags.Args(), errs) {
+		file := &operations.ParsedDicomFile{Path: path}
+		if err := file.Reload(); err != nil {
+			errs.Add(fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		files = append(files, file)
+	}
+
+	report := operations.CheckUIDs(files, nil)
+	for _, conflict := range report.Conflicts {
+		fmt.Fprintf(os.Stdout, "%s %s: shared by %d groups\n", conflict.Level, conflict.UID, len(conflict.Groups))
+		for _, group := range conflict.Groups {
+			fmt.Fprintf(os.Stdout, "  %s: %d files\n", group.Owner, len(group.Files))
+			for _, path := range group.Files {
+				fmt.Fprintf(os.Stdout, "    %s\n", path)
+			}
+		}
+	}
+	fmt.Fprintf(os.Stdout, "%d study, %d series and %d instance UID conflicts in %d files\n",
+		report.Len(operations.UIDLevelStudy), report.Len(operations.UIDLevelSeries), report.Len(operations.UIDLevelInstance), report.Files)
+
+	if len(report.Conflicts) > 0 {
+		errs.Add(fmt.Errorf("%d conflicting UIDs found", len(report.Conflicts)))
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Package cli implements the non-interactive subcommands of tyro.
+//
+// Every subcommand parses its own flags and operates on the files passed as arguments. The
+// interactive TUI is started when the first argument is not a known subcommand.
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Command is a non-interactive tyro subcommand.
+type Command struct {
+	// Name is the name used to invoke the command, e.g. "export-image".
+	Name string
+	// Summary is a one line description shown in the usage text.
+	Summary string
+	// Run executes the command with the arguments following its name.
+	Run func(args []string) error
+}
+
+// commands holds all registered subcommands indexed by name.
+var commands = map[string]Command{}
+
+// register adds a command to the set of available subcommands.
+func register(cmd Command) {
+	commands[cmd.Name] = cmd
+}
+
+// Lookup returns the subcommand with the given name and whether it exists.
+func Lookup(name string) (Command, bool) {
+	cmd, ok := commands[name]
+	return cmd, ok
+}
+
+// PrintUsage writes the general usage text including all subcommands to w.
+func PrintUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: tyro <directory>")
+	fmt.Fprintln(w, "       tyro <command> [flags] <args>")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-16s %s\n", name, commands[name].Summary)
+	}
+}
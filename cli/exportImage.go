@@ -0,0 +1,121 @@
+// exportImage.go implements the export-image subcommand.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/streimelstefan/tyro/operations"
+)
+
+func init() {
+	register(Command{
+		Name:    "export-image",
+		Summary: "export frames to PNG or raw pixel dumps",
+		Run:     exportImage,
+	})
+}
+
+// exportImage writes the frames of every DICOM file given as argument to PNG files or raw dumps.
+func exportImage(args []string) error {
+	flags := flag.NewFlagSet("export-image", flag.ContinueOnError)
+	format := flags.String("format", "png8", "output format: png8, png16 or raw")
+	out := flags.String("out", ".", "directory the exported files are written to")
+	frames := flags.String("frames", "", "frames to export as 1-based list and ranges, e.g. 1,3-5 (default all)")
+	voi := flags.Bool("voi", false, "apply the Modality LUT and VOI window to monochrome PNG output")
+	center := flags.Float64("wc", 0, "window center overriding the dataset (requires -voi and -ww)")
+	width := flags.Float64("ww", 0, "window width overriding the dataset (requires -voi and -wc)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: tyro export-image [flags] <file>...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no input files given")
+	}
+	given := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	if given["wc"] != given["ww"] {
+		flags.Usage()
+		return errors.New("-wc and -ww must be given together")
+	}
+	if given["ww"] && !*voi {
+		flags.Usage()
+		return errors.New("-wc and -ww require -voi")
+	}
+	if given["ww"] && *width < 1 {
+		// PS3.3 C.11.2.1.2 requires a window width of at least 1.
+		flags.Usage()
+		return errors.New("-ww must be at least 1")
+	}
+
+	opts := operations.ExportOptions{OutputDir: *out, ApplyWindow: *voi}
+
+	var err error
+	if opts.Format, err = operations.ParseExportFormat(*format); err != nil {
+		return err
+	}
+	if opts.Frames, err = parseFrameList(*frames); err != nil {
+		return err
+	}
+	if given["ww"] {
+		opts.Window = &operations.Window{Center: *center, Width: *width}
+	}
+
+	errs := tyroErrors.New()
+	for _, path := range flags.Args() {
+		pixels, err := operations.LoadPixelData(path)
+		if err != nil {
+			errs.Add(fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		written, err := operations.ExportFrames(pixels, opts)
+		for _, w := range written {
+			fmt.Fprintln(os.Stdout, w)
+		}
+		if err != nil {
+			errs.Add(fmt.Errorf("%s: %w", path, err))
+		}
+	}
+
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// parseFrameList parses a comma separated list of 1-based frame numbers and ranges into zero
+// based frame indices. An empty list results in nil, which selects all frames.
+func parseFrameList(list string) ([]int, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	frames := make([]int, 0)
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("invalid frame %q", part)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil || end < start {
+				return nil, fmt.Errorf("invalid frame range %q", part)
+			}
+		}
+		for f := start; f <= end; f++ {
+			frames = append(frames, f-1)
+		}
+	}
+	return frames, nil
+}
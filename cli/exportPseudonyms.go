@@ -0,0 +1,73 @@
+// exportPseudonyms.go implements the export-pseudonyms subcommand.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/streimelstefan/tyro/operations"
+)
+
+func init() {
+	register(Command{
+		Name:    "export-pseudonyms",
+		Summary: "export the re-identification key of the pseudonym store as CSV",
+		Run:     exportPseudonyms,
+	})
+}
+
+// exportPseudonyms writes the patients and accession numbers of the pseudonym store and their
+// pseudonyms as CSV to standard output or a file.
+func exportPseudonyms(args []string) error {
+	flags := flag.NewFlagSet("export-pseudonyms", flag.ContinueOnError)
+	storePath := flags.String("store", "", "pseudonym store (default $"+operations.PseudonymStoreEnv+" or ~/.tyro/pseudonyms.json)")
+	out := flags.String("out", "", "CSV file to write (default standard output)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: tyro export-pseudonyms [flags]")
+		fmt.Fprintln(flags.Output(), "Writes the original and pseudonymized patient IDs, names and accession numbers as CSV.")
+		fmt.Fprintln(flags.Output(), "An encrypted store is decrypted with the passphrase in $"+operations.PseudonymPassphraseEnv+".")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return errors.New("unexpected arguments")
+	}
+	if *storePath != "" {
+		os.Setenv(operations.PseudonymStoreEnv, *storePath)
+	}
+
+	path, err := operations.PseudonymStorePath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("opening pseudonym store: %w", err)
+	}
+	store, err := operations.OpenDefaultPseudonymStore()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := store.WriteCSV(w); err != nil {
+		return err
+	}
+	if *out != "" {
+		fmt.Fprintf(os.Stderr, "%d patients written to %s\n", len(store.Patients()), *out)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// restore.go implements the restore subcommand.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/streimelstefan/tyro/operations"
+)
+
+func init() {
+	register(Command{
+		Name:    "restore",
+		Summary: "list or restore backed up versions of a file",
+		Run:     restore,
+	})
+}
+
+// restore lists the backups of a file or, if a version number is given, restores that version.
+func restore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	backupDir := flags.String("backup-dir", "", "backup location (default $"+operations.BackupDirEnv+" or ~/.tyro/backups)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: tyro restore [flags] <file> [version]")
+		fmt.Fprintln(flags.Output(), "Lists the backups of file, newest first, or restores the given version.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return errors.New("expected a file and an optional version")
+	}
+	if *backupDir != "" {
+		os.Setenv(operations.BackupDirEnv, *backupDir)
+	}
+
+	path := flags.Arg(0)
+	backups, err := operations.ListBackups(path)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("%s: %w", path, operations.ErrorNoBackups)
+	}
+
+	if flags.NArg() == 1 {
+		for i, backup := range backups {
+			fmt.Fprintf(os.Stdout, "%3d  %s  %10d bytes  %s\n", i+1, backup.Time.Local().Format("2006-01-02 15:04:05"), backup.Size, backup.Path)
+		}
+		return nil
+	}
+
+	version, err := strconv.Atoi(flags.Arg(1))
+	if err != nil || version < 1 || version > len(backups) {
+		return fmt.Errorf("invalid version %q, expected a number between 1 and %d", flags.Arg(1), len(backups))
+	}
+	backup := backups[version-1]
+	if err := operations.RestoreBackup(backup); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "restored %s from %s\n", backup.Original, backup.Time.Local().Format("2006-01-02 15:04:05"))
+	return nil
+}
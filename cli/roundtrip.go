@@ -0,0 +1,111 @@
+// roundtrip.go implements the roundtrip subcommand.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/streimelstefan/tyro/operations"
+)
+
+func init() {
+	register(Command{
+		Name:    "roundtrip",
+		Summary: "verify that saving leaves unmodified files byte for byte identical",
+		Run:     roundtrip,
+	})
+}
+
+// roundtrip rewrites every DICOM file given as argument, or found below a directory given as
+// argument, in memory without modifications and reports files whose content would change.
+func roundtrip(args []string) error {
+	flags := flag.NewFlagSet("roundtrip", flag.ContinueOnError)
+	verbose := flags.Bool("v", false, "list every file, not only the ones that fail")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: tyro roundtrip [flags] <file or directory>...")
+		fmt.Fprintln(flags.Output(), "Writes each file the way edits are saved, without touching it, and compares the result.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no input files given")
+	}
+
+	errs := tyroErrors.New()
+	paths := inputFiles(flags.Args(), errs)
+
+	failed := 0
+	for _, path := range paths {
+		elements, reencoded, err := operations.CheckRoundTrip(path)
+		if err != nil {
+			failed++
+			errs.Add(fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if *verbose {
+			fmt.Fprintf(os.Stdout, "%s: identical, without preservation %d of %d elements would be re-encoded\n", path, reencoded, elements)
+		}
+	}
+	fmt.Fprintf(os.Stdout, "%d of %d files round-trip byte for byte\n", len(paths)-failed, len(paths))
+
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// inputFiles returns the files given as arguments and the DICOM files found below the directories
+// given as arguments. Arguments that cannot be read are added to errs.
+func inputFiles(args []string, errs *tyroErrors.MultiError) []string {
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		found, err := discoverFiles(arg)
+		if err != nil {
+			errs.Add(err)
+		}
+		paths = append(paths, found...)
+	}
+	return paths
+}
+
+// discoverFiles returns the paths of all DICOM files below dir in lexical order.
+func discoverFiles(dir string) ([]string, error) {
+	result := operations.DiscoverDICOMFiles(dir, 0)
+	errs := tyroErrors.New()
+	done := make(chan struct{})
+	go func() {
+		for err := range result.Errors {
+			errs.Add(err)
+		}
+		close(done)
+	}()
+
+	var paths []string
+	for file := range result.Files {
+		file.Handle.Close()
+		paths = append(paths, file.Path)
+	}
+	<-done
+	sort.Strings(paths)
+
+	if errs.HasErrors() {
+		return paths, errs
+	}
+	return paths, nil
+}
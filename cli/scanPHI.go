@@ -0,0 +1,83 @@
+// scanPHI.go implements the scan-phi subcommand.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/streimelstefan/tyro/operations"
+)
+
+func init() {
+	register(Command{
+		Name:    "scan-phi",
+		Summary: "report residual patient information in text and private elements",
+		Run:     scanPHI,
+	})
+}
+
+// scanPHI scans every DICOM file given as argument, or found below a directory given as argument,
+// for residual PHI and reports every hit. It fails if anything is found, so it can guard an export.
+func scanPHI(args []string) error {
+	flags := flag.NewFlagSet("scan-phi", flag.ContinueOnError)
+	storePath := flags.String("store", "", "pseudonym store (default $"+operations.PseudonymStoreEnv+" or ~/.tyro/pseudonyms.json)")
+	var patterns []operations.PHIPattern
+	flags.Func("pattern", "additional pattern as \"<name> <regular expression>\", may be repeated", func(spec string) error {
+		pattern, err := operations.ParsePHIPattern(spec)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, pattern)
+		return nil
+	})
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: tyro scan-phi [flags] <file or directory>...")
+		fmt.Fprintln(flags.Output(), "Searches text and private elements for patient names, IDs, birth dates and the patterns")
+		fmt.Fprintln(flags.Output(), "of $"+operations.PHIPatternsEnv+" or ~/.tyro/phi-patterns. Exits with an error if anything is found.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no input files given")
+	}
+	if *storePath != "" {
+		os.Setenv(operations.PseudonymStoreEnv, *storePath)
+	}
+
+	scanner, err := operations.NewDefaultPHIScanner(patterns...)
+	if err != nil {
+		return err
+	}
+	errs := tyroErrors.New()
+	paths := inputFiles(flags.Args(), errs)
+
+	report := scanner.ScanFiles(paths, nil)
+	if report.Warning != "" {
+		fmt.Fprintln(os.Stderr, "warning: "+report.Warning)
+	}
+	files := 0
+	for i, hit := range report.Hits {
+		if i == 0 || hit.File != report.Hits[i-1].File {
+			files++
+		}
+		fmt.Fprintf(os.Stdout, "%s: %s [%s] %q in %q\n", hit.File, hit.Path, hit.Kind, hit.Match, hit.Value)
+	}
+	fmt.Fprintf(os.Stdout, "%d hits in %d of %d files\n", len(report.Hits), files, report.Files)
+
+	for _, err := range report.Errors.Errors() {
+		errs.Add(err)
+	}
+	if len(report.Hits) > 0 {
+		errs.Add(fmt.Errorf("residual PHI found in %d files", files))
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
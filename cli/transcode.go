@@ -0,0 +1,65 @@
+// transcode.go implements the transcode subcommand.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/streimelstefan/tyro/operations"
+)
+
+func init() {
+	register(Command{
+		Name:    "transcode",
+		Summary: "convert files between implicit, explicit, big endian, deflated and RLE Lossless transfer syntaxes",
+		Run:     transcode,
+	})
+}
+
+// transcode converts every DICOM file given as argument to the requested transfer syntax.
+func transcode(args []string) error {
+	flags := flag.NewFlagSet("transcode", flag.ContinueOnError)
+	to := flags.String("to", "explicit", "target transfer syntax: implicit, explicit, big, deflated or rle")
+	backupDir := flags.String("backup-dir", "", "backup location (default $"+operations.BackupDirEnv+" or ~/.tyro/backups)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: tyro transcode [flags] <file>...")
+		fmt.Fprintln(flags.Output(), "Rewrites files with native or RLE Lossless pixel data in another transfer syntax, keeping a backup of each.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no input files given")
+	}
+	if *backupDir != "" {
+		os.Setenv(operations.BackupDirEnv, *backupDir)
+	}
+
+	target, err := operations.ParseTransferSyntax(*to)
+	if err != nil {
+		return err
+	}
+
+	errs := tyroErrors.New()
+	for _, path := range flags.Args() {
+		changed, err := operations.Transcode(path, target)
+		switch {
+		case err != nil:
+			errs.Add(fmt.Errorf("%s: %w", path, err))
+		case changed:
+			fmt.Fprintf(os.Stdout, "%s: %s\n", path, operations.TransferSyntaxName(target))
+		default:
+			fmt.Fprintf(os.Stdout, "%s: already %s\n", path, operations.TransferSyntaxName(target))
+		}
+	}
+
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
@@ -7,16 +7,26 @@ import (
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/streimelstefan/tyro/cli"
 	"github.com/streimelstefan/tyro/ui"
 )
 
 func main() {
 	// Check if directory argument is provided
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: tyro <directory>")
+		cli.PrintUsage(os.Stdout)
 		os.Exit(1)
 	}
 
+	// Run a non-interactive subcommand if one is requested
+	if cmd, ok := cli.Lookup(os.Args[1]); ok {
+		if err := cmd.Run(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.Name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	dir := os.Args[1]
 
 	// Initialize the Bubble Tea program
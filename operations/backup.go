@@ -0,0 +1,280 @@
+// backup.go implements atomic file replacement and the backups kept of every replaced file.
+//
+// Files are never modified in place: new content is written to a temporary file next to the
+// original, synced to disk and renamed over the original, so the file always contains either the
+// old or the new version. Before the rename, the old version is copied to the backup location,
+// unless the replacement removes identifying information, see ElementChange.Redact.
+//
+// The backup location defaults to ~/.tyro/backups and can be changed with the TYRO_BACKUP_DIR
+// environment variable. Backups of a file are stored in a directory mirroring the file's absolute
+// path, e.g. the backups of /data/ct/1.dcm are kept in <backup location>/data/ct/1.dcm/, and are
+// named after the time they were taken.
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupDirEnv is the environment variable overriding the default backup location.
+const BackupDirEnv = "TYRO_BACKUP_DIR"
+
+// backupTimeFormat is the format of the backup file names. It sorts chronologically.
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+// backupExtension is the file extension of backup files.
+const backupExtension = ".dcm"
+
+// ErrorNoBackups is returned when a backup is requested for a file that has none.
+var ErrorNoBackups = errors.New("no backups available")
+
+// Backup is a previous version of a file kept in the backup location.
+type Backup struct {
+	// Path is the location of the backup file.
+	Path string
+	// Original is the absolute path of the file the backup was taken of.
+	Original string
+	// Time is the point in time the backup was taken.
+	Time time.Time
+	// Size is the size of the backup in bytes.
+	Size int64
+}
+
+// BackupDir returns the root directory backups are stored in.
+func BackupDir() (string, error) {
+	if dir := os.Getenv(BackupDirEnv); dir != "" {
+		return filepath.Abs(dir)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine backup location, set %s: %w", BackupDirEnv, err)
+	}
+	return filepath.Join(home, ".tyro", "backups"), nil
+}
+
+// backupDirOf returns the directory the backups of the file at path are stored in.
+func backupDirOf(path string) (string, error) {
+	root, err := BackupDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// Strip the volume name so the absolute path can be nested below the backup root on Windows.
+	abs = strings.TrimPrefix(abs, filepath.VolumeName(abs))
+	return filepath.Join(root, abs), nil
+}
+
+// CreateBackup copies the current content of the file at path to the backup location.
+func CreateBackup(path string) (Backup, error) {
+	dir, err := backupDirOf(path)
+	if err != nil {
+		return Backup{}, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Backup{}, err
+	}
+
+	now := time.Now().UTC()
+	backupPath := filepath.Join(dir, now.Format(backupTimeFormat)+backupExtension)
+	size, err := copyFile(path, backupPath)
+	if err != nil {
+		os.Remove(backupPath)
+		return Backup{}, err
+	}
+
+	abs, _ := filepath.Abs(path)
+	return Backup{Path: backupPath, Original: abs, Time: now, Size: size}, nil
+}
+
+// ListBackups returns the backups of the file at path, newest first.
+func ListBackups(path string) ([]Backup, error) {
+	dir, err := backupDirOf(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	abs, _ := filepath.Abs(path)
+	var backups []Backup
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), backupExtension)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		taken, err := time.Parse(backupTimeFormat, name)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{
+			Path:     filepath.Join(dir, entry.Name()),
+			Original: abs,
+			Time:     taken,
+			Size:     info.Size(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return backups, nil
+}
+
+// RestoreBackup replaces the original file of backup with the backup's content.
+//
+// The replaced version of the file is backed up itself, so a restore can be reverted.
+func RestoreBackup(backup Backup) error {
+	in, err := os.Open(backup.Path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	return ReplaceFile(backup.Original, func(out io.Writer) error {
+		_, err := io.Copy(out, in)
+		return err
+	})
+}
+
+// ReplaceFile atomically replaces the content of the file at path with the content produced by write.
+//
+// The content is written to a temporary file in the same directory and synced to disk. If the
+// file at path exists, it is backed up and its permissions are carried over before the temporary
+// file is renamed over it. If write fails, the original file is left untouched.
+func ReplaceFile(path string, write func(out io.Writer) error) error {
+	_, err := replaceFile(path, true, write)
+	return err
+}
+
+// replaceFile implements ReplaceFile and returns the backup taken of the replaced file, or nil if
+// the file did not exist before. If backup is false, no backup is taken.
+func replaceFile(path string, backup bool, write func(out io.Writer) error) (*Backup, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tyro-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	// Removing the temporary file fails once it has been renamed, which is fine.
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	var taken *Backup
+	if info, err := os.Stat(path); err == nil {
+		if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+		if backup {
+			b, err := CreateBackup(path)
+			if err != nil {
+				return nil, fmt.Errorf("backing up %s: %w", path, err)
+			}
+			taken = &b
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+	syncDir(dir)
+	return taken, nil
+}
+
+// keepPrevious copies the file at path to a temporary file in the same directory and returns its
+// path, so a replacement that is not backed up can still be rolled back. The caller removes it.
+func keepPrevious(path string) (string, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tyro-*.orig")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(tmp, in)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// copyFile copies the file at src to dst, syncs dst to disk and returns the number of bytes copied.
+func copyFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+// syncDir syncs the directory at path so a rename within it is persisted.
+//
+// Not every platform supports syncing directories, so errors are ignored.
+func syncDir(path string) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer dir.Close()
+	dir.Sync()
+}
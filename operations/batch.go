@@ -0,0 +1,476 @@
+// batch.go implements batch edits, a single tag change, transcode, File Meta Information repair,
+// de-identification, UID replacement, private tag removal or pixel blanking applied to many DICOM
+// files at once.
+//
+// A batch edit is previewed before it is performed: every file is read and the change it would
+// receive is computed, so the affected files and values can be reviewed before anything is
+// written. The previewed changes are then performed as a single operation of a History, which
+// makes the whole batch undoable.
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// ErrorInvalidBatchEdit is returned when a batch edit specification cannot be parsed.
+var ErrorInvalidBatchEdit = errors.New("invalid batch edit")
+
+// BatchAction is the name of a batch edit, the first word of its specification.
+type BatchAction string
+
+const (
+	// BatchSet sets the value of the element, adding it to files that lack it.
+	BatchSet BatchAction = "set"
+	// BatchClear empties the value of the element.
+	BatchClear BatchAction = "clear"
+	// BatchDelete removes the element.
+	BatchDelete BatchAction = "delete"
+	// BatchReplace replaces all matches of a regular expression in the value of the element.
+	BatchReplace BatchAction = "replace"
+	// BatchTranscode converts the files to another transfer syntax, see TranscodeChange.
+	BatchTranscode BatchAction = "transcode"
+	// BatchFixMeta regenerates inconsistent File Meta Information, see FileMetaChange.
+	BatchFixMeta BatchAction = "fix-meta"
+	// BatchDeidentify de-identifies the files, see Deidentifier.
+	BatchDeidentify BatchAction = "deidentify"
+	// BatchRemapUIDs replaces the UIDs of the files, see UIDRemapper.
+	BatchRemapUIDs BatchAction = "remap-uids"
+	// BatchStripPrivate removes the private elements of the files, see PrivateTagPolicy.
+	BatchStripPrivate BatchAction = "strip-private"
+	// BatchBlank blanks regions of the pixel data of the files, see BlankChanges.
+	BatchBlank BatchAction = "blank"
+)
+
+// BatchEdit is an edit applied to many files: a change of a single top level element, a conversion
+// to another transfer syntax, a repair of the File Meta Information, a de-identification, a
+// replacement of UIDs, a removal of private elements or a blanking of pixel regions.
+type BatchEdit interface {
+	// Changes returns the changes the edit makes to the dataset ds of the file at path, which are
+	// none if the file is not affected.
+	Changes(path string, ds dicom.Dataset) ([]*ElementChange, error)
+	// String describes the edit, e.g. `set AccessionNumber to "A123"`.
+	String() string
+}
+
+// BatchCommitter is implemented by batch edits that keep state beyond the changes they return,
+// such as the new pseudonyms of a de-identification. The state is only kept once the changes are
+// performed, so previews that are never performed leave no trace.
+type BatchCommitter interface {
+	// Commit keeps the state the returned changes depend on. It is called before the changes are
+	// performed or staged.
+	Commit() error
+}
+
+// CommitBatchEdit commits edit if it is a BatchCommitter.
+func CommitBatchEdit(edit BatchEdit) error {
+	if committer, ok := edit.(BatchCommitter); ok {
+		return committer.Commit()
+	}
+	return nil
+}
+
+// batchParsers parse the arguments of each action of ParseBatchEdit.
+var batchParsers = map[BatchAction]func(args string) (BatchEdit, error){
+	BatchSet:          elementEditParser(BatchSet),
+	BatchClear:        elementEditParser(BatchClear),
+	BatchDelete:       elementEditParser(BatchDelete),
+	BatchReplace:      elementEditParser(BatchReplace),
+	BatchTranscode:    parseTranscodeEdit,
+	BatchFixMeta:      parseFixMetaEdit,
+	BatchDeidentify:   parseDeidentifyEdit,
+	BatchRemapUIDs:    parseRemapUIDsEdit,
+	BatchStripPrivate: parseStripPrivateEdit,
+	BatchBlank:        parseBlankEdit,
+}
+
+// ParseBatchEdit parses a batch edit written as one of
+//
+//	set <tag> <value>
+//	clear <tag>
+//	delete <tag>
+//	replace <tag> /<pattern>/<replacement>/
+//	transcode <implicit|explicit|big|deflated|rle>
+//	fix-meta
+//	deidentify [option ...]
+//	remap-uids
+//	strip-private [all]
+//	blank <WxH+X+Y[@frames] ...|preset>
+//
+// Tags are given by keyword or number as accepted by ParseTagSpec. The value of set extends to the
+// end of the specification. Any character can be used as delimiter of replace instead of a slash,
+// e.g. "replace InstitutionName |a/b|c|". The options of deidentify are listed by
+// DeidentifyOptionNames. remap-uids creates UIDs below UIDRoot with a key of its own, so every
+// edit creates different UIDs. strip-private applies DefaultPrivateTagPolicy, strip-private all
+// removes every private element. blank takes pixel regions as accepted by ParsePixelRegions, or
+// preset to blank the regions of the matching preset of LoadBlankPresets in each file.
+func ParseBatchEdit(spec string) (BatchEdit, error) {
+	action, rest, _ := strings.Cut(strings.TrimSpace(spec), " ")
+	parse, ok := batchParsers[BatchAction(strings.ToLower(action))]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown action %q, expected set, clear, delete, replace, transcode, fix-meta, deidentify, remap-uids, strip-private or blank", ErrorInvalidBatchEdit, action)
+	}
+	return parse(strings.TrimSpace(rest))
+}
+
+// noArguments returns an error if an action taking no arguments is given some.
+func noArguments(action BatchAction, args string) error {
+	if args != "" {
+		return fmt.Errorf("%w: %s takes no arguments", ErrorInvalidBatchEdit, action)
+	}
+	return nil
+}
+
+// ElementEdit sets, clears, deletes or replaces the value of a single top level element.
+type ElementEdit struct {
+	// Action is the modification applied to the element, one of BatchSet, BatchClear, BatchDelete
+	// and BatchReplace.
+	Action BatchAction
+	// Tag is the tag of the modified element.
+	Tag tag.Tag
+	// VR is the Value Representation of elements added by BatchSet. It is empty if the tag is not
+	// part of the data dictionary, in which case files lacking the element cannot be edited.
+	VR string
+	// Value is the new value of BatchSet or the replacement of BatchReplace, which may refer to
+	// capture groups as $1 or ${name}.
+	Value string
+	// Pattern is the regular expression matched by BatchReplace.
+	Pattern *regexp.Regexp
+}
+
+// elementEditParser returns the parser of the arguments "<tag> [argument]" of action.
+func elementEditParser(action BatchAction) func(args string) (BatchEdit, error) {
+	return func(args string) (BatchEdit, error) {
+		tagSpec, arg, _ := strings.Cut(args, " ")
+		arg = strings.TrimSpace(arg)
+		if tagSpec == "" {
+			return nil, fmt.Errorf("%w: %s expects <tag> [argument]", ErrorInvalidBatchEdit, action)
+		}
+
+		edit := &ElementEdit{Action: action}
+		var err error
+		edit.Tag, edit.VR, err = ParseTagSpec(tagSpec)
+		if err != nil {
+			// Tags missing from the dictionary can still be changed in files containing them.
+			if edit.Tag, err = parseTag(tagSpec); err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrorUnknownTag, tagSpec)
+			}
+		}
+
+		switch action {
+		case BatchSet:
+			edit.Value = arg
+		case BatchClear, BatchDelete:
+			if arg != "" {
+				return nil, fmt.Errorf("%w: %s takes no value", ErrorInvalidBatchEdit, action)
+			}
+		case BatchReplace:
+			if len(arg) < 2 {
+				return nil, fmt.Errorf("%w: expected /pattern/replacement/", ErrorInvalidBatchEdit)
+			}
+			delimiter := arg[:1]
+			pattern, replacement, ok := strings.Cut(arg[1:], delimiter)
+			if !ok {
+				return nil, fmt.Errorf("%w: expected /pattern/replacement/", ErrorInvalidBatchEdit)
+			}
+			if edit.Pattern, err = regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrorInvalidBatchEdit, err)
+			}
+			edit.Value = strings.TrimSuffix(replacement, delimiter)
+		}
+		return edit, nil
+	}
+}
+
+// String describes the edit, e.g. `set AccessionNumber to "A123"`.
+func (e *ElementEdit) String() string {
+	name := fmt.Sprintf("(%04X,%04X)", e.Tag.Group, e.Tag.Element)
+	if info, err := tag.Find(e.Tag); err == nil {
+		name = info.Name
+	}
+
+	switch e.Action {
+	case BatchSet:
+		return fmt.Sprintf("set %s to %q", name, e.Value)
+	case BatchReplace:
+		return fmt.Sprintf("replace /%s/ with %q in %s", e.Pattern, e.Value, name)
+	}
+	return fmt.Sprintf("%s %s", e.Action, name)
+}
+
+// Changes returns the change of the element, which is none if the file lacks an element that is
+// not set or if its value stays the same. New values are validated against the VR of the element.
+func (e *ElementEdit) Changes(path string, ds dicom.Dataset) ([]*ElementChange, error) {
+	change, err := e.change(path, ds)
+	if err != nil || change == nil {
+		return nil, err
+	}
+	return []*ElementChange{change}, nil
+}
+
+// change returns the single change of the edit, or nil if the file is not affected.
+func (e *ElementEdit) change(path string, ds dicom.Dataset) (*ElementChange, error) {
+	tagPath := NewTagPath(e.Tag)
+	elem, err := tagPath.Find(ds.Elements)
+	if err != nil {
+		if e.Action != BatchSet {
+			return nil, nil
+		}
+		if e.VR == "" {
+			return nil, fmt.Errorf("%w: %s is missing and has no VR to add it with", ErrorUnknownTag, tagPath)
+		}
+		if _, err := NewElement(e.Tag, e.VR, e.Value); err != nil {
+			return nil, fmt.Errorf("%s: %w", tagPath, err)
+		}
+		return &ElementChange{Kind: ChangeAdd, File: path, Path: tagPath, VR: e.VR, New: e.Value}, nil
+	}
+
+	if e.Action == BatchDelete {
+		return &ElementChange{Kind: ChangeRemove, File: path, Path: tagPath}, nil
+	}
+	if !IsEditable(elem) {
+		return nil, fmt.Errorf("%s: %w", tagPath, ErrorElementNotEditable)
+	}
+
+	old := ElementText(elem)
+	value := e.Value
+	switch e.Action {
+	case BatchClear:
+		value = ""
+	case BatchReplace:
+		value = e.Pattern.ReplaceAllString(old, e.Value)
+	}
+	if value == old {
+		return nil, nil
+	}
+	if _, err := ParseElementText(elem, value); err != nil {
+		return nil, fmt.Errorf("%s: %w", tagPath, err)
+	}
+	return &ElementChange{Kind: ChangeSet, File: path, Path: tagPath, Old: old, New: value}, nil
+}
+
+// TranscodeEdit converts the files to another transfer syntax, see TranscodeChange.
+type TranscodeEdit struct {
+	// TransferSyntax is the UID of the target transfer syntax.
+	TransferSyntax string
+}
+
+func parseTranscodeEdit(args string) (BatchEdit, error) {
+	ts, err := ParseTransferSyntax(args)
+	if err != nil {
+		return nil, err
+	}
+	return &TranscodeEdit{TransferSyntax: ts}, nil
+}
+
+// String describes the edit, e.g. "transcode to Explicit VR Big Endian".
+func (e *TranscodeEdit) String() string {
+	return "transcode to " + TransferSyntaxName(e.TransferSyntax)
+}
+
+// Changes returns the change converting the file, which is none if it already uses the transfer
+// syntax.
+func (e *TranscodeEdit) Changes(path string, ds dicom.Dataset) ([]*ElementChange, error) {
+	change, err := TranscodeChange(path, ds, e.TransferSyntax)
+	if err != nil || change == nil {
+		return nil, err
+	}
+	return []*ElementChange{change}, nil
+}
+
+// FixMetaEdit regenerates inconsistent File Meta Information, see FileMetaChange.
+type FixMetaEdit struct{}
+
+func parseFixMetaEdit(args string) (BatchEdit, error) {
+	if err := noArguments(BatchFixMeta, args); err != nil {
+		return nil, err
+	}
+	return FixMetaEdit{}, nil
+}
+
+// String describes the edit.
+func (FixMetaEdit) String() string {
+	return "fix file meta information"
+}
+
+// Changes returns the change regenerating the File Meta Information of the file, which is none if
+// it is consistent.
+func (FixMetaEdit) Changes(path string, ds dicom.Dataset) ([]*ElementChange, error) {
+	change, err := FileMetaChange(path, ds)
+	if err != nil || change == nil {
+		return nil, err
+	}
+	return []*ElementChange{change}, nil
+}
+
+// parseDeidentifyEdit returns a Deidentifier, which is shared by all files, so their UIDs are
+// replaced consistently.
+func parseDeidentifyEdit(args string) (BatchEdit, error) {
+	options, err := ParseDeidentifyOptions(args)
+	if err != nil {
+		return nil, err
+	}
+	deidentifier, err := NewDeidentifier(options...)
+	if err != nil {
+		return nil, err
+	}
+	return deidentifier, nil
+}
+
+// RemapUIDsEdit replaces the UIDs of the files, see UIDRemapper.
+type RemapUIDsEdit struct {
+	// Remapper is shared by all files, so references between them are kept.
+	Remapper *UIDRemapper
+}
+
+func parseRemapUIDsEdit(args string) (BatchEdit, error) {
+	if err := noArguments(BatchRemapUIDs, args); err != nil {
+		return nil, err
+	}
+	root, err := UIDRoot()
+	if err != nil {
+		return nil, err
+	}
+	remapper, err := NewUIDRemapper(root, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &RemapUIDsEdit{Remapper: remapper}, nil
+}
+
+// String describes the edit, e.g. "remap UIDs below 2.25".
+func (e *RemapUIDsEdit) String() string {
+	return "remap UIDs below " + e.Remapper.Root()
+}
+
+// Changes returns the changes replacing the UIDs of the file, see UIDRemapper.RemapChanges.
+func (e *RemapUIDsEdit) Changes(path string, ds dicom.Dataset) ([]*ElementChange, error) {
+	return e.Remapper.RemapChanges(path, ds)
+}
+
+// StripPrivateEdit removes the private elements of the files, see PrivateTagPolicy.
+type StripPrivateEdit struct {
+	// Policy decides which private elements are kept.
+	Policy *PrivateTagPolicy
+	// All reports whether Policy keeps no private element at all.
+	All bool
+}
+
+func parseStripPrivateEdit(args string) (BatchEdit, error) {
+	switch strings.ToLower(args) {
+	case "":
+		policy, err := DefaultPrivateTagPolicy()
+		if err != nil {
+			return nil, err
+		}
+		return &StripPrivateEdit{Policy: policy}, nil
+	case "all":
+		return &StripPrivateEdit{Policy: NewPrivateTagPolicy(), All: true}, nil
+	}
+	return nil, fmt.Errorf("%w: %s takes no argument but all", ErrorInvalidBatchEdit, BatchStripPrivate)
+}
+
+// String describes the edit.
+func (e *StripPrivateEdit) String() string {
+	if e.All {
+		return "strip all private tags"
+	}
+	return "strip private tags except safe ones"
+}
+
+// Changes returns the changes removing the private elements of the file Policy does not keep.
+func (e *StripPrivateEdit) Changes(path string, ds dicom.Dataset) ([]*ElementChange, error) {
+	return e.Policy.Changes(path, ds)
+}
+
+// BlankEdit blanks regions of the pixel data of the files, see BlankChanges.
+type BlankEdit struct {
+	// Regions are the blanked pixel regions unless UsePresets is set.
+	Regions []PixelRegion
+	// UsePresets reports whether the regions of each file are picked from Presets.
+	UsePresets bool
+	// Presets are the presets the regions of each file are picked from, see MatchBlankPreset.
+	Presets []BlankPreset
+}
+
+func parseBlankEdit(args string) (BatchEdit, error) {
+	if strings.EqualFold(args, "preset") {
+		presets, err := LoadBlankPresets()
+		if err != nil {
+			return nil, err
+		}
+		return &BlankEdit{UsePresets: true, Presets: presets}, nil
+	}
+	regions, err := ParsePixelRegions(args)
+	if err != nil {
+		return nil, err
+	}
+	return &BlankEdit{Regions: regions}, nil
+}
+
+// String describes the edit, e.g. "blank pixel regions 100x20+0+0".
+func (e *BlankEdit) String() string {
+	if e.UsePresets {
+		return "blank the pixel regions of the matching presets"
+	}
+	return "blank pixel regions " + formatPixelRegions(e.Regions)
+}
+
+// Changes returns the changes blanking the regions of the file.
+func (e *BlankEdit) Changes(path string, ds dicom.Dataset) ([]*ElementChange, error) {
+	regions := e.Regions
+	if e.UsePresets {
+		preset, err := MatchBlankPreset(e.Presets, ds)
+		if err != nil {
+			return nil, err
+		}
+		regions = preset.Regions
+	}
+	return BlankChanges(path, ds, regions)
+}
+
+// BatchPreview lists the changes a batch edit makes to a set of files.
+type BatchPreview struct {
+	// Edit is the previewed edit.
+	Edit BatchEdit
+	// Files is the number of examined files.
+	Files int
+	// Changes are the changes of all affected files, in the order the files were given.
+	Changes []ElementChange
+	// Errors holds an error for every file that cannot be read or edited.
+	Errors *tyroErrors.MultiError
+}
+
+// PreviewBatchEdit computes the changes edit makes to the DICOM files at paths. Files are read
+// concurrently and the progress is reported to progress.
+//
+// The changes can be performed with History.DoWithProgress.
+func PreviewBatchEdit(paths []string, edit BatchEdit, progress Progress) BatchPreview {
+	changes := make([][]*ElementChange, len(paths))
+	fileErrs := make([]error, len(paths))
+	forEachFile(len(paths), progress, func(i int) {
+		ds, err := parseFile(paths[i], dicom.SkipPixelData())
+		if err == nil {
+			changes[i], err = edit.Changes(paths[i], ds)
+		}
+		fileErrs[i] = err
+	})
+
+	preview := BatchPreview{Edit: edit, Files: len(paths), Errors: tyroErrors.New()}
+	for i, path := range paths {
+		if fileErrs[i] != nil {
+			preview.Errors.Add(fmt.Errorf("%s: %w", path, fileErrs[i]))
+		} else {
+			for _, change := range changes[i] {
+				preview.Changes = append(preview.Changes, *change)
+			}
+		}
+	}
+	return preview
+}
@@ -0,0 +1,508 @@
+// burnedInAnnotation.go implements the detection of burned-in annotations and the blanking of
+// pixel regions.
+//
+// Ultrasound, secondary capture and video images often show the patient's name or ID as part of
+// their pixels, which de-identification of the attributes cannot remove. BurnedInAnnotationRisk
+// flags the instances whose Burned In Annotation attribute is YES and those of modalities and SOP
+// classes known to carry burned-in text.
+//
+// Blanking sets all samples within rectangles of native pixel data to zero, frame by frame. The
+// rectangles are given in the geometry notation WxH+X+Y, in pixels from the top left corner, and
+// may be restricted to frames, e.g. "640x40+0+0@1-3,5" blanks the top 40 rows of the first three
+// and the fifth frame. Compressed pixel data cannot be blanked.
+//
+// Since annotations are placed differently by every device, the rectangles can be kept as presets
+// per manufacturer and model, read from the file named by the TYRO_BLANK_PRESETS environment
+// variable, ~/.tyro/blank-presets by default. Each line holds the quoted manufacturer, the quoted
+// model, optionally the image size as COLUMNSxROWS and the rectangles, e.g.
+//
+//	"ACME Medical" "Sono 5" 800x600 800x48+0+0 200x30+600+570
+//
+// An empty model matches every model of the manufacturer, a preset without size every image size.
+// The last matching preset applies. Empty lines and lines starting with # are ignored.
+package operations
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// BlankPresetsEnv is the environment variable naming the file blanking presets are read from.
+const BlankPresetsEnv = "TYRO_BLANK_PRESETS"
+
+var (
+	// ErrorInvalidPixelRegion is returned when a pixel region cannot be parsed or lies outside the
+	// image.
+	ErrorInvalidPixelRegion = errors.New("invalid pixel region")
+	// ErrorInvalidBlankPreset is returned when a blanking preset cannot be parsed.
+	ErrorInvalidBlankPreset = errors.New("invalid blanking preset")
+	// ErrorNoBlankPreset is returned when no blanking preset matches a file.
+	ErrorNoBlankPreset = errors.New("no blanking preset")
+)
+
+// burnedInSOPClasses are the SOP classes whose images commonly carry burned-in annotations.
+var burnedInSOPClasses = map[string]string{
+	"1.2.840.10008.5.1.4.1.1.7":        "Secondary Capture",
+	"1.2.840.10008.5.1.4.1.1.7.1":      "Multi-frame Single Bit Secondary Capture",
+	"1.2.840.10008.5.1.4.1.1.7.2":      "Multi-frame Grayscale Byte Secondary Capture",
+	"1.2.840.10008.5.1.4.1.1.7.3":      "Multi-frame Grayscale Word Secondary Capture",
+	"1.2.840.10008.5.1.4.1.1.7.4":      "Multi-frame True Color Secondary Capture",
+	"1.2.840.10008.5.1.4.1.1.6":        "Ultrasound (retired)",
+	"1.2.840.10008.5.1.4.1.1.6.1":      "Ultrasound",
+	"1.2.840.10008.5.1.4.1.1.3":        "Ultrasound Multi-frame (retired)",
+	"1.2.840.10008.5.1.4.1.1.3.1":      "Ultrasound Multi-frame",
+	"1.2.840.10008.5.1.4.1.1.77.1.1.1": "Video Endoscopic",
+	"1.2.840.10008.5.1.4.1.1.77.1.2.1": "Video Microscopic",
+	"1.2.840.10008.5.1.4.1.1.77.1.4.1": "Video Photographic",
+}
+
+// burnedInModalities are the modalities whose images commonly carry burned-in annotations.
+var burnedInModalities = map[string]bool{
+	"US": true, "IVUS": true, "OT": true, "XC": true, "ES": true,
+}
+
+// BurnedInAnnotationRisk returns why the images of ds may show identifying text, or an empty
+// string if they are not at risk. A Burned In Annotation of NO is trusted over the SOP class and
+// the modality.
+func BurnedInAnnotationRisk(ds dicom.Dataset) string {
+	switch strings.ToUpper(elementString(ds.Elements, tag.BurnedInAnnotation)) {
+	case "YES":
+		return "Burned In Annotation is YES"
+	case "NO":
+		return ""
+	}
+	if class, ok := burnedInSOPClasses[elementString(ds.Elements, tag.SOPClassUID)]; ok {
+		return class + " images often carry burned-in annotations"
+	}
+	if modality := strings.ToUpper(elementString(ds.Elements, tag.Modality)); burnedInModalities[modality] {
+		return modality + " images often carry burned-in annotations"
+	}
+	return ""
+}
+
+// PixelRegion is a rectangle of pixels, optionally restricted to some frames.
+type PixelRegion struct {
+	X, Y          int
+	Width, Height int
+	// Frames are the zero based indices of the frames the region applies to, all frames if nil.
+	Frames []int
+}
+
+// pixelRegionPattern matches a region written as WxH+X+Y[@frames].
+var pixelRegionPattern = regexp.MustCompile(`^(\d+)x(\d+)\+(\d+)\+(\d+)(?:@([0-9,-]+))?$`)
+
+// ParsePixelRegions parses regions written as WxH+X+Y[@frames] and separated by spaces, where
+// frames lists one based frame numbers and ranges, e.g. "1-3,5".
+func ParsePixelRegions(spec string) ([]PixelRegion, error) {
+	var regions []PixelRegion
+	for _, field := range strings.Fields(spec) {
+		region, err := parsePixelRegion(field)
+		if err != nil {
+			return nil, err
+		}
+		regions = append(regions, region)
+	}
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("%w: expected WxH+X+Y[@frames]", ErrorInvalidPixelRegion)
+	}
+	return regions, nil
+}
+
+// parsePixelRegion parses a single region written as WxH+X+Y[@frames].
+func parsePixelRegion(spec string) (PixelRegion, error) {
+	match := pixelRegionPattern.FindStringSubmatch(spec)
+	if match == nil {
+		return PixelRegion{}, fmt.Errorf("%w: %q, expected WxH+X+Y[@frames]", ErrorInvalidPixelRegion, spec)
+	}
+	var numbers [4]int
+	for i := range numbers {
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return PixelRegion{}, fmt.Errorf("%w: %q: %v", ErrorInvalidPixelRegion, spec, err)
+		}
+		numbers[i] = n
+	}
+	region := PixelRegion{Width: numbers[0], Height: numbers[1], X: numbers[2], Y: numbers[3]}
+	if region.Width == 0 || region.Height == 0 {
+		return PixelRegion{}, fmt.Errorf("%w: %q is empty", ErrorInvalidPixelRegion, spec)
+	}
+
+	if match[5] == "" {
+		return region, nil
+	}
+	region.Frames = []int{}
+	for _, part := range strings.Split(match[5], ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		if !isRange {
+			last = first
+		}
+		from, fromErr := strconv.Atoi(first)
+		to, toErr := strconv.Atoi(last)
+		if fromErr != nil || toErr != nil || from < 1 || to < from {
+			return PixelRegion{}, fmt.Errorf("%w: %q, frames are numbered from 1", ErrorInvalidPixelRegion, spec)
+		}
+		for frame := from; frame <= to; frame++ {
+			region.Frames = append(region.Frames, frame-1)
+		}
+	}
+	return region, nil
+}
+
+// String returns the region in the notation of ParsePixelRegions.
+func (r PixelRegion) String() string {
+	text := fmt.Sprintf("%dx%d+%d+%d", r.Width, r.Height, r.X, r.Y)
+	if r.Frames == nil {
+		return text
+	}
+	var ranges []string
+	for i := 0; i < len(r.Frames); i++ {
+		j := i
+		for j+1 < len(r.Frames) && r.Frames[j+1] == r.Frames[j]+1 {
+			j++
+		}
+		if j == i {
+			ranges = append(ranges, strconv.Itoa(r.Frames[i]+1))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", r.Frames[i]+1, r.Frames[j]+1))
+		}
+		i = j
+	}
+	return text + "@" + strings.Join(ranges, ",")
+}
+
+// formatPixelRegions returns regions in the notation of ParsePixelRegions.
+func formatPixelRegions(regions []PixelRegion) string {
+	texts := make([]string, len(regions))
+	for i, region := range regions {
+		texts[i] = region.String()
+	}
+	return strings.Join(texts, " ")
+}
+
+// BlankPreset holds the regions blanked in the images of a device.
+type BlankPreset struct {
+	// Manufacturer is matched against the Manufacturer of a file, ignoring case.
+	Manufacturer string
+	// Model is matched against the Manufacturer's Model Name, ignoring case. An empty model
+	// matches all models.
+	Model string
+	// Columns and Rows restrict the preset to images of this size unless they are 0.
+	Columns, Rows int
+	Regions       []PixelRegion
+}
+
+// imageSizePattern matches an image size written as COLUMNSxROWS.
+var imageSizePattern = regexp.MustCompile(`^(\d+)x(\d+)$`)
+
+// ParseBlankPreset parses a preset written as
+// `"<manufacturer>" "<model>" [<columns>x<rows>] <region> ...`.
+func ParseBlankPreset(spec string) (BlankPreset, error) {
+	var preset BlankPreset
+	rest := strings.TrimSpace(spec)
+	for _, field := range []*string{&preset.Manufacturer, &preset.Model} {
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return BlankPreset{}, fmt.Errorf("%w: %q, expected a quoted manufacturer and model", ErrorInvalidBlankPreset, spec)
+		}
+		*field, _ = strconv.Unquote(quoted)
+		*field = strings.TrimSpace(*field)
+		rest = strings.TrimSpace(rest[len(quoted):])
+	}
+	if preset.Manufacturer == "" {
+		return BlankPreset{}, fmt.Errorf("%w: %q has no manufacturer", ErrorInvalidBlankPreset, spec)
+	}
+
+	if size, regions, _ := strings.Cut(rest, " "); imageSizePattern.MatchString(size) {
+		match := imageSizePattern.FindStringSubmatch(size)
+		preset.Columns, _ = strconv.Atoi(match[1])
+		preset.Rows, _ = strconv.Atoi(match[2])
+		rest = regions
+	}
+	regions, err := ParsePixelRegions(rest)
+	if err != nil {
+		return BlankPreset{}, err
+	}
+	preset.Regions = regions
+	return preset, nil
+}
+
+// matches returns whether the preset applies to the images of ds.
+func (p BlankPreset) matches(ds dicom.Dataset) bool {
+	if !strings.EqualFold(p.Manufacturer, elementString(ds.Elements, tag.Manufacturer)) {
+		return false
+	}
+	if p.Model != "" && !strings.EqualFold(p.Model, elementString(ds.Elements, tag.ManufacturerModelName)) {
+		return false
+	}
+	if p.Columns == 0 {
+		return true
+	}
+	columns, columnsErr := intAttribute(ds, tag.Columns)
+	rows, rowsErr := intAttribute(ds, tag.Rows)
+	return columnsErr == nil && rowsErr == nil && columns == p.Columns && rows == p.Rows
+}
+
+// BlankPresetsPath returns the location of the file holding the blanking presets.
+func BlankPresetsPath() (string, error) {
+	if path := os.Getenv(BlankPresetsEnv); path != "" {
+		return filepath.Abs(path)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine blanking preset location, set %s: %w", BlankPresetsEnv, err)
+	}
+	return filepath.Join(home, ".tyro", "blank-presets"), nil
+}
+
+// LoadBlankPresets reads the presets of the file at BlankPresetsPath. A missing file holds no
+// presets.
+func LoadBlankPresets() ([]BlankPreset, error) {
+	path, err := BlankPresetsPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var presets []BlankPreset
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		preset, err := ParseBlankPreset(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		presets = append(presets, preset)
+	}
+	return presets, scanner.Err()
+}
+
+// MatchBlankPreset returns the last of presets matching the manufacturer, model and image size of
+// ds, or ErrorNoBlankPreset.
+func MatchBlankPreset(presets []BlankPreset, ds dicom.Dataset) (BlankPreset, error) {
+	for i := len(presets) - 1; i >= 0; i-- {
+		if presets[i].matches(ds) {
+			return presets[i], nil
+		}
+	}
+	return BlankPreset{}, fmt.Errorf("%w for %q %q", ErrorNoBlankPreset, elementString(ds.Elements, tag.Manufacturer), elementString(ds.Elements, tag.ManufacturerModelName))
+}
+
+// BlankChanges returns the changes blanking regions in the pixel data of ds, the dataset of the
+// file at path, which may have been parsed without its pixel data. Burned In Annotation is set to
+// NO, since the annotations are assumed to lie within the regions. The changes are redacting, so
+// the blanked pixels are not kept, see ElementChange.Redact.
+func BlankChanges(path string, ds dicom.Dataset, regions []PixelRegion) ([]*ElementChange, error) {
+	if ts := transferSyntaxOf(ds); !isNativeTransferSyntax(ts) {
+		return nil, fmt.Errorf("%w: %s pixel data cannot be blanked", ErrorUnsupportedPixelData, TransferSyntaxName(ts))
+	}
+	elem, err := ds.FindElementByTag(tag.PixelData)
+	if err != nil {
+		return nil, ErrorNoPixelData
+	}
+	if dicom.MustGetPixelDataInfo(elem.Value).IsEncapsulated {
+		return nil, fmt.Errorf("%w: pixel data is encapsulated", ErrorUnsupportedPixelData)
+	}
+	info, err := ReadImageInfo(ds)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := blankSpans(info, regions); err != nil {
+		return nil, err
+	}
+
+	changes := []*ElementChange{{Kind: ChangeBlank, File: path, Path: NewTagPath(tag.PixelData), New: formatPixelRegions(regions)}}
+	annotation := NewTagPath(tag.BurnedInAnnotation)
+	if elem, err := annotation.Find(ds.Elements); err != nil {
+		changes = append(changes, &ElementChange{Kind: ChangeAdd, File: path, Path: annotation, VR: "CS", New: "NO"})
+	} else if old := ElementText(elem); old != "NO" {
+		changes = append(changes, &ElementChange{Kind: ChangeSet, File: path, Path: annotation, Old: old, New: "NO"})
+	}
+	return redact(changes), nil
+}
+
+// sampleSpan is a run of consecutive samples of native pixel data, given by the index of its
+// first sample and the index following its last sample.
+type sampleSpan struct {
+	first, end int
+}
+
+// blankSpans returns the runs of samples covered by regions in the pixel data described by info,
+// in the order they are blanked. Regions are clipped to the image, regions outside of it and
+// frames that do not exist are an error. YBR_FULL_422 pixels share their chroma samples in pairs,
+// so regions are widened to whole pairs.
+func blankSpans(info ImageInfo, regions []PixelRegion) ([]sampleSpan, error) {
+	switch info.BitsAllocated {
+	case 1, 8, 16, 32:
+	default:
+		return nil, fmt.Errorf("%w: %d bits allocated", ErrorUnsupportedPixelData, info.BitsAllocated)
+	}
+	subsampled := isSubsampled(info)
+	if subsampled && (info.Columns%2 != 0 || info.PlanarConfiguration != 0) {
+		return nil, fmt.Errorf("%w: YBR_FULL_422 with %d columns and planar configuration %d", ErrorUnsupportedPixelData, info.Columns, info.PlanarConfiguration)
+	}
+
+	var spans []sampleSpan
+	pixels := info.Rows * info.Columns
+	for _, region := range regions {
+		if region.X >= info.Columns || region.Y >= info.Rows {
+			return nil, fmt.Errorf("%w: %s lies outside the %dx%d image", ErrorInvalidPixelRegion, region, info.Columns, info.Rows)
+		}
+		x := region.X
+		width := min(region.Width, info.Columns-region.X)
+		height := min(region.Height, info.Rows-region.Y)
+		if subsampled {
+			x = region.X &^ 1
+			width = min(region.X+width+1, info.Columns)&^1 - x
+		}
+
+		frames := region.Frames
+		if frames == nil {
+			frames = make([]int, info.NumberOfFrames)
+			for i := range frames {
+				frames[i] = i
+			}
+		}
+		for _, frame := range frames {
+			if frame >= info.NumberOfFrames {
+				return nil, fmt.Errorf("%w: %s, the image has %d frames", ErrorInvalidPixelRegion, region, info.NumberOfFrames)
+			}
+			base := frame * frameSamples(info)
+			for row := region.Y; row < region.Y+height; row++ {
+				pixel := row*info.Columns + x
+				if subsampled {
+					first := base + pixel*2
+					spans = append(spans, sampleSpan{first, first + width*2})
+					continue
+				}
+				if info.PlanarConfiguration == 1 && info.SamplesPerPixel > 1 {
+					for plane := 0; plane < info.SamplesPerPixel; plane++ {
+						first := base + plane*pixels + pixel
+						spans = append(spans, sampleSpan{first, first + width})
+					}
+					continue
+				}
+				first := base + pixel*info.SamplesPerPixel
+				spans = append(spans, sampleSpan{first, first + width*info.SamplesPerPixel})
+			}
+		}
+	}
+	return spans, nil
+}
+
+// blankPixelData sets the samples within regions of the native pixel data of ds to zero and
+// returns the bytes it overwrote, see restorePixelData. The bytes are only kept in memory to revert
+// a failed operation, since ChangeBlank is redacting. Datasets parsed without their pixel data are
+// left unchanged, so blanking can be staged.
+func blankPixelData(ds *dicom.Dataset, regions []PixelRegion) ([]byte, error) {
+	data, info, spans, err := pixelDataSpans(*ds, regions)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	blanked := append([]byte(nil), data...)
+	var old []byte
+	for _, span := range spans {
+		first, end := spanBytes(span, info.BitsAllocated)
+		old = append(old, blanked[first:end]...)
+		if info.BitsAllocated == 1 {
+			for i := span.first; i < span.end; i++ {
+				blanked[i/8] &^= 1 << (i % 8)
+			}
+			continue
+		}
+		clear(blanked[first:end])
+	}
+	return old, setPixelData(ds, blanked)
+}
+
+// restorePixelData reverts blankPixelData, writing the bytes old it overwrote back to the pixel
+// data of ds.
+func restorePixelData(ds *dicom.Dataset, regions []PixelRegion, old []byte) error {
+	data, info, spans, err := pixelDataSpans(*ds, regions)
+	if err != nil || data == nil {
+		return err
+	}
+
+	restored := append([]byte(nil), data...)
+	// Overlapping regions are restored in reverse, so the original bytes are restored last.
+	end := len(old)
+	for i := len(spans) - 1; i >= 0; i-- {
+		first, last := spanBytes(spans[i], info.BitsAllocated)
+		if end < last-first {
+			return fmt.Errorf("recorded pixel data is corrupt")
+		}
+		copy(restored[first:last], old[end-(last-first):end])
+		end -= last - first
+	}
+	if end != 0 {
+		return fmt.Errorf("recorded pixel data is corrupt")
+	}
+	return setPixelData(ds, restored)
+}
+
+// pixelDataSpans returns the raw native pixel data of ds, its geometry and the runs of samples
+// covered by regions. The data is nil if ds has been parsed without its pixel data.
+func pixelDataSpans(ds dicom.Dataset, regions []PixelRegion) ([]byte, ImageInfo, []sampleSpan, error) {
+	elem, err := ds.FindElementByTag(tag.PixelData)
+	if err != nil {
+		return nil, ImageInfo{}, nil, ErrorNoPixelData
+	}
+	pixelInfo := dicom.MustGetPixelDataInfo(elem.Value)
+	if pixelInfo.IsEncapsulated {
+		return nil, ImageInfo{}, nil, fmt.Errorf("%w: pixel data is encapsulated", ErrorUnsupportedPixelData)
+	}
+	if !pixelInfo.IntentionallyUnprocessed {
+		return nil, ImageInfo{}, nil, nil
+	}
+
+	info, err := ReadImageInfo(ds)
+	if err != nil {
+		return nil, info, nil, err
+	}
+	spans, err := blankSpans(info, regions)
+	if err != nil {
+		return nil, info, nil, err
+	}
+	data := pixelInfo.UnprocessedValueData
+	if last, _ := spanBytes(sampleSpan{0, frameSamples(info) * info.NumberOfFrames}, info.BitsAllocated); len(data) < last {
+		return nil, info, nil, fmt.Errorf("%w: expected %d bytes of pixel data but found %d", ErrorUnsupportedPixelData, last, len(data))
+	}
+	return data, info, spans, nil
+}
+
+// spanBytes returns the range of bytes holding the samples of span.
+func spanBytes(span sampleSpan, bitsAllocated int) (int, int) {
+	return span.first * bitsAllocated / 8, (span.end*bitsAllocated + 7) / 8
+}
+
+// setPixelData replaces the raw native pixel data of ds with data.
+func setPixelData(ds *dicom.Dataset, data []byte) error {
+	elem, err := ds.FindElementByTag(tag.PixelData)
+	if err != nil {
+		return ErrorNoPixelData
+	}
+	value, err := dicom.NewValue(dicom.PixelDataInfo{IntentionallyUnprocessed: true, UnprocessedValueData: data})
+	if err != nil {
+		return err
+	}
+	elem.Value = value
+	return nil
+}
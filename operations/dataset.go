@@ -0,0 +1,122 @@
+// dataset.go provides helpers to modify the top level elements of a parsed DICOM dataset.
+//
+// The DICOM library exposes the elements of a dataset as a plain slice. The helpers in this file
+// replace or insert elements while keeping the slice ordered by tag, as required when writing.
+package operations
+
+import (
+	"strings"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// setElement replaces the top level element with the same tag as elem or inserts elem in tag order.
+func setElement(ds *dicom.Dataset, elem *dicom.Element) {
+	ds.Elements = insertElement(ds.Elements, elem)
+}
+
+// insertElement replaces the element with the same tag as elem in elems or inserts elem in tag
+// order. Returns the modified list.
+func insertElement(elems []*dicom.Element, elem *dicom.Element) []*dicom.Element {
+	for i, existing := range elems {
+		switch existing.Tag.Compare(elem.Tag) {
+		case 0:
+			elems[i] = elem
+			return elems
+		case 1:
+			return append(elems[:i], append([]*dicom.Element{elem}, elems[i:]...)...)
+		}
+	}
+	return append(elems, elem)
+}
+
+// removeElement removes the top level element with the given tag. It reports whether an element was removed.
+func removeElement(ds *dicom.Dataset, t tag.Tag) bool {
+	for i, existing := range ds.Elements {
+		if existing.Tag == t {
+			ds.Elements = append(ds.Elements[:i], ds.Elements[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// setStringElement sets the top level element with the given tag to the given string values.
+//
+// The VR is taken from the data dictionary.
+func setStringElement(ds *dicom.Dataset, t tag.Tag, values ...string) error {
+	elem, err := dicom.NewElement(t, values)
+	if err != nil {
+		return err
+	}
+	setElement(ds, elem)
+	return nil
+}
+
+// setIntElement sets the top level element with the given tag to the given integer values.
+//
+// The VR is taken from the data dictionary.
+func setIntElement(ds *dicom.Dataset, t tag.Tag, values ...int) error {
+	elem, err := dicom.NewElement(t, values)
+	if err != nil {
+		return err
+	}
+	setElement(ds, elem)
+	return nil
+}
+
+// findElement returns the element with the given tag from a list of elements, such as the
+// elements of a sequence item, or nil if there is none.
+func findElement(elems []*dicom.Element, t tag.Tag) *dicom.Element {
+	for _, elem := range elems {
+		if elem.Tag == t {
+			return elem
+		}
+	}
+	return nil
+}
+
+// elementStrings returns the string values of the element with the given tag from a list of
+// elements, or nil if the element does not exist or does not hold strings.
+func elementStrings(elems []*dicom.Element, t tag.Tag) []string {
+	elem := findElement(elems, t)
+	if elem == nil {
+		return nil
+	}
+	values, _ := elem.Value.GetValue().([]string)
+	return values
+}
+
+// elementString returns the first string value of the element with the given tag from a list of
+// elements with surrounding whitespace removed, or an empty string.
+func elementString(elems []*dicom.Element, t tag.Tag) string {
+	values := elementStrings(elems, t)
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(values[0])
+}
+
+// sequenceItems returns the elements of every item of the sequence element with the given tag
+// from a list of elements. It returns nil if the element does not exist or is not a sequence.
+func sequenceItems(elems []*dicom.Element, t tag.Tag) [][]*dicom.Element {
+	elem := findElement(elems, t)
+	if elem == nil {
+		return nil
+	}
+	return itemsOf(elem)
+}
+
+// itemsOf returns the elements of every item of a sequence element or nil if elem is not a sequence.
+func itemsOf(elem *dicom.Element) [][]*dicom.Element {
+	items, ok := elem.Value.GetValue().([]*dicom.SequenceItemValue)
+	if !ok {
+		return nil
+	}
+	result := make([][]*dicom.Element, len(items))
+	for i, item := range items {
+		result[i], _ = item.GetValue().([]*dicom.Element)
+	}
+	return result
+}
@@ -0,0 +1,167 @@
+// deflate.go provides support for the Deflated Explicit VR Little Endian transfer syntax.
+//
+// In deflated files the File Meta Information is stored uncompressed, while the remaining data set
+// is an Explicit VR Little Endian stream compressed with the raw deflate algorithm (RFC 1951) as
+// described in PS3.5 A.5. The DICOM library treats the transfer syntax like Explicit VR Little
+// Endian without inflating the data set, so reading and writing such files is handled here.
+package operations
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+	"github.com/suyashkumar/dicom/pkg/uid"
+)
+
+// ErrorInvalidFileMeta is returned when the File Meta Information of a file cannot be read.
+var ErrorInvalidFileMeta = errors.New("invalid file meta information")
+
+// metaElementHeaderSize is the size of an explicit VR element header with a 2 byte length field.
+const metaElementHeaderSize = 8
+
+// IsDeflated reports whether the dataset is encoded with the Deflated Explicit VR Little Endian transfer syntax.
+func IsDeflated(ds dicom.Dataset) bool {
+	return transferSyntaxOf(ds) == uid.DeflatedExplicitVRLittleEndian
+}
+
+// inflatingReader returns a reader yielding the content of r with a deflated data set inflated.
+//
+// The preamble and File Meta Information are passed through unchanged, except for a wrong or
+// missing File Meta Information Group Length, which is corrected. If the transfer syntax is not
+// deflated, the returned reader yields the remaining content of r unchanged.
+func inflatingReader(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	header, transferSyntax, err := readFileMetaBytes(buffered)
+	if err != nil {
+		return nil, err
+	}
+	header = correctGroupLength(header)
+
+	if transferSyntax != uid.DeflatedExplicitVRLittleEndian {
+		return io.MultiReader(bytes.NewReader(header), buffered), nil
+	}
+	return io.MultiReader(bytes.NewReader(header), flate.NewReader(buffered)), nil
+}
+
+// readFileMetaBytes reads the preamble, the DICM prefix and all group 0002 elements from r.
+//
+// Returns the bytes read and the value of the Transfer Syntax UID element, if present.
+// The reader is left positioned at the first element of the data set.
+func readFileMetaBytes(r *bufio.Reader) ([]byte, string, error) {
+	header := make([]byte, 132)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, "", err
+	}
+
+	transferSyntax := ""
+	for {
+		peek, err := r.Peek(metaElementHeaderSize)
+		if err == io.EOF || (err == nil && binary.LittleEndian.Uint16(peek) != tag.MetadataGroup) {
+			return header, transferSyntax, nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		element := binary.LittleEndian.Uint16(peek[2:])
+		vr := string(peek[4:6])
+		elemHeader := make([]byte, metaElementHeaderSize)
+		if _, err := io.ReadFull(r, elemHeader); err != nil {
+			return nil, "", err
+		}
+		length := uint32(binary.LittleEndian.Uint16(elemHeader[6:]))
+		if hasLongLength(vr) {
+			extra := make([]byte, 4)
+			if _, err := io.ReadFull(r, extra); err != nil {
+				return nil, "", err
+			}
+			elemHeader = append(elemHeader, extra...)
+			length = binary.LittleEndian.Uint32(extra)
+		}
+		if length == tag.VLUndefinedLength {
+			return nil, "", ErrorInvalidFileMeta
+		}
+
+		value := make([]byte, length)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, "", err
+		}
+		header = append(header, elemHeader...)
+		header = append(header, value...)
+
+		if element == tag.TransferSyntaxUID.Element {
+			transferSyntax = strings.TrimRight(string(value), " \x00")
+		}
+	}
+}
+
+// hasLongLength reports whether an explicit VR element with the given VR uses a 4 byte length field.
+func hasLongLength(vr string) bool {
+	switch vr {
+	case "OB", "OD", "OF", "OL", "OV", "OW", "SQ", "SV", "UC", "UN", "UR", "UT", "UV":
+		return true
+	}
+	return false
+}
+
+// writeDeflated writes the encoded file to out, deflating everything following the File Meta Information.
+//
+// encoded must be a complete file as produced by dicom.Write, including the File Meta Information
+// Group Length element that is used to locate the end of the meta header.
+func writeDeflated(out io.Writer, encoded []byte) error {
+	// preamble (128), DICM (4) and the group length element (12)
+	const groupLengthEnd = 132 + 12
+	if len(encoded) < groupLengthEnd {
+		return ErrorInvalidFileMeta
+	}
+	metaEnd := groupLengthEnd + int(binary.LittleEndian.Uint32(encoded[groupLengthEnd-4:]))
+	if metaEnd > len(encoded) {
+		return ErrorInvalidFileMeta
+	}
+	return writeDeflatedParts(out, encoded[:metaEnd], encoded[metaEnd:])
+}
+
+// writeDeflatedParts writes header, the preamble and File Meta Information, followed by the
+// deflated data set to out.
+func writeDeflatedParts(out io.Writer, header []byte, dataset []byte) error {
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+
+	compressed := &bytes.Buffer{}
+	deflater, err := flate.NewWriter(compressed, flate.DefaultCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := deflater.Write(dataset); err != nil {
+		return err
+	}
+	if err := deflater.Close(); err != nil {
+		return err
+	}
+	// The deflated bitstream is padded to an even length as required by PS3.5 A.5.
+	if compressed.Len()%2 != 0 {
+		compressed.WriteByte(0)
+	}
+
+	_, err = out.Write(compressed.Bytes())
+	return err
+}
+
+// SetDeflated changes the transfer syntax of ds to Deflated Explicit VR Little Endian, so that
+// WriteDataset produces deflated output.
+//
+// The data set must use native pixel data, as deflate is only defined for uncompressed encodings.
+func SetDeflated(ds *dicom.Dataset) error {
+	if elem, err := ds.FindElementByTag(tag.PixelData); err == nil && dicom.MustGetPixelDataInfo(elem.Value).IsEncapsulated {
+		return ErrorUnsupportedPixelData
+	}
+	return setStringElement(ds, tag.TransferSyntaxUID, uid.DeflatedExplicitVRLittleEndian)
+}
@@ -0,0 +1,1050 @@
+// deidentify.go implements de-identification modelled on the Basic Application Level
+// Confidentiality Profile of DICOM PS3.15 Annex E.
+//
+// Every attribute of profileTable, which holds the attributes of the profile's action table known
+// to tyro, is dummied (D), zeroed (Z), removed (X), kept (K), cleaned (C) or has its UIDs replaced
+// (U). The profile options, e.g. Retain Patient Characteristics or Clean Descriptors, change the
+// action of the attributes they cover. The table applies at every nesting level: sequences that are
+// kept are de-identified item by item. Private elements, curves and overlay comments are removed.
+// The retain-safe-private option keeps the private elements of the default PrivateTagPolicy
+// instead.
+//
+// profileTable does not cover every attribute of Table E.1-1, so attributes missing from it are
+// judged by their VR and keyword, see fallbackEntry: person names, dates and times, identifiers and
+// free text are removed, all other attributes are kept. The result is therefore not guaranteed to
+// conform to the profile and should be checked, e.g. with a PHIScanner.
+//
+// Compound actions of the standard depend on the IOD an attribute belongs to, which tyro does not
+// know. They are resolved to the action keeping the file valid for all IODs: X/Z becomes Z and
+// X/D, Z/D and X/Z/D become D. Sequences that are zeroed or dummied lose all of their items.
+//
+// With the pseudonymize option, the patient and accession identifiers are replaced with the
+// pseudonyms of a PseudonymStore, so the same patient gets the same pseudonym in every run. The
+// shift-dates option keeps all dates but shifts them by the date offset of the patient in the
+// store, which preserves the intervals between the studies of a patient.
+//
+// Cleaning removes the names, IDs and birth date of the patient from a value, since tyro cannot
+// judge whether other content is identifying. UIDs are replaced by a UIDRemapper shared by all
+// files de-identified by a Deidentifier, so references between the files are kept. Besides the
+// UIDs of the action table, every UI attribute referencing an instance is replaced.
+//
+// De-identification is computed as a list of redacting element changes, which are performed and
+// staged like any other modification. The previous values are neither journaled nor backed up, so
+// a de-identification cannot be undone.
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// ErrorUnknownDeidentifyOption is returned when a de-identification option cannot be parsed.
+var ErrorUnknownDeidentifyOption = errors.New("unknown de-identification option")
+
+// ErrorConflictingDeidentifyOptions is returned when de-identification options cannot be combined.
+var ErrorConflictingDeidentifyOptions = errors.New("conflicting de-identification options")
+
+// DeidentifyAction is an action of the PS3.15 Annex E action table.
+type DeidentifyAction byte
+
+const (
+	// ActionDummy replaces the value with a dummy value of the same VR.
+	ActionDummy DeidentifyAction = 'D'
+	// ActionZero replaces the value with an empty value.
+	ActionZero DeidentifyAction = 'Z'
+	// ActionRemove removes the element.
+	ActionRemove DeidentifyAction = 'X'
+	// ActionKeep keeps the element. The items of kept sequences are de-identified.
+	ActionKeep DeidentifyAction = 'K'
+	// ActionClean removes identifying information from the value, see cleanText.
+	ActionClean DeidentifyAction = 'C'
+	// ActionUID replaces every UID of the value with a UID derived from it.
+	ActionUID DeidentifyAction = 'U'
+	// ActionShift shifts dates by the date offset of the patient, see OptionShiftDates. The
+	// standard marks the attributes of this option with C and leaves the modification to the
+	// implementation.
+	ActionShift DeidentifyAction = 'S'
+)
+
+// DeidentifyOption is an option of the Basic Application Level Confidentiality Profile.
+type DeidentifyOption string
+
+const (
+	// OptionRetainDates keeps dates and times, i.e. Retain Longitudinal Temporal Information
+	// with Full Dates.
+	OptionRetainDates DeidentifyOption = "retain-dates"
+	// OptionShiftDates keeps dates and times after shifting the dates by a random number of days
+	// that is stable per patient, i.e. Retain Longitudinal Temporal Information with Modified
+	// Dates. It requires OptionPseudonymize, since the offsets are kept in the pseudonym store.
+	OptionShiftDates DeidentifyOption = "shift-dates"
+	// OptionRetainPatient keeps the age, sex, size and similar characteristics of the patient.
+	OptionRetainPatient DeidentifyOption = "retain-patient"
+	// OptionRetainDevice keeps the identity of the devices, e.g. station names and serial numbers.
+	OptionRetainDevice DeidentifyOption = "retain-device"
+	// OptionRetainInstitution keeps the identity of the institution.
+	OptionRetainInstitution DeidentifyOption = "retain-institution"
+	// OptionRetainUIDs keeps all UIDs.
+	OptionRetainUIDs DeidentifyOption = "retain-uids"
+	// OptionCleanDescriptors keeps descriptions and comments after cleaning them.
+	OptionCleanDescriptors DeidentifyOption = "clean-descriptors"
+	// OptionCleanStructured keeps structured content, e.g. SR content, after cleaning it.
+	OptionCleanStructured DeidentifyOption = "clean-structured"
+	// OptionCleanGraphics keeps overlays, curves and graphic annotations.
+	OptionCleanGraphics DeidentifyOption = "clean-graphics"
+	// OptionRetainSafePrivate keeps, removes or cleans private elements according to the default
+	// private tag policy, see DefaultPrivateTagPolicy, instead of removing all of them.
+	OptionRetainSafePrivate DeidentifyOption = "retain-safe-private"
+	// OptionPseudonymize replaces the Patient ID, Patient Name and Accession Number with the
+	// pseudonyms of the default pseudonym store instead of zeroing them. It is not a PS3.15 option
+	// and is not recorded in the De-identification Method Code Sequence.
+	OptionPseudonymize DeidentifyOption = "pseudonymize"
+)
+
+// deidentifyCode is an entry of CID 7050, the codes of the De-identification Method Code Sequence.
+type deidentifyCode struct {
+	value   string
+	meaning string
+}
+
+// basicProfileCode identifies the Basic Application Level Confidentiality Profile.
+var basicProfileCode = deidentifyCode{"113100", "Basic Application Confidentiality Profile"}
+
+// deidentifyOptionInfo describes an option of the profile.
+type deidentifyOptionInfo struct {
+	option DeidentifyOption
+	// code records the option in the De-identification Method Code Sequence.
+	code deidentifyCode
+	// column is the option whose column of the action table applies to the option.
+	column DeidentifyOption
+	// action is the action of the attributes covered by the option if it is enabled.
+	action DeidentifyAction
+}
+
+// deidentifyOptions lists all options in the order they are recorded.
+var deidentifyOptions = []deidentifyOptionInfo{
+	{OptionCleanGraphics, deidentifyCode{"113103", "Clean Graphics Option"}, OptionCleanGraphics, ActionClean},
+	{OptionCleanStructured, deidentifyCode{"113104", "Clean Structured Content Option"}, OptionCleanStructured, ActionClean},
+	{OptionCleanDescriptors, deidentifyCode{"113105", "Clean Descriptors Option"}, OptionCleanDescriptors, ActionClean},
+	{OptionRetainDates, deidentifyCode{"113106", "Retain Longitudinal Temporal Information Full Dates Option"}, OptionRetainDates, ActionKeep},
+	{OptionShiftDates, deidentifyCode{"113107", "Retain Longitudinal Temporal Information Modified Dates Option"}, OptionRetainDates, ActionShift},
+	{OptionRetainPatient, deidentifyCode{"113108", "Retain Patient Characteristics Option"}, OptionRetainPatient, ActionKeep},
+	{OptionRetainDevice, deidentifyCode{"113109", "Retain Device Identity Option"}, OptionRetainDevice, ActionKeep},
+	{OptionRetainUIDs, deidentifyCode{"113110", "Retain UIDs Option"}, OptionRetainUIDs, ActionKeep},
+	{OptionRetainSafePrivate, deidentifyCode{"113111", "Retain Safe Private Option"}, OptionRetainSafePrivate, ActionKeep},
+	{OptionRetainInstitution, deidentifyCode{"113112", "Retain Institution Identity Option"}, OptionRetainInstitution, ActionKeep},
+}
+
+// profileEntry is the treatment of an attribute by the Basic Application Level Confidentiality
+// Profile.
+type profileEntry struct {
+	// action is the action of the profile without options.
+	action DeidentifyAction
+	// options are the options changing the action.
+	options []DeidentifyOption
+}
+
+// Shorthands for the options of profileTable.
+var (
+	retainDates       = []DeidentifyOption{OptionRetainDates}
+	retainPatient     = []DeidentifyOption{OptionRetainPatient}
+	retainDevice      = []DeidentifyOption{OptionRetainDevice}
+	retainInstitution = []DeidentifyOption{OptionRetainInstitution}
+	retainUIDs        = []DeidentifyOption{OptionRetainUIDs}
+	cleanDescriptors  = []DeidentifyOption{OptionCleanDescriptors}
+	cleanStructured   = []DeidentifyOption{OptionCleanStructured}
+	cleanGraphics     = []DeidentifyOption{OptionCleanGraphics}
+)
+
+// profileTable holds the attributes of the action table of PS3.15 Annex E, Table E.1-1, that are
+// known to tyro. Attributes are given by keyword, or as (gggg,eeee) if they are missing from the
+// data dictionary of the DICOM library.
+var profileTable = []struct {
+	attribute string
+	profileEntry
+}{
+	// Patient
+	{"PatientName", profileEntry{ActionZero, nil}},
+	{"PatientID", profileEntry{ActionZero, nil}},
+	{"IssuerOfPatientID", profileEntry{ActionRemove, nil}},
+	{"IssuerOfPatientIDQualifiersSequence", profileEntry{ActionRemove, nil}},
+	{"PatientBirthDate", profileEntry{ActionZero, retainDates}},
+	{"PatientBirthTime", profileEntry{ActionRemove, retainDates}},
+	{"(0010,0033)", profileEntry{ActionRemove, nil}}, // PatientBirthDateInAlternativeCalendar
+	{"(0010,0034)", profileEntry{ActionRemove, nil}}, // PatientDeathDateInAlternativeCalendar
+	{"(0010,0035)", profileEntry{ActionRemove, nil}}, // PatientAlternativeCalendar
+	{"PatientSex", profileEntry{ActionZero, retainPatient}},
+	{"PatientSexNeutered", profileEntry{ActionZero, retainPatient}},
+	{"PatientAge", profileEntry{ActionRemove, retainPatient}},
+	{"PatientSize", profileEntry{ActionRemove, retainPatient}},
+	{"PatientWeight", profileEntry{ActionRemove, retainPatient}},
+	{"EthnicGroup", profileEntry{ActionRemove, retainPatient}},
+	{"SmokingStatus", profileEntry{ActionRemove, retainPatient}},
+	{"PregnancyStatus", profileEntry{ActionRemove, retainPatient}},
+	{"LastMenstrualDate", profileEntry{ActionRemove, retainDates}},
+	{"MedicalAlerts", profileEntry{ActionRemove, retainPatient}},
+	{"Allergies", profileEntry{ActionRemove, retainPatient}},
+	{"SpecialNeeds", profileEntry{ActionRemove, retainPatient}},
+	{"PatientState", profileEntry{ActionRemove, retainPatient}},
+	{"AdditionalPatientHistory", profileEntry{ActionRemove, []DeidentifyOption{OptionRetainPatient, OptionCleanDescriptors}}},
+	{"PatientComments", profileEntry{ActionRemove, cleanDescriptors}},
+	{"Occupation", profileEntry{ActionRemove, cleanDescriptors}},
+	{"OtherPatientIDs", profileEntry{ActionRemove, nil}},
+	{"OtherPatientIDsSequence", profileEntry{ActionRemove, nil}},
+	{"OtherPatientNames", profileEntry{ActionRemove, nil}},
+	{"PatientBirthName", profileEntry{ActionRemove, nil}},
+	{"PatientMotherBirthName", profileEntry{ActionRemove, nil}},
+	{"PatientAddress", profileEntry{ActionRemove, nil}},
+	{"PatientTelephoneNumbers", profileEntry{ActionRemove, nil}},
+	{"CountryOfResidence", profileEntry{ActionRemove, nil}},
+	{"RegionOfResidence", profileEntry{ActionRemove, nil}},
+	{"MilitaryRank", profileEntry{ActionRemove, nil}},
+	{"BranchOfService", profileEntry{ActionRemove, nil}},
+	{"MedicalRecordLocator", profileEntry{ActionRemove, nil}},
+	{"PatientReligiousPreference", profileEntry{ActionRemove, nil}},
+	{"PatientInsurancePlanCodeSequence", profileEntry{ActionRemove, nil}},
+	{"PatientPrimaryLanguageCodeSequence", profileEntry{ActionRemove, nil}},
+	{"PatientTransportArrangements", profileEntry{ActionRemove, nil}},
+	{"PatientInstitutionResidence", profileEntry{ActionRemove, nil}},
+	{"ResponsiblePerson", profileEntry{ActionRemove, nil}},
+	{"ResponsibleOrganization", profileEntry{ActionRemove, nil}},
+	{"ReferencedPatientSequence", profileEntry{ActionRemove, nil}},
+	{"CurrentPatientLocation", profileEntry{ActionRemove, nil}},
+	{"VisitComments", profileEntry{ActionRemove, cleanDescriptors}},
+	{"AdmissionID", profileEntry{ActionRemove, nil}},
+	{"IssuerOfAdmissionIDSequence", profileEntry{ActionRemove, nil}},
+	{"AdmittingDiagnosesDescription", profileEntry{ActionRemove, cleanDescriptors}},
+	{"AdmittingDiagnosesCodeSequence", profileEntry{ActionRemove, cleanStructured}},
+	{"(0038,0040)", profileEntry{ActionRemove, cleanDescriptors}}, // DischargeDiagnosisDescription
+	{"ServiceEpisodeID", profileEntry{ActionRemove, nil}},
+	{"ServiceEpisodeDescription", profileEntry{ActionRemove, cleanDescriptors}},
+	{"(0010,2155)", profileEntry{ActionRemove, nil}}, // PatientTelecomInformation
+	{"(0010,1100)", profileEntry{ActionRemove, nil}}, // ReferencedPatientPhotoSequence
+	{"AdmittingDate", profileEntry{ActionRemove, retainDates}},
+	{"AdmittingTime", profileEntry{ActionRemove, retainDates}},
+	{"(0038,0030)", profileEntry{ActionRemove, retainDates}}, // DischargeDate
+	{"(0038,0032)", profileEntry{ActionRemove, retainDates}}, // DischargeTime
+	{"(0038,001A)", profileEntry{ActionRemove, retainDates}}, // ScheduledAdmissionDate
+	{"(0038,001B)", profileEntry{ActionRemove, retainDates}}, // ScheduledAdmissionTime
+	{"(0038,001C)", profileEntry{ActionRemove, retainDates}}, // ScheduledDischargeDate
+	{"(0038,001D)", profileEntry{ActionRemove, retainDates}}, // ScheduledDischargeTime
+	{"(0038,001E)", profileEntry{ActionRemove, nil}},         // ScheduledPatientInstitutionResidence
+	{"VisitStatusID", profileEntry{ActionRemove, nil}},
+	{"RouteOfAdmissions", profileEntry{ActionRemove, nil}},
+	{"PreMedication", profileEntry{ActionRemove, retainPatient}},
+
+	// Study, request and procedure
+	{"StudyInstanceUID", profileEntry{ActionUID, retainUIDs}},
+	{"StudyID", profileEntry{ActionZero, nil}},
+	{"StudyDate", profileEntry{ActionZero, retainDates}},
+	{"StudyTime", profileEntry{ActionZero, retainDates}},
+	{"AccessionNumber", profileEntry{ActionZero, nil}},
+	{"StudyDescription", profileEntry{ActionRemove, cleanDescriptors}},
+	{"(0032,4000)", profileEntry{ActionRemove, cleanDescriptors}}, // StudyComments
+	{"ReferringPhysicianName", profileEntry{ActionZero, nil}},
+	{"ReferringPhysicianAddress", profileEntry{ActionRemove, nil}},
+	{"ReferringPhysicianTelephoneNumbers", profileEntry{ActionRemove, nil}},
+	{"ReferringPhysicianIdentificationSequence", profileEntry{ActionRemove, nil}},
+	{"(0008,009C)", profileEntry{ActionRemove, nil}}, // ConsultingPhysicianName
+	{"PhysiciansOfRecord", profileEntry{ActionRemove, nil}},
+	{"PhysiciansOfRecordIdentificationSequence", profileEntry{ActionRemove, nil}},
+	{"NameOfPhysiciansReadingStudy", profileEntry{ActionRemove, nil}},
+	{"PhysiciansReadingStudyIdentificationSequence", profileEntry{ActionRemove, nil}},
+	{"PerformingPhysicianName", profileEntry{ActionRemove, nil}},
+	{"PerformingPhysicianIdentificationSequence", profileEntry{ActionRemove, nil}},
+	{"OperatorsName", profileEntry{ActionDummy, nil}},
+	{"OperatorIdentificationSequence", profileEntry{ActionRemove, nil}},
+	{"RequestingPhysician", profileEntry{ActionRemove, nil}},
+	{"RequestingService", profileEntry{ActionRemove, nil}},
+	{"RequestAttributesSequence", profileEntry{ActionRemove, nil}},
+	{"RequestedProcedureID", profileEntry{ActionRemove, nil}},
+	{"RequestedProcedureDescription", profileEntry{ActionRemove, cleanDescriptors}},
+	{"(0032,1030)", profileEntry{ActionRemove, cleanDescriptors}}, // ReasonForStudy
+	{"ReasonForTheRequestedProcedure", profileEntry{ActionRemove, cleanDescriptors}},
+	{"PlacerOrderNumberImagingServiceRequest", profileEntry{ActionZero, nil}},
+	{"FillerOrderNumberImagingServiceRequest", profileEntry{ActionZero, nil}},
+	{"OrderEnteredBy", profileEntry{ActionRemove, nil}},
+	{"OrderEntererLocation", profileEntry{ActionRemove, nil}},
+	{"OrderCallbackPhoneNumber", profileEntry{ActionRemove, nil}},
+	{"NamesOfIntendedRecipientsOfResults", profileEntry{ActionRemove, nil}},
+	{"ScheduledProcedureStepDescription", profileEntry{ActionRemove, cleanDescriptors}},
+	{"ScheduledPerformingPhysicianName", profileEntry{ActionRemove, nil}},
+	{"(0032,1020)", profileEntry{ActionRemove, nil}}, // ScheduledStudyLocation
+	{"ScheduledStationAETitle", profileEntry{ActionRemove, retainDevice}},
+	{"ScheduledStationName", profileEntry{ActionRemove, retainDevice}},
+	{"PerformedProcedureStepID", profileEntry{ActionRemove, nil}},
+	{"PerformedProcedureStepDescription", profileEntry{ActionRemove, cleanDescriptors}},
+	{"PerformedProcedureStepStartDate", profileEntry{ActionRemove, retainDates}},
+	{"PerformedProcedureStepStartTime", profileEntry{ActionRemove, retainDates}},
+	{"PerformedProcedureStepEndDate", profileEntry{ActionRemove, retainDates}},
+	{"PerformedProcedureStepEndTime", profileEntry{ActionRemove, retainDates}},
+	{"CommentsOnThePerformedProcedureStep", profileEntry{ActionRemove, cleanDescriptors}},
+	{"PerformedLocation", profileEntry{ActionRemove, nil}},
+	{"PerformedStationAETitle", profileEntry{ActionRemove, retainDevice}},
+	{"PerformedStationName", profileEntry{ActionRemove, retainDevice}},
+	{"ProcedureCodeSequence", profileEntry{ActionRemove, cleanStructured}},
+	{"ReferencedStudySequence", profileEntry{ActionZero, nil}},
+	{"ReferencedPerformedProcedureStepSequence", profileEntry{ActionDummy, nil}},
+	{"(0032,0012)", profileEntry{ActionRemove, nil}},          // StudyIDIssuer
+	{"(0032,0032)", profileEntry{ActionRemove, retainDates}},  // StudyVerifiedDate
+	{"(0032,0033)", profileEntry{ActionRemove, retainDates}},  // StudyVerifiedTime
+	{"(0032,0034)", profileEntry{ActionRemove, retainDates}},  // StudyReadDate
+	{"(0032,0035)", profileEntry{ActionRemove, retainDates}},  // StudyReadTime
+	{"(0032,1000)", profileEntry{ActionRemove, retainDates}},  // ScheduledStudyStartDate
+	{"(0032,1001)", profileEntry{ActionRemove, retainDates}},  // ScheduledStudyStartTime
+	{"(0032,1010)", profileEntry{ActionRemove, retainDates}},  // ScheduledStudyStopDate
+	{"(0032,1011)", profileEntry{ActionRemove, retainDates}},  // ScheduledStudyStopTime
+	{"(0032,1021)", profileEntry{ActionRemove, retainDevice}}, // ScheduledStudyLocationAETitle
+	{"(0032,1031)", profileEntry{ActionRemove, nil}},          // RequestingPhysicianIdentificationSequence
+	{"(0032,1040)", profileEntry{ActionRemove, retainDates}},  // StudyArrivalDate
+	{"(0032,1041)", profileEntry{ActionRemove, retainDates}},  // StudyArrivalTime
+	{"(0032,1050)", profileEntry{ActionRemove, retainDates}},  // StudyCompletionDate
+	{"(0032,1051)", profileEntry{ActionRemove, retainDates}},  // StudyCompletionTime
+	{"ScheduledProcedureStepStartDate", profileEntry{ActionRemove, retainDates}},
+	{"ScheduledProcedureStepStartTime", profileEntry{ActionRemove, retainDates}},
+	{"ScheduledProcedureStepEndDate", profileEntry{ActionRemove, retainDates}},
+	{"ScheduledProcedureStepEndTime", profileEntry{ActionRemove, retainDates}},
+	{"ScheduledProcedureStepID", profileEntry{ActionRemove, nil}},
+	{"ScheduledProcedureStepLocation", profileEntry{ActionRemove, nil}},
+	{"ScheduledPerformingPhysicianIdentificationSequence", profileEntry{ActionRemove, nil}},
+	{"ScheduledProcedureStepStartDateTime", profileEntry{ActionRemove, retainDates}},
+	{"ScheduledProcedureStepModificationDateTime", profileEntry{ActionRemove, retainDates}},
+	{"ExpectedCompletionDateTime", profileEntry{ActionRemove, retainDates}},
+	{"ScheduledStationNameCodeSequence", profileEntry{ActionRemove, retainDevice}},
+	{"ScheduledStationGeographicLocationCodeSequence", profileEntry{ActionRemove, retainDevice}},
+	{"PerformedStationNameCodeSequence", profileEntry{ActionRemove, retainDevice}},
+	{"ScheduledHumanPerformersSequence", profileEntry{ActionRemove, nil}},
+	{"ActualHumanPerformersSequence", profileEntry{ActionRemove, nil}},
+	{"HumanPerformerOrganization", profileEntry{ActionRemove, nil}},
+	{"HumanPerformerName", profileEntry{ActionRemove, nil}},
+	{"PerformedProcedureStepStartDateTime", profileEntry{ActionRemove, retainDates}},
+	{"PerformedProcedureStepEndDateTime", profileEntry{ActionRemove, retainDates}},
+	{"ProcedureStepCancellationDateTime", profileEntry{ActionRemove, retainDates}},
+	{"RequestedProcedureLocation", profileEntry{ActionRemove, nil}},
+	{"ConfidentialityCode", profileEntry{ActionRemove, nil}},
+	{"RequestedProcedureComments", profileEntry{ActionRemove, cleanDescriptors}},
+	{"ReasonForRequestedProcedureCodeSequence", profileEntry{ActionRemove, cleanStructured}},
+	{"(0040,2001)", profileEntry{ActionRemove, cleanDescriptors}}, // ReasonForImagingServiceRequest
+	{"IssueDateOfImagingServiceRequest", profileEntry{ActionRemove, retainDates}},
+	{"IssueTimeOfImagingServiceRequest", profileEntry{ActionRemove, retainDates}},
+	{"ImagingServiceRequestComments", profileEntry{ActionRemove, cleanDescriptors}},
+	{"PersonIdentificationCodeSequence", profileEntry{ActionRemove, nil}},
+	{"PersonAddress", profileEntry{ActionRemove, nil}},
+	{"PersonTelephoneNumbers", profileEntry{ActionRemove, nil}},
+
+	// Series, acquisition and instance
+	{"SeriesInstanceUID", profileEntry{ActionUID, retainUIDs}},
+	{"SeriesDate", profileEntry{ActionRemove, retainDates}},
+	{"SeriesTime", profileEntry{ActionRemove, retainDates}},
+	{"SeriesDescription", profileEntry{ActionRemove, cleanDescriptors}},
+	{"ProtocolName", profileEntry{ActionDummy, cleanDescriptors}},
+	{"AcquisitionDate", profileEntry{ActionZero, retainDates}},
+	{"AcquisitionTime", profileEntry{ActionZero, retainDates}},
+	{"AcquisitionDateTime", profileEntry{ActionDummy, retainDates}},
+	{"(0018,4000)", profileEntry{ActionRemove, cleanDescriptors}}, // AcquisitionComments
+	{"AcquisitionDeviceProcessingDescription", profileEntry{ActionDummy, []DeidentifyOption{OptionRetainDevice, OptionCleanDescriptors}}},
+	{"ContrastBolusAgent", profileEntry{ActionDummy, cleanDescriptors}},
+	{"ContentDate", profileEntry{ActionDummy, retainDates}},
+	{"ContentTime", profileEntry{ActionDummy, retainDates}},
+	{"InstanceCreationDate", profileEntry{ActionDummy, retainDates}},
+	{"InstanceCreationTime", profileEntry{ActionDummy, retainDates}},
+	{"InstanceCreatorUID", profileEntry{ActionUID, retainUIDs}},
+	{"TimezoneOffsetFromUTC", profileEntry{ActionRemove, retainDates}},
+	{"DerivationDescription", profileEntry{ActionRemove, cleanDescriptors}},
+	{"ImageComments", profileEntry{ActionRemove, cleanDescriptors}},
+	{"FrameComments", profileEntry{ActionRemove, cleanDescriptors}},
+	{"SOPInstanceUID", profileEntry{ActionUID, retainUIDs}},
+	{"MediaStorageSOPInstanceUID", profileEntry{ActionUID, retainUIDs}},
+	{"FrameOfReferenceUID", profileEntry{ActionUID, retainUIDs}},
+	{"SynchronizationFrameOfReferenceUID", profileEntry{ActionUID, retainUIDs}},
+	{"ReferencedSOPInstanceUID", profileEntry{ActionUID, retainUIDs}},
+	{"ReferencedFrameOfReferenceUID", profileEntry{ActionUID, retainUIDs}},
+	{"RelatedFrameOfReferenceUID", profileEntry{ActionUID, retainUIDs}},
+	{"ConcatenationUID", profileEntry{ActionUID, retainUIDs}},
+	{"DimensionOrganizationUID", profileEntry{ActionUID, retainUIDs}},
+	{"IrradiationEventUID", profileEntry{ActionUID, retainUIDs}},
+	{"StorageMediaFileSetUID", profileEntry{ActionUID, retainUIDs}},
+	{"UID", profileEntry{ActionUID, retainUIDs}},
+	{"DoseReferenceUID", profileEntry{ActionUID, retainUIDs}},
+	{"FiducialUID", profileEntry{ActionUID, retainUIDs}},
+	{"(0018,2042)", profileEntry{ActionUID, retainUIDs}}, // TargetUID
+	{"DeviceUID", profileEntry{ActionUID, retainUIDs}},
+	{"(0062,0021)", profileEntry{ActionUID, retainUIDs}}, // TrackingUID
+	{"SpecimenUID", profileEntry{ActionUID, retainUIDs}},
+	{"TransactionUID", profileEntry{ActionUID, retainUIDs}},
+	{"CreatorVersionUID", profileEntry{ActionUID, retainUIDs}},
+	{"PaletteColorLookupTableUID", profileEntry{ActionUID, retainUIDs}},
+	{"(0028,1214)", profileEntry{ActionUID, retainUIDs}}, // LargePaletteColorLookupTableUID
+	{"(0040,DB0D)", profileEntry{ActionUID, retainUIDs}}, // TemplateExtensionCreatorUID
+	{"(0040,DB0C)", profileEntry{ActionUID, retainUIDs}}, // TemplateExtensionOrganizationUID
+	{"ContextGroupExtensionCreatorUID", profileEntry{ActionUID, retainUIDs}},
+	{"ReferencedSOPInstanceUIDInFile", profileEntry{ActionUID, retainUIDs}},
+	{"SOPInstanceUIDOfConcatenationSource", profileEntry{ActionUID, retainUIDs}},
+	{"RequestedSOPInstanceUID", profileEntry{ActionUID, retainUIDs}},
+	{"ModifiedAttributesSequence", profileEntry{ActionRemove, nil}},
+	{"OriginalAttributesSequence", profileEntry{ActionRemove, nil}},
+	{"DigitalSignaturesSequence", profileEntry{ActionRemove, nil}},
+	{"DataSetTrailingPadding", profileEntry{ActionRemove, nil}},
+	{"IconImageSequence", profileEntry{ActionRemove, nil}},
+	{"GraphicAnnotationSequence", profileEntry{ActionDummy, cleanGraphics}},
+	{"(0008,0024)", profileEntry{ActionRemove, retainDates}}, // OverlayDate
+	{"(0008,0034)", profileEntry{ActionRemove, retainDates}}, // OverlayTime
+	{"(0008,0025)", profileEntry{ActionRemove, retainDates}}, // CurveDate
+	{"(0008,0035)", profileEntry{ActionRemove, retainDates}}, // CurveTime
+	{"InstanceCoercionDateTime", profileEntry{ActionRemove, retainDates}},
+	{"DateOfSecondaryCapture", profileEntry{ActionRemove, retainDates}},
+	{"TimeOfSecondaryCapture", profileEntry{ActionRemove, retainDates}},
+	{"SecondaryCaptureDeviceID", profileEntry{ActionRemove, retainDevice}},
+	{"DateOfLastCalibration", profileEntry{ActionRemove, retainDates}},
+	{"TimeOfLastCalibration", profileEntry{ActionRemove, retainDates}},
+	{"DateOfLastDetectorCalibration", profileEntry{ActionRemove, retainDates}},
+	{"FrameAcquisitionDateTime", profileEntry{ActionRemove, retainDates}},
+	{"FrameReferenceDateTime", profileEntry{ActionRemove, retainDates}},
+	{"StartAcquisitionDateTime", profileEntry{ActionRemove, retainDates}},
+	{"EndAcquisitionDateTime", profileEntry{ActionRemove, retainDates}},
+	{"ContributionDateTime", profileEntry{ActionRemove, retainDates}},
+	{"ContributionDescription", profileEntry{ActionRemove, cleanDescriptors}},
+	{"SpecimenShortDescription", profileEntry{ActionRemove, cleanDescriptors}},
+	{"ContainerDescription", profileEntry{ActionRemove, cleanDescriptors}},
+	{"(0008,0055)", profileEntry{ActionRemove, retainDevice}},     // StationAETitle
+	{"(0088,0904)", profileEntry{ActionRemove, cleanDescriptors}}, // TopicTitle
+	{"(0088,0906)", profileEntry{ActionRemove, cleanDescriptors}}, // TopicSubject
+	{"(0088,0910)", profileEntry{ActionRemove, nil}},              // TopicAuthor
+	{"(0088,0912)", profileEntry{ActionRemove, cleanDescriptors}}, // TopicKeywords
+	{"ReferencedDigitalSignatureSequence", profileEntry{ActionRemove, nil}},
+	{"ReferencedSOPInstanceMACSequence", profileEntry{ActionRemove, nil}},
+
+	// Structured reporting and observers
+	{"ContentSequence", profileEntry{ActionRemove, cleanStructured}},
+	{"AcquisitionContextSequence", profileEntry{ActionRemove, cleanStructured}},
+	{"AuthorObserverSequence", profileEntry{ActionRemove, nil}},
+	{"ParticipantSequence", profileEntry{ActionRemove, nil}},
+	{"CustodialOrganizationSequence", profileEntry{ActionRemove, nil}},
+	{"VerifyingObserverName", profileEntry{ActionDummy, nil}},
+	{"VerifyingObserverSequence", profileEntry{ActionDummy, nil}},
+	{"VerifyingObserverIdentificationCodeSequence", profileEntry{ActionZero, nil}},
+	{"VerifyingOrganization", profileEntry{ActionRemove, nil}},
+	{"PersonName", profileEntry{ActionDummy, nil}},
+	{"ContentCreatorName", profileEntry{ActionZero, nil}},
+	{"ReviewerName", profileEntry{ActionZero, nil}},
+	{"TextString", profileEntry{ActionRemove, cleanDescriptors}},
+	{"TextValue", profileEntry{ActionRemove, cleanStructured}},
+	{"ObservationDateTime", profileEntry{ActionRemove, retainDates}},
+	{"DateTime", profileEntry{ActionRemove, retainDates}},
+	{"Date", profileEntry{ActionRemove, retainDates}},
+	{"Time", profileEntry{ActionRemove, retainDates}},
+	{"(4008,0040)", profileEntry{ActionRemove, nil}},              // ResultsID
+	{"(4008,0042)", profileEntry{ActionRemove, nil}},              // ResultsIDIssuer
+	{"(4008,0100)", profileEntry{ActionRemove, retainDates}},      // InterpretationRecordedDate
+	{"(4008,0101)", profileEntry{ActionRemove, retainDates}},      // InterpretationRecordedTime
+	{"(4008,0102)", profileEntry{ActionRemove, nil}},              // InterpretationRecorder
+	{"(4008,0108)", profileEntry{ActionRemove, retainDates}},      // InterpretationTranscriptionDate
+	{"(4008,0109)", profileEntry{ActionRemove, retainDates}},      // InterpretationTranscriptionTime
+	{"(4008,010A)", profileEntry{ActionRemove, nil}},              // InterpretationTranscriber
+	{"(4008,010B)", profileEntry{ActionRemove, cleanDescriptors}}, // InterpretationText
+	{"(4008,010C)", profileEntry{ActionRemove, nil}},              // InterpretationAuthor
+	{"(4008,0111)", profileEntry{ActionRemove, nil}},              // InterpretationApproverSequence
+	{"(4008,0114)", profileEntry{ActionRemove, nil}},              // PhysicianApprovingInterpretation
+	{"(4008,0115)", profileEntry{ActionRemove, cleanDescriptors}}, // InterpretationDiagnosisDescription
+	{"(4008,0119)", profileEntry{ActionRemove, nil}},              // DistributionName
+	{"(4008,011A)", profileEntry{ActionRemove, nil}},              // DistributionAddress
+	{"(4008,0300)", profileEntry{ActionRemove, cleanDescriptors}}, // Impressions
+	{"(4008,4000)", profileEntry{ActionRemove, cleanDescriptors}}, // ResultsComments
+
+	// Coding resources, which are kept although they are dates or identifiers
+	{"ContextGroupVersion", profileEntry{ActionKeep, nil}},
+	{"ContextGroupLocalVersion", profileEntry{ActionKeep, nil}},
+	{"CodingSchemeVersion", profileEntry{ActionKeep, nil}},
+	{"(0008,0119)", profileEntry{ActionKeep, nil}},                // LongCodeValue
+	{"(4000,4000)", profileEntry{ActionRemove, cleanDescriptors}}, // TextComments
+
+	// Device and institution
+	{"StationName", profileEntry{ActionDummy, retainDevice}},
+	{"DeviceSerialNumber", profileEntry{ActionDummy, retainDevice}},
+	{"DeviceDescription", profileEntry{ActionRemove, []DeidentifyOption{OptionRetainDevice, OptionCleanDescriptors}}},
+	{"(3010,002D)", profileEntry{ActionRemove, retainDevice}}, // DeviceLabel
+	{"DeviceID", profileEntry{ActionRemove, retainDevice}},
+	{"DetectorID", profileEntry{ActionDummy, retainDevice}},
+	{"PlateID", profileEntry{ActionRemove, retainDevice}},
+	{"GantryID", profileEntry{ActionRemove, retainDevice}},
+	{"CassetteID", profileEntry{ActionRemove, retainDevice}},
+	{"GeneratorID", profileEntry{ActionRemove, retainDevice}},
+	{"SourceSerialNumber", profileEntry{ActionRemove, retainDevice}},
+	{"InstitutionName", profileEntry{ActionDummy, retainInstitution}},
+	{"InstitutionAddress", profileEntry{ActionRemove, retainInstitution}},
+	{"InstitutionalDepartmentName", profileEntry{ActionRemove, retainInstitution}},
+	{"InstitutionCodeSequence", profileEntry{ActionDummy, retainInstitution}},
+	{"(0008,1041)", profileEntry{ActionRemove, retainInstitution}}, // InstitutionalDepartmentTypeCodeSequence
+}
+
+// basicProfile maps the tags of profileTable to their entries.
+var basicProfile = func() map[tag.Tag]profileEntry {
+	profile := make(map[tag.Tag]profileEntry, len(profileTable))
+	for _, row := range profileTable {
+		t, err := parseTag(row.attribute)
+		if err != nil {
+			info, findErr := tag.FindByName(row.attribute)
+			if findErr != nil {
+				panic(fmt.Sprintf("de-identification profile: unknown attribute %s", row.attribute))
+			}
+			t = info.Tag
+		}
+		profile[t] = row.profileEntry
+	}
+	return profile
+}()
+
+// Groups whose elements are covered by the profile regardless of their element number.
+const (
+	// curveGroups are the repeating groups 5000-501E of retired curves.
+	curveGroupsFirst, curveGroupsLast = 0x5000, 0x501E
+	// overlayGroups are the repeating groups 6000-601E of overlays.
+	overlayGroupsFirst, overlayGroupsLast = 0x6000, 0x601E
+	// overlayDataElement and overlayCommentsElement are Overlay Data and Overlay Comments.
+	overlayDataElement, overlayCommentsElement = 0x3000, 0x4000
+)
+
+// dummyValues are the values of dummied elements by VR. Other VRs are zeroed.
+var dummyValues = map[string]string{
+	"AE": "ANONYMIZED",
+	"AS": "000D",
+	"CS": "ANONYMIZED",
+	"DA": "19000101",
+	"DS": "0",
+	"DT": "19000101000000",
+	"IS": "0",
+	"LO": "ANONYMIZED",
+	"LT": "ANONYMIZED",
+	"PN": "ANONYMIZED",
+	"SH": "ANONYMIZED",
+	"ST": "ANONYMIZED",
+	"TM": "000000",
+	"UC": "ANONYMIZED",
+	"UT": "ANONYMIZED",
+	"FD": "0",
+	"FL": "0",
+	"SL": "0",
+	"SS": "0",
+	"UL": "0",
+	"US": "0",
+}
+
+// deidentificationMethod is the De-identification Method recorded in de-identified files.
+const deidentificationMethod = "tyro PS3.15 Basic Application Confidentiality Profile"
+
+// Deidentifier de-identifies files using the Basic Application Level Confidentiality Profile with
+// a set of options. All files de-identified by the same Deidentifier get the same UID for the same
+// original UID. It is the BatchEdit of BatchDeidentify.
+type Deidentifier struct {
+	options map[DeidentifyOption]bool
+	// uids replaces the UIDs of all files.
+	uids *UIDRemapper
+	// pseudonyms is the store of OptionPseudonymize, it is nil if the option is disabled.
+	pseudonyms *PseudonymStore
+	// private decides which private elements are kept. Without OptionRetainSafePrivate, it has no
+	// rules and removes all of them.
+	private *PrivateTagPolicy
+}
+
+// ParseDeidentifyOptions parses options separated by commas or spaces, e.g.
+// "retain-dates, clean-descriptors".
+func ParseDeidentifyOptions(spec string) ([]DeidentifyOption, error) {
+	var options []DeidentifyOption
+	fields := strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	for _, field := range fields {
+		option := DeidentifyOption(strings.ToLower(field))
+		if option != OptionPseudonymize && !slices.ContainsFunc(deidentifyOptions, func(o deidentifyOptionInfo) bool { return o.option == option }) {
+			return nil, fmt.Errorf("%w: %q, expected one of %s", ErrorUnknownDeidentifyOption, field, strings.Join(DeidentifyOptionNames(), ", "))
+		}
+		options = append(options, option)
+	}
+	return options, nil
+}
+
+// DeidentifyOptionNames returns the names of all options accepted by ParseDeidentifyOptions.
+func DeidentifyOptionNames() []string {
+	names := []string{string(OptionPseudonymize)}
+	for _, o := range deidentifyOptions {
+		names = append(names, string(o.option))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// NewDeidentifier creates a Deidentifier applying the profile with options. OptionPseudonymize
+// opens the default pseudonym store, see OpenDefaultPseudonymStore.
+func NewDeidentifier(options ...DeidentifyOption) (*Deidentifier, error) {
+	d := &Deidentifier{options: map[DeidentifyOption]bool{}}
+	for _, option := range options {
+		d.options[option] = true
+	}
+	if d.options[OptionShiftDates] && d.options[OptionRetainDates] {
+		return nil, fmt.Errorf("%w: %s and %s", ErrorConflictingDeidentifyOptions, OptionRetainDates, OptionShiftDates)
+	}
+	if d.options[OptionShiftDates] && !d.options[OptionPseudonymize] {
+		return nil, fmt.Errorf("%w: %s requires %s", ErrorConflictingDeidentifyOptions, OptionShiftDates, OptionPseudonymize)
+	}
+	if d.options[OptionPseudonymize] {
+		store, err := OpenDefaultPseudonymStore()
+		if err != nil {
+			return nil, err
+		}
+		d.pseudonyms = store
+	}
+	d.private = NewPrivateTagPolicy()
+	if d.options[OptionRetainSafePrivate] {
+		policy, err := DefaultPrivateTagPolicy()
+		if err != nil {
+			return nil, err
+		}
+		d.private = policy
+	}
+
+	root, err := UIDRoot()
+	if err != nil {
+		return nil, err
+	}
+	// With a pseudonym store, the key is kept in the store, so every run creates the same UIDs.
+	var key []byte
+	if d.pseudonyms != nil {
+		if key, err = d.pseudonyms.UIDKey(); err != nil {
+			return nil, err
+		}
+	}
+	if d.uids, err = NewUIDRemapper(root, key); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Options returns the enabled options in the order they are recorded.
+func (d *Deidentifier) Options() []DeidentifyOption {
+	var options []DeidentifyOption
+	for _, o := range deidentifyOptions {
+		if d.options[o.option] {
+			options = append(options, o.option)
+		}
+	}
+	if d.options[OptionPseudonymize] {
+		options = append(options, OptionPseudonymize)
+	}
+	return options
+}
+
+// String describes the de-identification, e.g. "de-identify (retain-dates, clean-descriptors)".
+func (d *Deidentifier) String() string {
+	options := d.Options()
+	if len(options) == 0 {
+		return "de-identify"
+	}
+	names := make([]string, len(options))
+	for i, option := range options {
+		names[i] = string(option)
+	}
+	return fmt.Sprintf("de-identify (%s)", strings.Join(names, ", "))
+}
+
+// Changes returns the changes de-identifying ds, the dataset of the file at path. Besides the
+// changes of the action table, the file is marked with Patient Identity Removed, the
+// De-identification Method and its Code Sequence and Longitudinal Temporal Information Modified.
+//
+// With OptionPseudonymize, pseudonyms new to the store are kept in memory until Commit saves them.
+// The changes are redacting, see ElementChange.Redact.
+func (d *Deidentifier) Changes(path string, ds dicom.Dataset) ([]*ElementChange, error) {
+	c := &deidentification{
+		Deidentifier: d,
+		path:         path,
+		identifiers:  identifiersOf(ds),
+	}
+	if d.pseudonyms != nil {
+		patient, err := d.pseudonyms.Patient(elementString(ds.Elements, tag.PatientID), elementString(ds.Elements, tag.PatientName))
+		if err != nil {
+			return nil, err
+		}
+		c.patient = &patient
+	}
+	if d.options[OptionShiftDates] {
+		offset, err := d.pseudonyms.DateOffset(c.patient.ID, c.patient.Name)
+		if err != nil {
+			return nil, err
+		}
+		c.dateOffset = offset
+	}
+	if err := c.elements(ds.Elements, nil); err != nil {
+		return nil, err
+	}
+	if err := c.mark(ds); err != nil {
+		return nil, err
+	}
+	return redact(c.changes), nil
+}
+
+// Commit saves the pseudonyms, date offsets and UID key generated for the changes to the pseudonym
+// store. It does nothing without OptionPseudonymize.
+func (d *Deidentifier) Commit() error {
+	if d.pseudonyms == nil {
+		return nil
+	}
+	return d.pseudonyms.Save()
+}
+
+// action returns the action for elem, which is not a private element.
+func (d *Deidentifier) action(elem *dicom.Element) DeidentifyAction {
+	t := elem.Tag
+	entry, ok := basicProfile[t]
+	switch {
+	case ok:
+	case t.Group >= curveGroupsFirst && t.Group <= curveGroupsLast:
+		entry = profileEntry{ActionRemove, cleanGraphics}
+	case t.Group >= overlayGroupsFirst && t.Group <= overlayGroupsLast && t.Element == overlayDataElement:
+		entry = profileEntry{ActionRemove, cleanGraphics}
+	case t.Group >= overlayGroupsFirst && t.Group <= overlayGroupsLast && t.Element == overlayCommentsElement:
+		entry = profileEntry{ActionRemove, cleanDescriptors}
+	default:
+		entry = fallbackEntry(elem)
+	}
+
+	// Retaining takes precedence over cleaning if an attribute is covered by both.
+	action := entry.action
+	for _, o := range deidentifyOptions {
+		if d.options[o.option] && slices.Contains(entry.options, o.column) && action != ActionKeep {
+			action = o.action
+		}
+	}
+	return action
+}
+
+// fallbackEntry returns the treatment of elem, an attribute missing from profileTable. Attributes
+// that are likely to identify the patient are removed: person names, dates and times, which are
+// kept by OptionRetainDates, text values, which are cleaned by OptionCleanDescriptors, and LO and
+// SH values whose keyword marks them as an identifier, description or comment. The File Meta
+// Information and all other attributes are kept.
+func fallbackEntry(elem *dicom.Element) profileEntry {
+	if elem.Tag.Group == tag.MetadataGroup {
+		return profileEntry{ActionKeep, nil}
+	}
+	keyword := ""
+	if info, err := tag.Find(elem.Tag); err == nil {
+		keyword = info.Name
+	}
+
+	switch elem.RawValueRepresentation {
+	case "PN":
+		return profileEntry{ActionRemove, nil}
+	case "DA", "DT", "TM":
+		return profileEntry{ActionRemove, retainDates}
+	case "LT", "ST", "UT":
+		return profileEntry{ActionRemove, cleanDescriptors}
+	case "LO", "SH":
+		for _, suffix := range []string{"ID", "IDs", "Identifier", "Number", "Numbers"} {
+			if strings.HasSuffix(keyword, suffix) {
+				return profileEntry{ActionRemove, nil}
+			}
+		}
+		if strings.Contains(keyword, "Description") || strings.Contains(keyword, "Comment") {
+			return profileEntry{ActionRemove, cleanDescriptors}
+		}
+	}
+	return profileEntry{ActionKeep, nil}
+}
+
+// deidentification collects the changes de-identifying a single file.
+type deidentification struct {
+	*Deidentifier
+	path string
+	// identifiers matches the names and IDs of the patient, see identifiersOf.
+	identifiers *regexp.Regexp
+	// patient is the pseudonym of the patient of the file, it is nil without OptionPseudonymize.
+	patient *PatientPseudonym
+	// dateOffset is the number of days dates are shifted by with OptionShiftDates.
+	dateOffset int
+	// privateOnly restricts the changes to private elements, see PrivateTagPolicy.Changes.
+	privateOnly bool
+	changes     []*ElementChange
+}
+
+// elements adds the changes de-identifying elems, whose paths are returned by child. A nil child
+// addresses the top level elements of the dataset.
+func (c *deidentification) elements(elems []*dicom.Element, child func(t tag.Tag) TagPath) error {
+	if child == nil {
+		child = NewTagPath
+	}
+	private := c.private.actions(elems)
+	for _, elem := range elems {
+		action, ok := private[elem.Tag]
+		switch {
+		case ok:
+		case c.privateOnly:
+			action = ActionKeep
+		default:
+			action = c.action(elem)
+		}
+		if err := c.element(elem, child(elem.Tag), action); err != nil {
+			return fmt.Errorf("%s: %w", child(elem.Tag), err)
+		}
+	}
+	return nil
+}
+
+// element adds the changes performing action on elem, which is addressed by path.
+func (c *deidentification) element(elem *dicom.Element, path TagPath, action DeidentifyAction) error {
+	// All dates that are kept are shifted, so intervals between them are preserved.
+	if c.options[OptionShiftDates] && action == ActionKeep && (elem.RawValueRepresentation == "DA" || elem.RawValueRepresentation == "DT") && !unshiftedDates[elem.Tag] {
+		action = ActionShift
+	}
+	// References to instances are replaced along with the UIDs of the action table.
+	if !c.options[OptionRetainUIDs] && !c.privateOnly && action == ActionKeep && remapsElement(elem) {
+		action = ActionUID
+	}
+	if action == ActionRemove {
+		c.changes = append(c.changes, &ElementChange{Kind: ChangeRemove, File: c.path, Path: path})
+		return nil
+	}
+
+	if elem.Value != nil && elem.Value.ValueType() == dicom.Sequences {
+		items := itemsOf(elem)
+		if action == ActionZero || action == ActionDummy {
+			for i := len(items) - 1; i >= 0; i-- {
+				c.changes = append(c.changes, &ElementChange{Kind: ChangeRemoveItem, File: c.path, Path: path, Item: i})
+			}
+			return nil
+		}
+		for i, item := range items {
+			if err := c.elements(item, func(t tag.Tag) TagPath { return path.Child(i, t) }); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if action == ActionKeep {
+		return nil
+	}
+	if !IsEditable(elem) {
+		// Binary values cannot be dummied or cleaned. Private values may hold anything and are
+		// removed rather than kept.
+		if action == ActionClean && !tag.IsPrivate(elem.Tag.Group) {
+			return nil
+		}
+		c.changes = append(c.changes, &ElementChange{Kind: ChangeRemove, File: c.path, Path: path})
+		return nil
+	}
+
+	old := ElementText(elem)
+	value, pseudonymized, err := c.pseudonym(elem.Tag, old)
+	if err != nil {
+		return err
+	}
+	if !pseudonymized {
+		value = c.apply(action, elem, old)
+	}
+	if value == old {
+		return nil
+	}
+	if _, err := ParseElementText(elem, value); err != nil {
+		return err
+	}
+	c.changes = append(c.changes, &ElementChange{Kind: ChangeSet, File: c.path, Path: path, Old: old, New: value})
+	return nil
+}
+
+// apply returns the value of elem, whose current value is old, after performing action.
+func (c *deidentification) apply(action DeidentifyAction, elem *dicom.Element, old string) string {
+	value := old
+	switch action {
+	case ActionZero:
+		value = ""
+	case ActionDummy:
+		// Empty values hold nothing to replace.
+		if old != "" {
+			value = dummyValues[elem.RawValueRepresentation]
+		}
+	case ActionClean:
+		value = c.cleanText(old)
+	case ActionUID:
+		value = c.uids.Remap(old)
+	case ActionShift:
+		value = shiftDates(elem.RawValueRepresentation, old, c.dateOffset)
+	}
+	return value
+}
+
+// unshiftedDates are the date attributes that are kept unchanged by OptionShiftDates. They date
+// coding resources rather than the patient.
+var unshiftedDates = map[tag.Tag]bool{
+	tag.ContextGroupVersion:      true,
+	tag.ContextGroupLocalVersion: true,
+}
+
+// shiftDates returns the DA or DT value with its dates shifted by offset days. Values and ranges
+// of multi-valued and range matching values are shifted individually, values of other VRs are
+// returned unchanged. Times are kept, since dates are shifted by whole days. Values without a full
+// date, e.g. a DT holding only the year, cannot be shifted and are removed.
+func shiftDates(vr string, value string, offset int) string {
+	if vr != "DA" && vr != "DT" {
+		return value
+	}
+	values := strings.Split(value, "\\")
+	for i, v := range values {
+		bounds := strings.Split(strings.TrimSpace(v), "-")
+		// A DT may end in a negative UTC offset, which is not a range bound.
+		if vr == "DT" && len(bounds) > 1 && len(bounds[len(bounds)-1]) == 4 && len(bounds[len(bounds)-2]) > 8 {
+			bounds = append(bounds[:len(bounds)-2], bounds[len(bounds)-2]+"-"+bounds[len(bounds)-1])
+		}
+		for j, bound := range bounds {
+			if bound == "" {
+				continue
+			}
+			bounds[j] = shiftDate(bound, offset)
+			if bounds[j] == "" {
+				return ""
+			}
+		}
+		values[i] = strings.Join(bounds, "-")
+	}
+	return strings.Join(values, "\\")
+}
+
+// shiftDate shifts the date at the start of a single DA or DT value by offset days and returns an
+// empty string if the value does not start with a valid date. Dates in the ACR-NEMA format
+// YYYY.MM.DD are written in the DICOM format YYYYMMDD.
+func shiftDate(value string, offset int) string {
+	for _, layout := range []string{"20060102", "2006.01.02"} {
+		if len(value) < len(layout) {
+			continue
+		}
+		date, err := time.Parse(layout, value[:len(layout)])
+		if err != nil {
+			continue
+		}
+		return date.AddDate(0, 0, offset).Format("20060102") + value[len(layout):]
+	}
+	return ""
+}
+
+// pseudonym returns the pseudonym replacing old, the value of an element with tag t, and whether
+// the element is pseudonymized. Elements are only pseudonymized with OptionPseudonymize.
+func (c *deidentification) pseudonym(t tag.Tag, old string) (string, bool, error) {
+	if c.patient == nil {
+		return "", false, nil
+	}
+	switch t {
+	case tag.PatientID:
+		return c.patient.PseudonymID, true, nil
+	case tag.PatientName:
+		return c.patient.PseudonymName, true, nil
+	case tag.AccessionNumber:
+		// Empty accession numbers are zeroed like without pseudonyms.
+		if accession := strings.TrimSpace(old); accession != "" {
+			pseudonym, err := c.pseudonyms.Accession(c.patient.ID, c.patient.Name, accession)
+			return pseudonym, true, err
+		}
+	}
+	return "", false, nil
+}
+
+// cleanText removes the names, IDs and birth date of the patient from text.
+func (c *deidentification) cleanText(text string) string {
+	if c.identifiers == nil {
+		return text
+	}
+	cleaned := c.identifiers.ReplaceAllString(text, "")
+	return strings.TrimSpace(repeatedSpaces.ReplaceAllString(cleaned, " "))
+}
+
+// repeatedSpaces matches the runs of spaces left behind by cleanText.
+var repeatedSpaces = regexp.MustCompile(` {2,}`)
+
+// mark adds the changes recording the de-identification in ds.
+func (c *deidentification) mark(ds dicom.Dataset) error {
+	temporal := "REMOVED"
+	if c.options[OptionRetainDates] {
+		temporal = "UNMODIFIED"
+	} else if c.options[OptionShiftDates] {
+		temporal = "MODIFIED"
+	}
+	for _, attr := range []struct {
+		tag   tag.Tag
+		vr    string
+		value string
+	}{
+		{tag.PatientIdentityRemoved, "CS", "YES"},
+		{tag.DeidentificationMethod, "LO", deidentificationMethod},
+		{tag.LongitudinalTemporalInformationModified, "CS", temporal},
+	} {
+		if err := c.set(ds, attr.tag, attr.vr, attr.value); err != nil {
+			return err
+		}
+	}
+
+	sequence := NewTagPath(tag.DeidentificationMethodCodeSequence)
+	if _, err := sequence.Find(ds.Elements); err == nil {
+		c.changes = append(c.changes, &ElementChange{Kind: ChangeRemove, File: c.path, Path: sequence})
+	}
+	c.changes = append(c.changes, &ElementChange{Kind: ChangeAdd, File: c.path, Path: sequence, VR: "SQ"})
+	codes := []deidentifyCode{basicProfileCode}
+	for _, o := range deidentifyOptions {
+		if c.options[o.option] {
+			codes = append(codes, o.code)
+		}
+	}
+	for i, code := range codes {
+		c.changes = append(c.changes,
+			&ElementChange{Kind: ChangeAddItem, File: c.path, Path: sequence, Item: i},
+			&ElementChange{Kind: ChangeAdd, File: c.path, Path: sequence.Child(i, tag.CodeValue), VR: "SH", New: code.value},
+			&ElementChange{Kind: ChangeAdd, File: c.path, Path: sequence.Child(i, tag.CodingSchemeDesignator), VR: "SH", New: "DCM"},
+			&ElementChange{Kind: ChangeAdd, File: c.path, Path: sequence.Child(i, tag.CodeMeaning), VR: "LO", New: code.meaning},
+		)
+	}
+	return nil
+}
+
+// set adds the change setting the top level element with tag t to value, adding it with VR vr if
+// ds lacks it.
+func (c *deidentification) set(ds dicom.Dataset, t tag.Tag, vr string, value string) error {
+	path := NewTagPath(t)
+	elem, err := path.Find(ds.Elements)
+	if err != nil {
+		c.changes = append(c.changes, &ElementChange{Kind: ChangeAdd, File: c.path, Path: path, VR: vr, New: value})
+		return nil
+	}
+	old := ElementText(elem)
+	if old == value {
+		return nil
+	}
+	if _, err := ParseElementText(elem, value); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	c.changes = append(c.changes, &ElementChange{Kind: ChangeSet, File: c.path, Path: path, Old: old, New: value})
+	return nil
+}
+
+// identifiersOf returns an expression matching the words of the names and the IDs and birth date
+// of the patient of ds, or nil if ds holds none of them.
+func identifiersOf(ds dicom.Dataset) *regexp.Regexp {
+	var words []string
+	for _, t := range []tag.Tag{
+		tag.PatientName, tag.OtherPatientNames, tag.PatientBirthName, tag.PatientMotherBirthName,
+		tag.PatientID, tag.OtherPatientIDs, tag.AccessionNumber, tag.PatientBirthDate,
+	} {
+		for _, value := range elementStrings(ds.Elements, t) {
+			for _, word := range strings.FieldsFunc(value, func(r rune) bool { return r == '^' || r == '=' || unicode.IsSpace(r) }) {
+				// Single letters, e.g. initials, would remove arbitrary letters from the text.
+				if len([]rune(word)) > 1 {
+					words = append(words, regexp.QuoteMeta(word))
+				}
+			}
+		}
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(words, "|") + `)\b`)
+}
@@ -0,0 +1,99 @@
+// dictionary.go provides lookups in the DICOM data dictionary that go beyond single tags, such as
+// completing keywords and parsing user supplied tag specifications.
+package operations
+
+//go:generate go run generateDictionary.go
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// ErrorUnknownTag is returned when a tag specification does not name a tag of the data dictionary
+// and does not specify a VR either.
+var ErrorUnknownTag = errors.New("unknown tag")
+
+// CompleteKeyword returns up to limit dictionary entries whose keyword matches prefix.
+//
+// Matching is case insensitive. Keywords starting with prefix are returned first, followed by
+// keywords containing it, each group sorted alphabetically.
+func CompleteKeyword(prefix string, limit int) []tag.Info {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" {
+		return nil
+	}
+
+	var starts, contains []tag.Info
+	for _, t := range dictionaryTags {
+		info, err := tag.Find(tag.Tag{Group: uint16(t >> 16), Element: uint16(t)})
+		if err != nil {
+			continue
+		}
+		keyword := strings.ToLower(info.Name)
+		if strings.HasPrefix(keyword, prefix) {
+			starts = append(starts, info)
+		} else if strings.Contains(keyword, prefix) {
+			contains = append(contains, info)
+		}
+	}
+
+	byName := func(infos []tag.Info) {
+		sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	}
+	byName(starts)
+	byName(contains)
+
+	matches := append(starts, contains...)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// ParseTagSpec parses a tag given by keyword, e.g. "AccessionNumber", or by number, e.g.
+// "(0008,0050)" or "00080050", optionally followed by a VR, e.g. "(0009,1001) LO".
+//
+// If no VR is given, it is taken from the data dictionary. Tags missing from the dictionary,
+// such as private tags, require a VR.
+func ParseTagSpec(spec string) (tag.Tag, string, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 || len(fields) > 2 {
+		return tag.Tag{}, "", fmt.Errorf("%w: %q, expected a keyword or (gggg,eeee) and an optional VR", ErrorUnknownTag, spec)
+	}
+
+	t, err := parseTag(fields[0])
+	if err != nil {
+		info, findErr := tag.FindByName(fields[0])
+		if findErr != nil {
+			return tag.Tag{}, "", fmt.Errorf("%w: %q", ErrorUnknownTag, fields[0])
+		}
+		t = info.Tag
+	}
+
+	if len(fields) == 2 {
+		vr := strings.ToUpper(fields[1])
+		if !knownVRs[vr] {
+			return tag.Tag{}, "", fmt.Errorf("%w: unknown VR %q", ErrorInvalidValue, fields[1])
+		}
+		return t, vr, nil
+	}
+
+	info, err := tag.Find(t)
+	if err != nil {
+		return tag.Tag{}, "", fmt.Errorf("%w: %s is not in the data dictionary, specify its VR", ErrorUnknownTag, fields[0])
+	}
+	return t, info.VR, nil
+}
+
+// knownVRs are all Value Representations defined in PS3.5.
+var knownVRs = map[string]bool{
+	"AE": true, "AS": true, "AT": true, "CS": true, "DA": true, "DS": true, "DT": true, "FD": true,
+	"FL": true, "IS": true, "LO": true, "LT": true, "OB": true, "OD": true, "OF": true, "OL": true,
+	"OV": true, "OW": true, "PN": true, "SH": true, "SL": true, "SQ": true, "SS": true, "ST": true,
+	"SV": true, "TM": true, "UC": true, "UI": true, "UL": true, "UN": true, "UR": true, "US": true,
+	"UT": true, "UV": true,
+}
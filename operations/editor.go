@@ -0,0 +1,283 @@
+// editor.go provides the functions used to modify element values of DICOM files.
+//
+// Values are edited in their textual representation: multiple values are separated by
+// backslashes and numbers are written in decimal. Every edit is validated against the VR and
+// Value Multiplicity of the element before it is applied.
+package operations
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+	"github.com/suyashkumar/dicom/pkg/uid"
+)
+
+// ErrorElementNotEditable is returned when the value of an element cannot be edited as text, such
+// as sequences, pixel data or binary values.
+var ErrorElementNotEditable = errors.New("element value cannot be edited as text")
+
+// IsEditable reports whether the value of elem can be edited as text.
+func IsEditable(elem *dicom.Element) bool {
+	switch tag.GetVRKind(elem.Tag, elem.RawValueRepresentation) {
+	case tag.VRBytes, tag.VRSequence, tag.VRItem, tag.VRPixelData, tag.VRTagList:
+		return false
+	}
+	return true
+}
+
+// ElementText returns the textual representation of the value of elem as accepted by ParseElementText.
+func ElementText(elem *dicom.Element) string {
+	if elem.Value == nil {
+		return ""
+	}
+
+	switch v := elem.Value.GetValue().(type) {
+	case []string:
+		return strings.Join(v, "\\")
+	case []int:
+		values := make([]string, len(v))
+		for i, value := range v {
+			values[i] = strconv.Itoa(value)
+		}
+		return strings.Join(values, "\\")
+	case []float64:
+		bitSize := 64
+		if elem.RawValueRepresentation == "FL" {
+			bitSize = 32
+		}
+		values := make([]string, len(v))
+		for i, value := range v {
+			values[i] = strconv.FormatFloat(value, 'g', -1, bitSize)
+		}
+		return strings.Join(values, "\\")
+	}
+	return elem.Value.String()
+}
+
+// ParseElementText validates text against the VR and Value Multiplicity of elem and returns the
+// value it represents.
+func ParseElementText(elem *dicom.Element, text string) (dicom.Value, error) {
+	if !IsEditable(elem) {
+		return nil, fmt.Errorf("%w: %s", ErrorElementNotEditable, elem.RawValueRepresentation)
+	}
+
+	vr := elem.RawValueRepresentation
+	values := SplitValues(vr, text)
+	for i, value := range values {
+		if err := ValidateValue(vr, value); err != nil {
+			if len(values) > 1 {
+				return nil, fmt.Errorf("value %d: %w", i+1, err)
+			}
+			return nil, err
+		}
+	}
+	if err := ValidateValueMultiplicity(elem.Tag, len(values)); err != nil {
+		return nil, err
+	}
+
+	return valueFromText(elem, text)
+}
+
+// valueFromText returns the value represented by text for elem without validating it against the
+// VR and Value Multiplicity. It is used to restore previous values, which might not have been
+// valid in the first place.
+func valueFromText(elem *dicom.Element, text string) (dicom.Value, error) {
+	if !IsEditable(elem) {
+		return nil, fmt.Errorf("%w: %s", ErrorElementNotEditable, elem.RawValueRepresentation)
+	}
+
+	vr := elem.RawValueRepresentation
+	values := SplitValues(vr, text)
+	switch tag.GetVRKind(elem.Tag, vr) {
+	case tag.VRUInt16List, tag.VRUInt32List, tag.VRInt16List, tag.VRInt32List:
+		ints := make([]int, len(values))
+		for i, value := range values {
+			v, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q is not an integer", ErrorInvalidValue, value)
+			}
+			ints[i] = int(v)
+		}
+		return dicom.NewValue(ints)
+	case tag.VRFloat32List, tag.VRFloat64List:
+		floats := make([]float64, len(values))
+		for i, value := range values {
+			v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q is not a floating point number", ErrorInvalidValue, value)
+			}
+			floats[i] = v
+		}
+		return dicom.NewValue(floats)
+	}
+
+	if values == nil {
+		values = []string{}
+	}
+	return dicom.NewValue(values)
+}
+
+// SetElementText validates text and replaces the value of elem with the value it represents.
+func SetElementText(elem *dicom.Element, text string) error {
+	value, err := ParseElementText(elem, text)
+	if err != nil {
+		return err
+	}
+	elem.Value = value
+	return nil
+}
+
+// NewElement creates an element with tag t and VR vr holding the value represented by text.
+//
+// Sequences are created without items. For all other VRs text is validated like an edited value.
+func NewElement(t tag.Tag, vr string, text string) (*dicom.Element, error) {
+	elem := &dicom.Element{
+		Tag:                    t,
+		ValueRepresentation:    tag.GetVRKind(t, vr),
+		RawValueRepresentation: vr,
+	}
+
+	var err error
+	if vr == "SQ" {
+		elem.ValueLength = tag.VLUndefinedLength
+		elem.Value, err = dicom.NewValue([][]*dicom.Element{})
+	} else {
+		elem.Value, err = ParseElementText(elem, text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return elem, nil
+}
+
+// updateContainer replaces the list of elements containing the element addressed by path with
+// the list returned by update. The containing list is either the top level of ds or a sequence item.
+func updateContainer(ds *dicom.Dataset, path TagPath, update func([]*dicom.Element) ([]*dicom.Element, error)) error {
+	if len(path) <= 1 {
+		elems, err := update(ds.Elements)
+		if err != nil {
+			return err
+		}
+		ds.Elements = elems
+		return nil
+	}
+
+	sequence := path[:len(path)-1]
+	return updateItems(ds, sequence, func(items [][]*dicom.Element) ([][]*dicom.Element, error) {
+		index := sequence[len(sequence)-1].Item
+		if index >= len(items) {
+			return nil, fmt.Errorf("%w: %s", ErrorElementNotFound, path)
+		}
+		elems, err := update(items[index])
+		if err != nil {
+			return nil, err
+		}
+		items[index] = elems
+		return items, nil
+	})
+}
+
+// updateItems replaces the items of the sequence addressed by path with the items returned by update.
+func updateItems(ds *dicom.Dataset, path TagPath, update func([][]*dicom.Element) ([][]*dicom.Element, error)) error {
+	elem, err := path.Find(ds.Elements)
+	if err != nil {
+		return err
+	}
+	if elem.Value == nil || elem.Value.ValueType() != dicom.Sequences {
+		return fmt.Errorf("%s is not a sequence", path)
+	}
+
+	items, err := update(itemsOf(elem))
+	if err != nil {
+		return err
+	}
+	elem.Value, err = dicom.NewValue(items)
+	return err
+}
+
+// addElementAt inserts elem at path. The item the path leads into has to exist and must not contain
+// an element with the same tag.
+func addElementAt(ds *dicom.Dataset, path TagPath, elem *dicom.Element) error {
+	return updateContainer(ds, path, func(elems []*dicom.Element) ([]*dicom.Element, error) {
+		if findElement(elems, elem.Tag) != nil {
+			return nil, fmt.Errorf("%s already exists", path)
+		}
+		return insertElement(elems, elem), nil
+	})
+}
+
+// removeElementAt removes the element addressed by path and returns it.
+func removeElementAt(ds *dicom.Dataset, path TagPath) (*dicom.Element, error) {
+	var removed *dicom.Element
+	err := updateContainer(ds, path, func(elems []*dicom.Element) ([]*dicom.Element, error) {
+		for i, elem := range elems {
+			if elem.Tag == path.Tag() {
+				removed = elem
+				return append(elems[:i], elems[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", ErrorElementNotFound, path)
+	})
+	return removed, err
+}
+
+// addItemAt inserts an item holding elems at the given index of the sequence addressed by path.
+func addItemAt(ds *dicom.Dataset, path TagPath, index int, elems []*dicom.Element) error {
+	return updateItems(ds, path, func(items [][]*dicom.Element) ([][]*dicom.Element, error) {
+		if index < 0 || index > len(items) {
+			return nil, fmt.Errorf("%s has no item %d", path, index+1)
+		}
+		return append(items[:index], append([][]*dicom.Element{elems}, items[index:]...)...), nil
+	})
+}
+
+// removeItemAt removes the item at the given index of the sequence addressed by path and returns
+// its elements.
+func removeItemAt(ds *dicom.Dataset, path TagPath, index int) ([]*dicom.Element, error) {
+	var removed []*dicom.Element
+	err := updateItems(ds, path, func(items [][]*dicom.Element) ([][]*dicom.Element, error) {
+		if index < 0 || index >= len(items) {
+			return nil, fmt.Errorf("%s has no item %d", path, index+1)
+		}
+		removed = items[index]
+		return append(items[:index], items[index+1:]...), nil
+	})
+	return removed, err
+}
+
+// encodeElements encodes elems as a DICOM stream using Explicit VR Little Endian, so removed
+// elements and items can be recorded and restored later.
+func encodeElements(elems []*dicom.Element) ([]byte, error) {
+	transferSyntax, err := dicom.NewElement(tag.TransferSyntaxUID, []string{uid.ExplicitVRLittleEndian})
+	if err != nil {
+		return nil, err
+	}
+
+	out := &bytes.Buffer{}
+	ds := dicom.Dataset{Elements: append([]*dicom.Element{transferSyntax}, elems...)}
+	if err := dicom.Write(out, ds, writeOptions...); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// decodeElements decodes elements encoded by encodeElements.
+func decodeElements(data []byte) ([]*dicom.Element, error) {
+	ds, err := dicom.ParseUntilEOF(bytes.NewReader(data), nil, dicom.SkipProcessingPixelDataValue())
+	if err != nil {
+		return nil, err
+	}
+
+	elems := make([]*dicom.Element, 0, len(ds.Elements))
+	for _, elem := range ds.Elements {
+		if elem.Tag.Group != tag.MetadataGroup {
+			elems = append(elems, elem)
+		}
+	}
+	return elems, nil
+}
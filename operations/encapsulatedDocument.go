@@ -0,0 +1,149 @@
+// encapsulatedDocument.go provides utilities for extracting encapsulated documents such as PDF
+// or CDA files from Encapsulated Document IODs.
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// ErrorNoEncapsulatedDocument is returned when a dataset does not contain an encapsulated document.
+var ErrorNoEncapsulatedDocument = errors.New("dataset does not contain an encapsulated document")
+
+// encapsulatedDocumentLength is the (0042,0015) Encapsulated Document Length tag, which is missing
+// from the data dictionary of the DICOM library.
+var encapsulatedDocumentLength = tag.Tag{Group: 0x0042, Element: 0x0015}
+
+// documentExtensions maps the MIME Type of Encapsulated Document to a file extension.
+var documentExtensions = map[string]string{
+	"application/pdf":    ".pdf",
+	"text/xml":           ".xml",
+	"application/xml":    ".xml",
+	"text/plain":         ".txt",
+	"model/stl":          ".stl",
+	"model/x.stl-binary": ".stl",
+	"model/obj":          ".obj",
+	"model/mtl":          ".mtl",
+}
+
+// EncapsulatedDocument describes the encapsulated document of a dataset.
+type EncapsulatedDocument struct {
+	// Title is the Document Title (0042,0010).
+	Title string
+	// MIMEType is the MIME Type of Encapsulated Document (0042,0012).
+	MIMEType string
+	// Data is the document content without trailing padding.
+	Data []byte
+}
+
+// Extension returns the file extension matching the MIME type of the document, or ".bin" if the
+// MIME type is unknown.
+func (d EncapsulatedDocument) Extension() string {
+	if ext, ok := documentExtensions[strings.ToLower(d.MIMEType)]; ok {
+		return ext
+	}
+	return ".bin"
+}
+
+// GetEncapsulatedDocument returns the encapsulated document (0042,0011) stored in ds.
+//
+// The value is truncated to the Encapsulated Document Length (0042,0015) if present, which removes
+// the padding byte added to odd length documents.
+func GetEncapsulatedDocument(ds dicom.Dataset) (EncapsulatedDocument, error) {
+	elem, err := ds.FindElementByTag(tag.EncapsulatedDocument)
+	if err != nil {
+		return EncapsulatedDocument{}, ErrorNoEncapsulatedDocument
+	}
+	data, ok := elem.Value.GetValue().([]byte)
+	if !ok {
+		return EncapsulatedDocument{}, ErrorNoEncapsulatedDocument
+	}
+
+	if length, err := intAttribute(ds, encapsulatedDocumentLength); err == nil && length >= 0 && length <= len(data) {
+		data = data[:length]
+	}
+
+	return EncapsulatedDocument{
+		Title:    elementString(ds.Elements, tag.DocumentTitle),
+		MIMEType: elementString(ds.Elements, tag.MIMETypeOfEncapsulatedDocument),
+		Data:     data,
+	}, nil
+}
+
+// ExtractEncapsulatedDocument writes the encapsulated document of file into outputDir.
+//
+// The output file is named after the DICOM file with the extension matching the document's MIME
+// type. Existing files are never overwritten: if the name is taken, a number is appended to it,
+// e.g. report-2.pdf. Returns the path of the written file.
+func ExtractEncapsulatedDocument(file *ParsedDicomFile, outputDir string) (string, error) {
+	doc, err := GetEncapsulatedDocument(file.Dataset)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", file.Path, err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return "", err
+	}
+	base := strings.TrimSuffix(filepath.Base(file.Path), filepath.Ext(file.Path))
+	paths, handles, err := createUnique(outputDir, base, doc.Extension())
+	if err != nil {
+		return "", err
+	}
+	out, handle := paths[0], handles[0]
+	_, err = handle.Write(doc.Data)
+	if closeErr := handle.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out)
+		return "", err
+	}
+	return out, nil
+}
+
+// createUnique creates a new file named base followed by ext in dir for every ext, e.g. a raw dump
+// and its sidecar. If one of the files exists, a number starting at 2 is appended to base until
+// all names are free. Creating the files with O_EXCL ensures that no file is overwritten, even if
+// another process creates one in the meantime. The files are returned in the order of exts.
+func createUnique(dir, base string, exts ...string) ([]string, []*os.File, error) {
+	for n := 1; ; n++ {
+		name := base
+		if n > 1 {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		paths, handles, err := createExclusive(filepath.Join(dir, name), exts)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return paths, handles, nil
+	}
+}
+
+// createExclusive creates the file base followed by ext for every ext with O_EXCL. If one of them
+// cannot be created, the files created before it are removed again.
+func createExclusive(base string, exts []string) ([]string, []*os.File, error) {
+	paths := make([]string, 0, len(exts))
+	handles := make([]*os.File, 0, len(exts))
+	for _, ext := range exts {
+		handle, err := os.OpenFile(base+ext, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o644)
+		if err != nil {
+			for i, created := range handles {
+				created.Close()
+				os.Remove(paths[i])
+			}
+			return nil, nil, err
+		}
+		paths = append(paths, base+ext)
+		handles = append(handles, handle)
+	}
+	return paths, handles, nil
+}
@@ -0,0 +1,298 @@
+// fileMeta.go checks and repairs the File Meta Information (group 0002) of DICOM files.
+//
+// PACS commonly reject files whose meta header is inconsistent: a File Meta Information Group
+// Length that does not match the encoded group, a missing Media Storage SOP Class UID or Media
+// Storage SOP UIDs that differ from the SOP Class and Instance UIDs of the data set. The group
+// length is checked on the raw bytes of a file, since the parsed dataset only holds the declared
+// value. Files with a wrong group length are still parsed, see inflatingReader.
+//
+// Repairing a file regenerates its meta header from the data set and identifies tyro as the
+// implementation that wrote it.
+package operations
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+const (
+	// ImplementationClassUID identifies tyro in the File Meta Information of repaired files.
+	ImplementationClassUID = "2.25.302897474346621395155882068769872207555"
+	// ImplementationVersionName is written next to ImplementationClassUID.
+	ImplementationVersionName = "TYRO"
+)
+
+var (
+	// ErrorMissingFileMeta is returned for files without preamble and File Meta Information.
+	ErrorMissingFileMeta = errors.New("file has no file meta information")
+	// ErrorFileMetaGroupLength is returned when the File Meta Information Group Length is missing
+	// or does not match the length of the encoded group.
+	ErrorFileMetaGroupLength = errors.New("wrong file meta information group length")
+	// ErrorMissingFileMetaElement is returned when a required File Meta Information element is
+	// missing or empty.
+	ErrorMissingFileMetaElement = errors.New("missing file meta information element")
+	// ErrorFileMetaMismatch is returned when a File Meta Information element contradicts the data set.
+	ErrorFileMetaMismatch = errors.New("file meta information does not match the data set")
+)
+
+// groupLengthElementSize is the size of the encoded File Meta Information Group Length element.
+const groupLengthElementSize = metaElementHeaderSize + 4
+
+// CheckFileMeta checks the File Meta Information of the DICOM file at path, whose parsed dataset
+// is ds. Every inconsistency is reported in the returned MultiError, nil means the meta header is
+// consistent.
+func CheckFileMeta(path string, ds dicom.Dataset) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header, _, err := readFileMetaBytes(bufio.NewReader(file))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorInvalidFileMeta, err)
+	}
+	errs := tyroErrors.New()
+	if string(header[128:132]) != "DICM" {
+		errs.Add(ErrorMissingFileMeta)
+		return errs
+	}
+
+	if declared, actual, ok := groupLengths(header); !ok {
+		errs.Add(fmt.Errorf("%w: (0002,0000) is missing", ErrorFileMetaGroupLength))
+	} else if declared != actual {
+		errs.Add(fmt.Errorf("%w: (0002,0000) is %d but the group is %d bytes long", ErrorFileMetaGroupLength, declared, actual))
+	}
+
+	for _, t := range []tag.Tag{tag.MediaStorageSOPClassUID, tag.MediaStorageSOPInstanceUID, tag.TransferSyntaxUID} {
+		if uidAttribute(ds, t) == "" {
+			errs.Add(fmt.Errorf("%w: %s", ErrorMissingFileMetaElement, tagName(t)))
+		}
+	}
+	for _, pair := range [][2]tag.Tag{
+		{tag.MediaStorageSOPClassUID, tag.SOPClassUID},
+		{tag.MediaStorageSOPInstanceUID, tag.SOPInstanceUID},
+	} {
+		meta, data := uidAttribute(ds, pair[0]), uidAttribute(ds, pair[1])
+		if meta != "" && data != "" && meta != data {
+			errs.Add(fmt.Errorf("%w: %s %s differs from %s %s", ErrorFileMetaMismatch, tagName(pair[0]), meta, tagName(pair[1]), data))
+		}
+	}
+
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// FileMetaChange returns the change regenerating the File Meta Information of ds, the dataset of
+// the file at path, or nil if CheckFileMeta finds no inconsistency.
+func FileMetaChange(path string, ds dicom.Dataset) (*ElementChange, error) {
+	issues := CheckFileMeta(path, ds)
+	if issues == nil {
+		return nil, nil
+	}
+	// Anything but a list of inconsistencies means the file could not be checked.
+	var multi *tyroErrors.MultiError
+	if !errors.As(issues, &multi) {
+		return nil, issues
+	}
+	return &ElementChange{Kind: ChangeFileMeta, File: path, Path: NewTagPath(tag.FileMetaInformationGroupLength)}, nil
+}
+
+// FixFileMeta regenerates the File Meta Information of the DICOM file at path if CheckFileMeta
+// finds an inconsistency and saves the file. Consistent files are left untouched and false is
+// returned.
+//
+// The previous version of the file is kept as a backup, see ReplaceFile.
+func FixFileMeta(path string) (bool, error) {
+	ds, err := parseFile(path, dicom.SkipPixelData())
+	if err != nil {
+		return false, err
+	}
+	change, err := FileMetaChange(path, ds)
+	if err != nil || change == nil {
+		return false, err
+	}
+	return true, applyFileChanges(path, []*ElementChange{change}, false, true)
+}
+
+// regenerateFileMeta replaces the File Meta Information of ds with one derived from the data set.
+//
+// The Media Storage SOP Class and Instance UIDs are taken from the SOP Class and Instance UIDs
+// and tyro is recorded as implementation. The transfer syntax and optional elements, such as the
+// Source Application Entity Title, are kept. Returns the replaced meta elements.
+func regenerateFileMeta(ds *dicom.Dataset) ([]*dicom.Element, error) {
+	old, _ := splitFileMeta(ds.Elements)
+
+	derived := []struct {
+		tag   tag.Tag
+		value string
+	}{
+		{tag.MediaStorageSOPClassUID, uidAttribute(*ds, tag.SOPClassUID)},
+		{tag.MediaStorageSOPInstanceUID, uidAttribute(*ds, tag.SOPInstanceUID)},
+		{tag.TransferSyntaxUID, transferSyntaxOf(*ds)},
+		{tag.ImplementationClassUID, ImplementationClassUID},
+		{tag.ImplementationVersionName, ImplementationVersionName},
+	}
+
+	version, err := dicom.NewElement(tag.FileMetaInformationVersion, []byte{0, 1})
+	if err != nil {
+		return nil, err
+	}
+	meta := []*dicom.Element{version}
+	regenerated := map[tag.Tag]bool{tag.FileMetaInformationGroupLength: true, tag.FileMetaInformationVersion: true}
+	for _, d := range derived {
+		value := d.value
+		if value == "" {
+			// The data set lacks the UID, so the meta header keeps its value.
+			value = uidAttribute(*ds, d.tag)
+		}
+		if value == "" {
+			return nil, fmt.Errorf("%w: %s cannot be derived from the data set", ErrorMissingFileMetaElement, tagName(d.tag))
+		}
+		elem, err := dicom.NewElement(d.tag, []string{value})
+		if err != nil {
+			return nil, err
+		}
+		meta = append(meta, elem)
+		regenerated[d.tag] = true
+	}
+	for _, elem := range old {
+		if !regenerated[elem.Tag] {
+			meta = append(meta, elem)
+		}
+	}
+	slices.SortFunc(meta, func(a, b *dicom.Element) int {
+		return int(a.Tag.Element) - int(b.Tag.Element)
+	})
+
+	if err := replaceFileMeta(ds, meta); err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+// replaceFileMeta replaces the File Meta Information of ds with meta and updates the group length
+// to the length of the encoded group.
+func replaceFileMeta(ds *dicom.Dataset, meta []*dicom.Element) error {
+	var elems []*dicom.Element
+	for _, elem := range meta {
+		if elem.Tag != tag.FileMetaInformationGroupLength {
+			elems = append(elems, elem)
+		}
+	}
+
+	encoded, err := encodeFileMeta(elems)
+	if err != nil {
+		return err
+	}
+	length, err := dicom.NewElement(tag.FileMetaInformationGroupLength, []int{len(encoded) - 132 - groupLengthElementSize})
+	if err != nil {
+		return err
+	}
+
+	_, rest := splitFileMeta(ds.Elements)
+	ds.Elements = append(append([]*dicom.Element{length}, elems...), rest...)
+	return nil
+}
+
+// splitFileMeta splits elems into the leading File Meta Information elements and the data set.
+func splitFileMeta(elems []*dicom.Element) ([]*dicom.Element, []*dicom.Element) {
+	end := 0
+	for end < len(elems) && elems[end].Tag.Group == tag.MetadataGroup {
+		end++
+	}
+	return elems[:end:end], elems[end:]
+}
+
+// encodeFileMeta encodes meta as the preamble and File Meta Information of an otherwise empty file.
+func encodeFileMeta(meta []*dicom.Element) ([]byte, error) {
+	out := &bytes.Buffer{}
+	opts := append(slices.Clone(writeOptions), dicom.DefaultMissingTransferSyntax())
+	if err := dicom.Write(out, dicom.Dataset{Elements: meta}, opts...); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// decodeFileMeta decodes the File Meta Information elements encoded by encodeFileMeta.
+func decodeFileMeta(data []byte) ([]*dicom.Element, error) {
+	ds, err := dicom.ParseUntilEOF(bytes.NewReader(data), nil)
+	if err != nil {
+		return nil, err
+	}
+	meta, _ := splitFileMeta(ds.Elements)
+	return meta, nil
+}
+
+// groupLengths returns the File Meta Information Group Length declared in header, as returned by
+// readFileMetaBytes, and the actual length of the group. ok is false if the header does not start
+// with a group length element.
+func groupLengths(header []byte) (declared int, actual int, ok bool) {
+	if len(header) < 132+groupLengthElementSize {
+		return 0, 0, false
+	}
+	elem := header[132:]
+	if binary.LittleEndian.Uint16(elem) != tag.MetadataGroup ||
+		binary.LittleEndian.Uint16(elem[2:]) != tag.FileMetaInformationGroupLength.Element ||
+		string(elem[4:6]) != "UL" || binary.LittleEndian.Uint16(elem[6:]) != 4 {
+		return 0, 0, false
+	}
+	return int(binary.LittleEndian.Uint32(elem[8:])), len(header) - 132 - groupLengthElementSize, true
+}
+
+// correctGroupLength returns header, as returned by readFileMetaBytes, with a File Meta Information
+// Group Length matching the length of the group, so that the DICOM library reads the File Meta
+// Information of files with a wrong or missing group length correctly.
+func correctGroupLength(header []byte) []byte {
+	if len(header) <= 132 || string(header[128:132]) != "DICM" {
+		return header
+	}
+
+	declared, actual, ok := groupLengths(header)
+	if ok && declared == actual {
+		return header
+	}
+	group := header[132:]
+	if ok {
+		group = group[groupLengthElementSize:]
+	}
+
+	corrected := make([]byte, 0, 132+groupLengthElementSize+len(group))
+	corrected = append(corrected, header[:132]...)
+	corrected = binary.LittleEndian.AppendUint16(corrected, tag.MetadataGroup)
+	corrected = binary.LittleEndian.AppendUint16(corrected, tag.FileMetaInformationGroupLength.Element)
+	corrected = append(corrected, "UL"...)
+	corrected = binary.LittleEndian.AppendUint16(corrected, 4)
+	corrected = binary.LittleEndian.AppendUint32(corrected, uint32(len(group)))
+	return append(corrected, group...)
+}
+
+// uidAttribute returns the UID stored in the top level element with the given tag without padding,
+// or an empty string if there is none.
+func uidAttribute(ds dicom.Dataset, t tag.Tag) string {
+	value, err := stringAttribute(ds, t)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(value, " \x00")
+}
+
+// tagName returns the keyword of t, or its number if t is not part of the data dictionary.
+func tagName(t tag.Tag) string {
+	if info, err := tag.Find(t); err == nil {
+		return info.Name
+	}
+	return fmt.Sprintf("(%04X,%04X)", t.Group, t.Element)
+}
@@ -0,0 +1,158 @@
+// findReplace.go implements searching element values with a regular expression and replacing the
+// matches, e.g. to standardise institution names across an archive.
+//
+// The search covers the elements selected by a list of tag selectors at any depth, including
+// elements nested in sequence items. Every hit records the value after the replacement, so the
+// hits can be reviewed before they are turned into element changes.
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/suyashkumar/dicom"
+)
+
+// ErrorInvalidFindReplace is returned when a search specification cannot be parsed.
+var ErrorInvalidFindReplace = errors.New("invalid find/replace")
+
+// FindReplace searches the values of the selected elements for Pattern and replaces the matches.
+type FindReplace struct {
+	// Selectors select the searched elements.
+	Selectors []TagSelector
+	// Pattern is the regular expression searched for.
+	Pattern *regexp.Regexp
+	// Replacement replaces every match and may refer to capture groups as $1 or ${name}.
+	Replacement string
+	// Replace reports whether matches are replaced. Without a replacement, hits are only listed.
+	Replace bool
+}
+
+// ParseFindReplace parses a search written as "<selectors> /<pattern>/<replacement>/", where
+// selectors is a comma separated list as accepted by ParseTagSelectors. Without a replacement,
+// i.e. "<selectors> /<pattern>/", the matches are only searched. Any character can be used as
+// delimiter instead of a slash.
+func ParseFindReplace(spec string) (FindReplace, error) {
+	selectors, expression, _ := strings.Cut(strings.TrimSpace(spec), " ")
+	expression = strings.TrimSpace(expression)
+	if len(expression) < 2 {
+		return FindReplace{}, fmt.Errorf("%w: expected <tags> /pattern/replacement/", ErrorInvalidFindReplace)
+	}
+
+	var f FindReplace
+	var err error
+	if f.Selectors, err = ParseTagSelectors(selectors); err != nil {
+		return FindReplace{}, err
+	}
+
+	delimiter := expression[:1]
+	pattern, replacement, ok := strings.Cut(expression[1:], delimiter)
+	if !ok {
+		return FindReplace{}, fmt.Errorf("%w: pattern is not terminated by %q", ErrorInvalidFindReplace, delimiter)
+	}
+	if f.Pattern, err = regexp.Compile(pattern); err != nil {
+		return FindReplace{}, fmt.Errorf("%w: %v", ErrorInvalidFindReplace, err)
+	}
+	if replacement != "" {
+		f.Replace = true
+		f.Replacement = strings.TrimSuffix(replacement, delimiter)
+	}
+	return f, nil
+}
+
+// String describes the search, e.g. `replace /HOSP_A/ with "Hospital A" in (0008,0080)`.
+func (f FindReplace) String() string {
+	selectors := make([]string, len(f.Selectors))
+	for i, selector := range f.Selectors {
+		selectors[i] = selector.String()
+	}
+	if !f.Replace {
+		return fmt.Sprintf("find /%s/ in %s", f.Pattern, strings.Join(selectors, ","))
+	}
+	return fmt.Sprintf("replace /%s/ with %q in %s", f.Pattern, f.Replacement, strings.Join(selectors, ","))
+}
+
+// Hit is an element value matching a search.
+type Hit struct {
+	// File is the path of the DICOM file containing the element.
+	File string
+	// Path addresses the element within the file's dataset.
+	Path TagPath
+	// Old is the value of the element.
+	Old string
+	// New is the value after replacing all matches. It equals Old if nothing is replaced.
+	New string
+	// Err is set if New is not a valid value of the element.
+	Err error
+}
+
+// Find returns the hits within ds, the dataset of the file at path, in dataset order.
+func (f FindReplace) Find(path string, ds dicom.Dataset) []Hit {
+	return f.find(path, ds.Elements, nil, 0)
+}
+
+// find returns the hits within elems, which are contained in the given item of the sequence
+// addressed by sequence. sequence is nil for the top level elements of a dataset.
+func (f FindReplace) find(path string, elems []*dicom.Element, sequence TagPath, item int) []Hit {
+	var hits []Hit
+	for _, elem := range elems {
+		elemPath := NewTagPath(elem.Tag)
+		if sequence != nil {
+			elemPath = sequence.Child(item, elem.Tag)
+		}
+
+		if elem.Value != nil && elem.Value.ValueType() == dicom.Sequences {
+			for i, itemElems := range itemsOf(elem) {
+				hits = append(hits, f.find(path, itemElems, elemPath, i)...)
+			}
+			continue
+		}
+		if !IsEditable(elem) || !matchesAny(f.Selectors, elem.Tag) {
+			continue
+		}
+
+		old := ElementText(elem)
+		if !f.Pattern.MatchString(old) {
+			continue
+		}
+		hit := Hit{File: path, Path: elemPath, Old: old, New: old}
+		if f.Replace {
+			hit.New = f.Pattern.ReplaceAllString(old, f.Replacement)
+			if _, err := ParseElementText(elem, hit.New); err != nil {
+				hit.Err = fmt.Errorf("%s: %s: %w", path, elemPath, err)
+			}
+		}
+		hits = append(hits, hit)
+	}
+	return hits
+}
+
+// FindInFiles searches the parsed datasets of files concurrently and reports the progress to
+// progress. The hits are returned in the order of files.
+func FindInFiles(files []*ParsedDicomFile, f FindReplace, progress Progress) []Hit {
+	fileHits := make([][]Hit, len(files))
+	forEachFile(len(files), progress, func(i int) {
+		fileHits[i] = f.Find(files[i].Path, files[i].Dataset)
+	})
+
+	var hits []Hit
+	for _, h := range fileHits {
+		hits = append(hits, h...)
+	}
+	return hits
+}
+
+// HitChanges returns the element changes replacing the values of all valid hits that change a
+// value. Hits with an invalid replacement are skipped.
+func HitChanges(hits []Hit) []ElementChange {
+	var changes []ElementChange
+	for _, hit := range hits {
+		if hit.Err != nil || hit.New == hit.Old {
+			continue
+		}
+		changes = append(changes, ElementChange{Kind: ChangeSet, File: hit.File, Path: hit.Path, Old: hit.Old, New: hit.New})
+	}
+	return changes
+}
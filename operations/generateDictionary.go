@@ -0,0 +1,73 @@
+//go:build ignore
+
+// generateDictionary.go generates dictionaryTags.go, the list of all tags of the data dictionary.
+//
+// The DICOM library only supports looking up single tags, so the tags are extracted from the
+// library's generated tag definitions. Run it with "go generate ./operations".
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// definitionPattern matches the dictionary entries of the library's tag_definitions.go.
+var definitionPattern = regexp.MustCompile(`^\ttagDict\[Tag\{(0x[0-9A-Fa-f]{4}), (0x[0-9A-Fa-f]{4})\}\]`)
+
+func main() {
+	dir, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "github.com/suyashkumar/dicom").Output()
+	if err != nil {
+		log.Fatalf("locating the DICOM library: %v", err)
+	}
+	definitions, err := os.Open(filepath.Join(strings.TrimSpace(string(dir)), "pkg", "tag", "tag_definitions.go"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer definitions.Close()
+
+	out := &bytes.Buffer{}
+	fmt.Fprintln(out, "// Code generated by generateDictionary.go. DO NOT EDIT.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "package operations")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "// dictionaryTags are all tags of the data dictionary of the DICOM library, encoded as")
+	fmt.Fprintln(out, "// group << 16 | element.")
+	fmt.Fprintln(out, "var dictionaryTags = []uint32{")
+
+	scanner := bufio.NewScanner(definitions)
+	count := 0
+	for scanner.Scan() {
+		match := definitionPattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		fmt.Fprintf(out, "0x%s%s,", strings.ToUpper(match[1][2:]), strings.ToUpper(match[2][2:]))
+		count++
+		if count%8 == 0 {
+			fmt.Fprintln(out)
+		} else {
+			fmt.Fprint(out, " ")
+		}
+	}
+	fmt.Fprintln(out)
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintln(out, "}")
+
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("dictionaryTags.go", source, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,320 @@
+// imageExport.go provides utilities for exporting decoded frames to PNG images and raw pixel dumps.
+//
+// PNG images are written with the standard library encoder as 8-bit or 16-bit grayscale, or RGB
+// for color images, optionally applying the VOI window. Raw dumps contain the stored sample values
+// in little endian byte order and are accompanied by a JSON sidecar describing their geometry.
+package operations
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExportFormat selects the output format of ExportFrames.
+type ExportFormat int
+
+const (
+	// ExportPNG8 writes every frame as an 8-bit grayscale or RGB PNG.
+	ExportPNG8 ExportFormat = iota
+	// ExportPNG16 writes every frame as a 16-bit grayscale or RGB PNG.
+	ExportPNG16
+	// ExportRaw writes all frames to a single little endian raw file with a JSON sidecar.
+	ExportRaw
+)
+
+// ErrorUnknownExportFormat is returned when an export format name cannot be parsed.
+var ErrorUnknownExportFormat = errors.New("unknown export format")
+
+// ParseExportFormat converts the name of an export format (png8, png16 or raw) into an ExportFormat.
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch strings.ToLower(name) {
+	case "png", "png8":
+		return ExportPNG8, nil
+	case "png16":
+		return ExportPNG16, nil
+	case "raw":
+		return ExportRaw, nil
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrorUnknownExportFormat, name)
+	}
+}
+
+// ExportOptions controls how ExportFrames writes its output.
+type ExportOptions struct {
+	// Format selects the output format.
+	Format ExportFormat
+	// OutputDir is the directory the exported files are written to. It is created if it does not exist.
+	OutputDir string
+	// Frames lists the zero based indices of the frames to export. All frames are exported if it is empty.
+	Frames []int
+	// ApplyWindow applies the Modality LUT and the VOI window to monochrome PNG output.
+	// If it is false the stored sample values are written. Raw dumps are never windowed.
+	ApplyWindow bool
+	// Window overrides the window stored in the dataset when ApplyWindow is set.
+	Window *Window
+}
+
+// RawSidecar describes the content of a raw pixel dump. It is written as JSON next to the dump.
+type RawSidecar struct {
+	// Source is the path of the DICOM file the frames were exported from.
+	Source string `json:"source"`
+	// Rows is the number of rows of each frame.
+	Rows int `json:"rows"`
+	// Columns is the number of columns of each frame.
+	Columns int `json:"columns"`
+	// Frames lists the zero based indices of the exported frames in the order they appear in the dump.
+	Frames []int `json:"frames"`
+	// SamplesPerPixel is the number of interleaved samples per pixel.
+	SamplesPerPixel int `json:"samplesPerPixel"`
+	// BitsAllocated is the size of a single sample in the dump.
+	BitsAllocated int `json:"bitsAllocated"`
+	// BitsStored is the number of significant bits of each sample.
+	BitsStored int `json:"bitsStored"`
+	// Signed reports whether samples are two's complement integers.
+	Signed bool `json:"signed"`
+	// ByteOrder is always "little".
+	ByteOrder string `json:"byteOrder"`
+	// PhotometricInterpretation is copied from the dataset.
+	PhotometricInterpretation string `json:"photometricInterpretation"`
+	// RescaleSlope is copied from the dataset.
+	RescaleSlope float64 `json:"rescaleSlope"`
+	// RescaleIntercept is copied from the dataset.
+	RescaleIntercept float64 `json:"rescaleIntercept"`
+	// Window is the window stored in the dataset, if any.
+	Window *Window `json:"window,omitempty"`
+}
+
+// ExportFrames writes the selected frames of the pixel data to opts.OutputDir.
+//
+// Returns the paths of all written files. PNG files are named <file>_frameNNNN.png, raw dumps
+// <file>.raw with a <file>.json sidecar, where <file> is the base name of the source file. Existing
+// files are never overwritten: if a name is taken, a number is appended to it, e.g.
+// <file>_frame0001-2.png.
+func ExportFrames(pixels *PixelData, opts ExportOptions) ([]string, error) {
+	frames := opts.Frames
+	if len(frames) == 0 {
+		frames = make([]int, len(pixels.Frames))
+		for i := range frames {
+			frames[i] = i
+		}
+	}
+	for _, f := range frames {
+		if f < 0 || f >= len(pixels.Frames) {
+			return nil, fmt.Errorf("%w: %d", ErrorFrameOutOfRange, f+1)
+		}
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0o755); err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(filepath.Base(pixels.Path), filepath.Ext(pixels.Path))
+
+	if opts.Format == ExportRaw {
+		return exportRaw(pixels, frames, opts.OutputDir, base)
+	}
+
+	written := make([]string, 0, len(frames))
+	for _, f := range frames {
+		img := pixels.exportImage(f, opts)
+		path, err := writePNG(opts.OutputDir, fmt.Sprintf("%s_frame%04d", base, f+1), img)
+		if err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// exportImage converts a single frame to an image suitable for PNG encoding.
+func (p *PixelData) exportImage(frameIndex int, opts ExportOptions) image.Image {
+	samples := p.Frames[frameIndex]
+	rect := image.Rect(0, 0, p.Info.Columns, p.Info.Rows)
+	pixels := p.Info.Rows * p.Info.Columns
+	maxOut := 255.0
+	if opts.Format == ExportPNG16 {
+		maxOut = 65535
+	}
+
+	// toOutput maps a stored sample to the output range, either through the window or by scaling
+	// the range of stored values (offset for signed data) to the output bit depth.
+	window := p.DefaultWindow(frameIndex)
+	if opts.Window != nil {
+		window = *opts.Window
+	}
+	storedRange := math.Pow(2, float64(p.Info.BitsStored)) - 1
+	offset := 0
+	if p.Info.PixelRepresentation == 1 {
+		offset = 1 << (p.Info.BitsStored - 1)
+	}
+	toOutput := func(sample int) float64 {
+		if opts.ApplyWindow && p.Info.IsMonochrome() {
+			return windowToRange(p.Info.ModalityValue(sample), window, maxOut)
+		}
+		return math.Round(float64(sample+offset) / storedRange * maxOut)
+	}
+
+	if p.Info.SamplesPerPixel == 1 {
+		invert := opts.ApplyWindow && p.Info.PhotometricInterpretation == "MONOCHROME1"
+		if opts.Format == ExportPNG16 {
+			img := image.NewGray16(rect)
+			for i := 0; i < pixels; i++ {
+				v := toOutput(samples[i])
+				if invert {
+					v = maxOut - v
+				}
+				img.SetGray16(i%p.Info.Columns, i/p.Info.Columns, color.Gray16{Y: uint16(v)})
+			}
+			return img
+		}
+		img := image.NewGray(rect)
+		for i := 0; i < pixels; i++ {
+			v := toOutput(samples[i])
+			if invert {
+				v = maxOut - v
+			}
+			img.Pix[i] = uint8(v)
+		}
+		return img
+	}
+
+	if opts.Format == ExportPNG16 {
+		img := image.NewNRGBA64(rect)
+		ybr := strings.HasPrefix(p.Info.PhotometricInterpretation, "YBR")
+		for i := 0; i < pixels; i++ {
+			s := samples[i*p.Info.SamplesPerPixel:]
+			r, g, b := toOutput(s[0]), toOutput(s[1]), toOutput(s[2])
+			if ybr {
+				r, g, b = ybrToRGB(r, g, b, maxOut)
+			}
+			img.SetNRGBA64(i%p.Info.Columns, i/p.Info.Columns, color.NRGBA64{
+				R: uint16(r),
+				G: uint16(g),
+				B: uint16(b),
+				A: 65535,
+			})
+		}
+		return img
+	}
+	img, _ := p.RenderFrame(frameIndex, window)
+	return img
+}
+
+// ybrToRGB converts full range YCbCr components in [0, maxOut] to RGB, using the same
+// coefficients as color.YCbCrToRGB does for the 8-bit output.
+func ybrToRGB(y, cb, cr, maxOut float64) (float64, float64, float64) {
+	half := (maxOut + 1) / 2
+	cb, cr = cb-half, cr-half
+	clamp := func(v float64) float64 {
+		return math.Round(math.Max(0, math.Min(maxOut, v)))
+	}
+	return clamp(y + 1.402*cr), clamp(y - 0.344136*cb - 0.714136*cr), clamp(y + 1.772*cb)
+}
+
+// windowToRange applies the linear VOI LUT function and scales the result to [0, maxOut].
+func windowToRange(value float64, window Window, maxOut float64) float64 {
+	width := math.Max(window.Width, 1)
+	lower := window.Center - 0.5 - (width-1)/2
+	upper := window.Center - 0.5 + (width-1)/2
+
+	switch {
+	case value <= lower:
+		return 0
+	case value > upper:
+		return maxOut
+	default:
+		return math.Round(((value-(window.Center-0.5))/(width-1) + 0.5) * maxOut)
+	}
+}
+
+// writePNG encodes img as PNG to a new file named base in dir, see createUnique, and returns its
+// path.
+func writePNG(dir, base string, img image.Image) (string, error) {
+	paths, handles, err := createUnique(dir, base, ".png")
+	if err != nil {
+		return "", err
+	}
+	err = png.Encode(handles[0], img)
+	if closeErr := handles[0].Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(paths[0])
+		return "", err
+	}
+	return paths[0], nil
+}
+
+// exportRaw writes the selected frames to a new file <base>.raw in dir in little endian byte order
+// and describes them in <base>.json, see createUnique.
+func exportRaw(pixels *PixelData, frames []int, dir, base string) ([]string, error) {
+	bitsAllocated := pixels.Info.BitsAllocated
+	if bitsAllocated == 1 {
+		// Single bit samples are widened to one byte each to keep the dump easy to consume.
+		bitsAllocated = 8
+	}
+	bytesPerSample := bitsAllocated / 8
+
+	data := make([]byte, 0, len(frames)*pixels.Info.FrameSize()*bytesPerSample)
+	for _, f := range frames {
+		for _, sample := range pixels.Frames[f] {
+			switch bytesPerSample {
+			case 1:
+				data = append(data, byte(sample))
+			case 2:
+				data = binary.LittleEndian.AppendUint16(data, uint16(sample))
+			default:
+				data = binary.LittleEndian.AppendUint32(data, uint32(sample))
+			}
+		}
+	}
+
+	sidecar := RawSidecar{
+		Source:                    pixels.Path,
+		Rows:                      pixels.Info.Rows,
+		Columns:                   pixels.Info.Columns,
+		Frames:                    frames,
+		SamplesPerPixel:           pixels.Info.SamplesPerPixel,
+		BitsAllocated:             bitsAllocated,
+		BitsStored:                pixels.Info.BitsStored,
+		Signed:                    pixels.Info.PixelRepresentation == 1,
+		ByteOrder:                 "little",
+		PhotometricInterpretation: pixels.Info.PhotometricInterpretation,
+		RescaleSlope:              pixels.Info.RescaleSlope,
+		RescaleIntercept:          pixels.Info.RescaleIntercept,
+	}
+	if pixels.Info.HasWindow {
+		window := pixels.Info.Window
+		sidecar.Window = &window
+	}
+
+	encoded, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	paths, handles, err := createUnique(dir, base, ".raw", ".json")
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for i, content := range [][]byte{data, encoded} {
+		_, err := handles[i].Write(content)
+		errs = append(errs, err, handles[i].Close())
+	}
+	if err := errors.Join(errs...); err != nil {
+		for _, path := range paths {
+			os.Remove(path)
+		}
+		return nil, err
+	}
+	return paths, nil
+}
@@ -0,0 +1,731 @@
+// journal.go implements the undo/redo history of all element modifications and its on-disk journal.
+//
+// Every modification is performed as an Operation, a list of element changes that is undone and
+// redone as a whole. Before an operation is performed, undone or redone, a pending record is
+// appended to the journal and synced to disk. A second record marks the action as done once all
+// files have been written. If tyro is terminated in between, the journal ends with a pending
+// record and the next History opened for the same root reports the interrupted action, which can
+// then be replayed or rolled back.
+//
+// Changes removing identifying information, e.g. those of a de-identification, are marked as
+// redacting. The journal does not record the values they replace and the files they modify are not
+// backed up, so no identifying information is kept on disk once the operation is done. Operations
+// containing them cannot be undone, and if they are interrupted they can only be replayed.
+//
+// Journals are stored below ~/.tyro/journals by default, in a directory mirroring the absolute path
+// of the root directory. The location can be changed with the TYRO_JOURNAL_DIR environment variable.
+package operations
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// JournalDirEnv is the environment variable overriding the default journal location.
+const JournalDirEnv = "TYRO_JOURNAL_DIR"
+
+// journalFileName is the name of the journal file within the journal directory of a root.
+const journalFileName = "journal.jsonl"
+
+var (
+	// ErrorNothingToUndo is returned when undo is requested but no operation has been performed.
+	ErrorNothingToUndo = errors.New("nothing to undo")
+	// ErrorNothingToRedo is returned when redo is requested but no operation has been undone.
+	ErrorNothingToRedo = errors.New("nothing to redo")
+	// ErrorPendingOperation is returned when an operation is requested while an interrupted
+	// operation has neither been replayed nor rolled back.
+	ErrorPendingOperation = errors.New("an interrupted operation has to be replayed or rolled back first")
+	// ErrorIrreversibleOperation is returned when an operation containing redacting changes is to
+	// be undone or rolled back.
+	ErrorIrreversibleOperation = errors.New("the operation removed identifying information and cannot be undone")
+)
+
+// ErrorFileMetaNotEditable is returned when File Meta Information elements are added or removed.
+var ErrorFileMetaNotEditable = errors.New("File Meta Information elements can only be changed, not added or removed")
+
+// ChangeKind is the kind of modification described by an ElementChange.
+type ChangeKind string
+
+const (
+	// ChangeSet replaces the value of an existing element.
+	ChangeSet ChangeKind = "set"
+	// ChangeAdd adds a new element.
+	ChangeAdd ChangeKind = "add"
+	// ChangeRemove removes an element including all of its items.
+	ChangeRemove ChangeKind = "remove"
+	// ChangeAddItem adds an empty item to a sequence.
+	ChangeAddItem ChangeKind = "add-item"
+	// ChangeRemoveItem removes an item from a sequence.
+	ChangeRemoveItem ChangeKind = "remove-item"
+	// ChangeTranscode converts the whole dataset to the transfer syntax New. Path addresses the
+	// Transfer Syntax UID of the File Meta Information.
+	ChangeTranscode ChangeKind = "transcode"
+	// ChangeFileMeta regenerates the File Meta Information from the data set. Path addresses the
+	// File Meta Information Group Length.
+	ChangeFileMeta ChangeKind = "fix-meta"
+	// ChangeBlank sets the samples within the pixel regions New of the native Pixel Data to zero,
+	// see ParsePixelRegions. Path addresses the Pixel Data.
+	ChangeBlank ChangeKind = "blank"
+)
+
+// ElementChange is a single modification of the dataset of a single file.
+type ElementChange struct {
+	// Kind is the kind of the modification.
+	Kind ChangeKind `json:"kind"`
+	// File is the path of the modified DICOM file.
+	File string `json:"file"`
+	// Path addresses the modified element within the file's dataset. For item changes it
+	// addresses the sequence.
+	Path TagPath `json:"path"`
+	// VR is the Value Representation of an added element.
+	VR string `json:"vr,omitempty"`
+	// Item is the zero based index of an added or removed sequence item.
+	Item int `json:"item,omitempty"`
+	// Old is the textual representation of a changed value before the change. It is captured
+	// when the change is performed.
+	Old string `json:"old,omitempty"`
+	// New is the textual representation of a changed value or of the value of an added element.
+	New string `json:"new,omitempty"`
+	// Data is the encoded element or item removed by the change, the File Meta Information
+	// replaced by ChangeFileMeta or the pixel data bytes overwritten by ChangeBlank. It is captured
+	// when the change is performed, so it can be undone. ChangeBlank is always redacting, so its
+	// Data never reaches the journal.
+	Data []byte `json:"data,omitempty"`
+	// Redact marks a change removing identifying information. Its Old and Data are kept in memory
+	// only, so they are never written to the journal, and the file is not backed up.
+	Redact bool `json:"redact,omitempty"`
+}
+
+// redact marks changes as redacting and returns them.
+func redact(changes []*ElementChange) []*ElementChange {
+	for _, change := range changes {
+		change.Redact = true
+	}
+	return changes
+}
+
+// Operation is a list of element changes that is performed, undone and redone as a whole.
+type Operation struct {
+	// ID identifies the operation within its journal.
+	ID int `json:"id"`
+	// Description is a short human readable summary of the operation.
+	Description string `json:"description"`
+	// Time is the point in time the operation was first performed.
+	Time time.Time `json:"time"`
+	// Changes are the element changes of the operation in the order they are applied.
+	Changes []ElementChange `json:"changes"`
+}
+
+// Files returns the paths of all files modified by the operation without duplicates.
+func (o Operation) Files() []string {
+	seen := map[string]bool{}
+	var files []string
+	for _, change := range o.Changes {
+		if !seen[change.File] {
+			seen[change.File] = true
+			files = append(files, change.File)
+		}
+	}
+	return files
+}
+
+// Irreversible reports whether the operation contains redacting changes. The values they replaced
+// are not recorded, so the operation can neither be undone nor rolled back.
+func (o Operation) Irreversible() bool {
+	return slices.ContainsFunc(o.Changes, func(change ElementChange) bool { return change.Redact })
+}
+
+// redacted returns o without the replaced values of its redacting changes.
+func (o Operation) redacted() Operation {
+	if !o.Irreversible() {
+		return o
+	}
+	o.Changes = slices.Clone(o.Changes)
+	for i := range o.Changes {
+		if o.Changes[i].Redact {
+			o.Changes[i].Old = ""
+			o.Changes[i].Data = nil
+		}
+	}
+	return o
+}
+
+// JournalAction is an action recorded in the journal.
+type JournalAction string
+
+const (
+	// ActionDo performs an operation for the first time.
+	ActionDo JournalAction = "do"
+	// ActionUndo reverts an operation.
+	ActionUndo JournalAction = "undo"
+	// ActionRedo performs an undone operation again.
+	ActionRedo JournalAction = "redo"
+)
+
+// journalState is the state of an action recorded in the journal.
+type journalState string
+
+const (
+	// statePending is recorded before the files of an action are written.
+	statePending journalState = "pending"
+	// stateDone is recorded after all files of an action have been written.
+	stateDone journalState = "done"
+	// stateAborted is recorded after a failed or rolled back action has been reverted.
+	stateAborted journalState = "aborted"
+)
+
+// journalRecord is a single line of the journal.
+type journalRecord struct {
+	Action JournalAction `json:"action"`
+	State  journalState  `json:"state"`
+	ID     int           `json:"id"`
+	// Operation is only recorded for pending do actions. Other records refer to it by ID.
+	Operation *Operation `json:"operation,omitempty"`
+}
+
+// PendingAction is an action that was interrupted before all of its files had been written.
+type PendingAction struct {
+	// Action is the interrupted action.
+	Action JournalAction
+	// Operation is the operation the action was performed on.
+	Operation Operation
+}
+
+// History is the undo/redo history of the element modifications within a root directory.
+//
+// The zero value is a usable history that is not backed by a journal. History is safe for
+// concurrent use; actions are performed one after another.
+type History struct {
+	mu sync.Mutex
+
+	journal *os.File
+
+	undo    []Operation
+	redo    []Operation
+	pending *PendingAction
+	nextID  int
+}
+
+// JournalDir returns the root directory journals are stored in.
+func JournalDir() (string, error) {
+	if dir := os.Getenv(JournalDirEnv); dir != "" {
+		return filepath.Abs(dir)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine journal location, set %s: %w", JournalDirEnv, err)
+	}
+	return filepath.Join(home, ".tyro", "journals"), nil
+}
+
+// OpenHistory opens the journal of the given root directory and restores the history recorded in it.
+//
+// If the journal ends with an interrupted action, it is reported by Pending and has to be
+// replayed or rolled back with Recover before any other action can be performed.
+func OpenHistory(root string) (*History, error) {
+	dir, err := JournalDir()
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	dir = filepath.Join(dir, strings.TrimPrefix(abs, filepath.VolumeName(abs)))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	journal, err := os.OpenFile(filepath.Join(dir, journalFileName), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &History{journal: journal}
+	if err := h.restore(); err != nil {
+		journal.Close()
+		return nil, fmt.Errorf("reading journal %s: %w", journal.Name(), err)
+	}
+	return h, nil
+}
+
+// Close closes the journal.
+func (h *History) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.journal == nil {
+		return nil
+	}
+	err := h.journal.Close()
+	h.journal = nil
+	return err
+}
+
+// Pending returns the interrupted action found in the journal or nil if there is none.
+func (h *History) Pending() *PendingAction {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.pending
+}
+
+// Do performs a new operation consisting of the given changes and adds it to the undo stack.
+//
+// New values are validated against the VR of their elements. If any change cannot be applied, no
+// file is written. If any file cannot be written, the files written so far are reverted and the
+// operation is discarded.
+//
+// The returned operation, like the one kept for undo, no longer holds the values replaced by
+// redacting changes.
+func (h *History) Do(description string, changes []ElementChange) (Operation, error) {
+	return h.DoWithProgress(description, changes, nil)
+}
+
+// DoWithProgress is Do for operations modifying many files. The progress of validating and
+// writing the files is reported to progress.
+func (h *History) DoWithProgress(description string, changes []ElementChange, progress Progress) (Operation, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pending != nil {
+		return Operation{}, ErrorPendingOperation
+	}
+
+	// A dry run validates the changes and captures the state they replace, so it is recorded in the
+	// journal before any file is written.
+	changes = slices.Clone(changes)
+	if _, err := applyChanges(changes, false, false, progress.phase(0, 2)); err != nil {
+		return Operation{}, err
+	}
+
+	op := Operation{ID: h.nextID, Description: description, Time: time.Now(), Changes: changes}
+	h.nextID++
+	if err := h.perform(ActionDo, op, progress.phase(1, 2)); err != nil {
+		return Operation{}, err
+	}
+	op = op.redacted()
+	h.undo = append(h.undo, op)
+	h.redo = nil
+	return op, nil
+}
+
+// Undo reverts the most recently performed operation and moves it to the redo stack.
+//
+// Irreversible operations are not undone and stay on the undo stack, so operations performed
+// before them cannot be undone either.
+func (h *History) Undo() (Operation, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pending != nil {
+		return Operation{}, ErrorPendingOperation
+	}
+	if len(h.undo) == 0 {
+		return Operation{}, ErrorNothingToUndo
+	}
+
+	op := h.undo[len(h.undo)-1]
+	if op.Irreversible() {
+		return Operation{}, fmt.Errorf("%w: %s", ErrorIrreversibleOperation, op.Description)
+	}
+	if err := h.perform(ActionUndo, op, nil); err != nil {
+		return Operation{}, err
+	}
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, op)
+	return op, nil
+}
+
+// Redo performs the most recently undone operation again and moves it back to the undo stack.
+func (h *History) Redo() (Operation, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pending != nil {
+		return Operation{}, ErrorPendingOperation
+	}
+	if len(h.redo) == 0 {
+		return Operation{}, ErrorNothingToRedo
+	}
+
+	op := h.redo[len(h.redo)-1]
+	if err := h.perform(ActionRedo, op, nil); err != nil {
+		return Operation{}, err
+	}
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, op)
+	return op, nil
+}
+
+// Recover resolves the interrupted action reported by Pending.
+//
+// If replay is true, the action is completed by writing all of its files again. Otherwise all
+// files are reset to the values they had before the action. Irreversible operations can only be
+// replayed; redacting changes that already apply to a file written before the interruption are
+// skipped.
+func (h *History) Recover(replay bool) (PendingAction, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pending == nil {
+		return PendingAction{}, nil
+	}
+	pending := *h.pending
+	if !replay && pending.Operation.Irreversible() {
+		return pending, fmt.Errorf("%w: replay %s instead", ErrorIrreversibleOperation, pending.Operation.Description)
+	}
+
+	reverse := pending.Action == ActionUndo
+	if !replay {
+		reverse = !reverse
+	}
+	if _, err := applyChanges(pending.Operation.Changes, reverse, true, nil); err != nil {
+		return pending, err
+	}
+
+	state := stateAborted
+	if replay {
+		state = stateDone
+	}
+	if err := h.record(journalRecord{Action: pending.Action, State: state, ID: pending.Operation.ID}); err != nil {
+		return pending, err
+	}
+	if replay {
+		h.transition(pending.Action, pending.Operation)
+	}
+	h.pending = nil
+	return pending, nil
+}
+
+// perform records action as pending, writes the files of op and records the action as done.
+//
+// Undo applies the old values of the changes, all other actions the new values. If a file cannot
+// be written, the files written so far are reverted and the action is recorded as aborted. The
+// progress of writing the files is reported to progress. The values replaced by redacting changes
+// are used to revert the files, but are not recorded.
+func (h *History) perform(action JournalAction, op Operation, progress Progress) error {
+	started := journalRecord{Action: action, State: statePending, ID: op.ID}
+	if action == ActionDo {
+		recorded := op.redacted()
+		started.Operation = &recorded
+	}
+	if err := h.record(started); err != nil {
+		return err
+	}
+
+	reverse := action == ActionUndo
+	written, err := applyChanges(op.Changes, reverse, true, progress)
+	if err != nil {
+		var revert []ElementChange
+		for _, change := range op.Changes {
+			for _, file := range written {
+				if change.File == file {
+					revert = append(revert, change)
+				}
+			}
+		}
+		if _, revertErr := applyChanges(revert, !reverse, true, nil); revertErr != nil {
+			// The files are in an inconsistent state, so the action stays pending for recovery.
+			h.pending = &PendingAction{Action: action, Operation: op.redacted()}
+			return fmt.Errorf("%w; reverting failed: %v", err, revertErr)
+		}
+		h.record(journalRecord{Action: action, State: stateAborted, ID: op.ID})
+		return err
+	}
+
+	return h.record(journalRecord{Action: action, State: stateDone, ID: op.ID})
+}
+
+// transition moves op between the undo and redo stacks according to a completed action.
+func (h *History) transition(action JournalAction, op Operation) {
+	switch action {
+	case ActionDo:
+		h.undo = append(h.undo, op)
+		h.redo = nil
+	case ActionUndo:
+		if len(h.undo) > 0 {
+			h.undo = h.undo[:len(h.undo)-1]
+		}
+		h.redo = append(h.redo, op)
+	case ActionRedo:
+		if len(h.redo) > 0 {
+			h.redo = h.redo[:len(h.redo)-1]
+		}
+		h.undo = append(h.undo, op)
+	}
+}
+
+// record appends rec to the journal and syncs it to disk.
+func (h *History) record(rec journalRecord) error {
+	if h.journal == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := h.journal.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return h.journal.Sync()
+}
+
+// restore rebuilds the undo and redo stacks and the pending action from the journal.
+func (h *History) restore() error {
+	if _, err := h.journal.Seek(0, 0); err != nil {
+		return err
+	}
+
+	operations := map[int]Operation{}
+	// Records of operations removing large elements span many megabytes, so lines are read
+	// without a length limit.
+	reader := bufio.NewReader(h.journal)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			return nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var rec journalRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			// A crash while appending leaves a truncated last line, which is ignored.
+			continue
+		}
+
+		if rec.Operation != nil {
+			operations[rec.ID] = *rec.Operation
+			h.nextID = max(h.nextID, rec.ID+1)
+		}
+		op, ok := operations[rec.ID]
+		if !ok {
+			continue
+		}
+
+		switch rec.State {
+		case statePending:
+			h.pending = &PendingAction{Action: rec.Action, Operation: op}
+		case stateDone:
+			h.transition(rec.Action, op)
+			h.pending = nil
+		case stateAborted:
+			h.pending = nil
+		}
+	}
+}
+
+// applyChanges applies changes to their files, or reverts them if reverse is true. Changes are
+// grouped by file, so every file is read and written once. Files are processed concurrently and
+// the progress is reported to progress.
+//
+// If save is false, the files are not written. Applying changes captures the state they replace,
+// i.e. the old value of ChangeSet and the encoded content of ChangeRemove and ChangeRemoveItem, so
+// a dry run prepares new changes for being recorded.
+//
+// Returns the files that have been written, even if writing another file failed.
+func applyChanges(changes []ElementChange, reverse bool, save bool, progress Progress) ([]string, error) {
+	var files []string
+	byFile := map[string][]*ElementChange{}
+	for i := range changes {
+		change := &changes[i]
+		if _, ok := byFile[change.File]; !ok {
+			files = append(files, change.File)
+		}
+		byFile[change.File] = append(byFile[change.File], change)
+	}
+
+	fileErrs := make([]error, len(files))
+	forEachFile(len(files), progress, func(i int) {
+		fileErrs[i] = applyFileChanges(files[i], byFile[files[i]], reverse, save)
+	})
+
+	var written []string
+	errs := tyroErrors.New()
+	for i, file := range files {
+		if fileErrs[i] != nil {
+			errs.Add(fmt.Errorf("%s: %w", file, fileErrs[i]))
+			continue
+		}
+		if save {
+			written = append(written, file)
+		}
+	}
+
+	if errs.HasErrors() {
+		return written, errs
+	}
+	return written, nil
+}
+
+// applyFileChanges applies changes that all belong to the DICOM file at path and saves it if
+// save is true. Reverted changes are applied in reverse order.
+//
+// Elements that are not affected by the changes are written exactly as they were read, see
+// sourceFile, and the saved file is verified, see saveVerified. Files modified by redacting changes
+// are not backed up.
+func applyFileChanges(path string, changes []*ElementChange, reverse bool, save bool) error {
+	ds, source, err := readSource(path, save, dicom.SkipProcessingPixelDataValue())
+	if err != nil {
+		return err
+	}
+
+	for i := range changes {
+		change := changes[i]
+		if reverse {
+			change = changes[len(changes)-1-i]
+		}
+		if err := applyChange(&ds, change, reverse); err != nil {
+			return fmt.Errorf("%s: %w", change.Path, err)
+		}
+	}
+
+	if !save {
+		return nil
+	}
+	backup := !slices.ContainsFunc(changes, func(change *ElementChange) bool { return change.Redact })
+	return saveVerified(path, ds, backup, func(out io.Writer) error {
+		return source.write(out, ds)
+	}, dicom.SkipProcessingPixelDataValue())
+}
+
+// applyChange applies change to ds or reverts it if reverse is true.
+//
+// New values are validated, old values are restored as they were recorded.
+func applyChange(ds *dicom.Dataset, change *ElementChange, reverse bool) error {
+	if change.Path.Tag().Group == tag.MetadataGroup && change.Kind != ChangeSet && change.Kind != ChangeTranscode && change.Kind != ChangeFileMeta {
+		return ErrorFileMetaNotEditable
+	}
+	if change.Redact && !reverse && redactionApplied(ds, change) {
+		return nil
+	}
+
+	switch {
+	case change.Kind == ChangeSet && !reverse:
+		elem, err := change.Path.Find(ds.Elements)
+		if err != nil {
+			return err
+		}
+		change.Old = ElementText(elem)
+		elem.Value, err = ParseElementText(elem, change.New)
+		return err
+	case change.Kind == ChangeSet && reverse:
+		elem, err := change.Path.Find(ds.Elements)
+		if err != nil {
+			return err
+		}
+		elem.Value, err = valueFromText(elem, change.Old)
+		return err
+
+	case change.Kind == ChangeAdd && !reverse:
+		elem, err := NewElement(change.Path.Tag(), change.VR, change.New)
+		if err != nil {
+			return err
+		}
+		return addElementAt(ds, change.Path, elem)
+	case change.Kind == ChangeRemove && !reverse,
+		change.Kind == ChangeAdd && reverse:
+		elem, err := removeElementAt(ds, change.Path)
+		if err != nil || change.Kind == ChangeAdd {
+			return err
+		}
+		change.Data, err = encodeElements([]*dicom.Element{elem})
+		return err
+	case change.Kind == ChangeRemove && reverse:
+		elems, err := decodeElements(change.Data)
+		if err != nil {
+			return err
+		}
+		if len(elems) != 1 {
+			return fmt.Errorf("recorded element is corrupt")
+		}
+		return addElementAt(ds, change.Path, elems[0])
+
+	case change.Kind == ChangeAddItem && !reverse:
+		return addItemAt(ds, change.Path, change.Item, []*dicom.Element{})
+	case change.Kind == ChangeRemoveItem && !reverse,
+		change.Kind == ChangeAddItem && reverse:
+		elems, err := removeItemAt(ds, change.Path, change.Item)
+		if err != nil || change.Kind == ChangeAddItem {
+			return err
+		}
+		change.Data, err = encodeElements(elems)
+		return err
+	case change.Kind == ChangeRemoveItem && reverse:
+		elems, err := decodeElements(change.Data)
+		if err != nil {
+			return err
+		}
+		return addItemAt(ds, change.Path, change.Item, elems)
+
+	case change.Kind == ChangeTranscode && !reverse:
+		source, err := transcodeDataset(ds, change.New)
+		change.Old = source
+		return err
+	case change.Kind == ChangeTranscode && reverse:
+		_, err := transcodeDataset(ds, change.Old)
+		return err
+
+	case change.Kind == ChangeFileMeta && !reverse:
+		old, err := regenerateFileMeta(ds)
+		if err != nil {
+			return err
+		}
+		change.Data, err = encodeFileMeta(old)
+		return err
+	case change.Kind == ChangeFileMeta && reverse:
+		meta, err := decodeFileMeta(change.Data)
+		if err != nil {
+			return err
+		}
+		return replaceFileMeta(ds, meta)
+
+	case change.Kind == ChangeBlank:
+		regions, err := ParsePixelRegions(change.New)
+		if err != nil {
+			return err
+		}
+		if reverse {
+			return restorePixelData(ds, regions, change.Data)
+		}
+		change.Data, err = blankPixelData(ds, regions)
+		return err
+	}
+	return fmt.Errorf("unknown change %q", change.Kind)
+}
+
+// redactionApplied reports whether the redacting change already holds in ds, i.e. the element or
+// item is removed or added as it would be by the change. This is the case for the files written before an
+// irreversible operation was interrupted, which cannot be rolled back and have to be replayed.
+func redactionApplied(ds *dicom.Dataset, change *ElementChange) bool {
+	elem, err := change.Path.Find(ds.Elements)
+	switch change.Kind {
+	case ChangeRemove:
+		return errors.Is(err, ErrorElementNotFound)
+	case ChangeRemoveItem:
+		return errors.Is(err, ErrorElementNotFound) || (err == nil && len(itemsOf(elem)) <= change.Item)
+	case ChangeAdd:
+		return err == nil && (change.VR == "SQ" || IsEditable(elem) && ElementText(elem) == change.New)
+	case ChangeAddItem:
+		return err == nil && len(itemsOf(elem)) > change.Item
+	}
+	return false
+}
@@ -8,6 +8,7 @@ package operations
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"sync"
 
@@ -24,6 +25,9 @@ type ParsedDicomFile struct {
 	Path string
 	// Dataset contains the parsed DICOM dataset with all elements and metadata.
 	Dataset dicom.Dataset
+	// MetaIssues lists the inconsistencies of the File Meta Information as returned by
+	// CheckFileMeta. It is nil if the File Meta Information is consistent.
+	MetaIssues error
 	// handle is the open file handle for the DICOM file.
 	// The caller must close this handle after processing.
 	handle *os.File
@@ -57,6 +61,21 @@ func (p *ParsedDicomFile) Close() error {
 	return p.handle.Close()
 }
 
+// Reload parses the file at Path again, without its pixel data, and replaces Dataset and
+// MetaIssues.
+//
+// The open file handle is closed, since it refers to the previous version of a replaced file.
+func (p *ParsedDicomFile) Reload() error {
+	dataset, err := parseFile(p.Path, dicom.SkipPixelData())
+	if err != nil {
+		return err
+	}
+	p.Close()
+	p.Dataset = dataset
+	p.MetaIssues = CheckFileMeta(p.Path, dataset)
+	return nil
+}
+
 // ParsingResult contains the channels for parsed DICOM files and errors.
 //
 // This struct provides access to the output channels from the parsing process,
@@ -124,7 +143,7 @@ func ParseDICOMFiles(dicomChannel <-chan DicomFile, maxConcurrency int) ParsingR
 func dicomParserWorker(fileCh <-chan DicomFile, resultCh chan<- *ParsedDicomFile, errCh chan<- error) {
 	for file := range fileCh {
 		// Use a panic recovery wrapper to handle any panics from ParseUntilEOF
-		dataset, err := saveParseUntilEOF(file.Handle)
+		dataset, err := saveParseUntilEOF(file.Handle, dicom.SkipPixelData())
 
 		if err != nil {
 			errCh <- err
@@ -132,10 +151,11 @@ func dicomParserWorker(fileCh <-chan DicomFile, resultCh chan<- *ParsedDicomFile
 			continue
 		}
 		resultCh <- &ParsedDicomFile{
-			Path:    file.Path,
-			Dataset: dataset,
-			handle:  file.Handle,
-			isOpen:  true,
+			Path:       file.Path,
+			Dataset:    dataset,
+			MetaIssues: CheckFileMeta(file.Path, dataset),
+			handle:     file.Handle,
+			isOpen:     true,
 		}
 	}
 }
@@ -144,13 +164,15 @@ func dicomParserWorker(fileCh <-chan DicomFile, resultCh chan<- *ParsedDicomFile
 //
 // This function wraps the dicom.ParseUntilEOF call with panic recovery to handle
 // any unexpected panics from the DICOM parsing library. Panics are converted to
-// regular errors that can be handled by the calling code.
+// regular errors that can be handled by the calling code. Data sets encoded with
+// the Deflated Explicit VR Little Endian transfer syntax are inflated on the fly.
 //
-// file is the open file handle to parse. The file should be positioned at the beginning.
+// file is the content to parse, e.g. an open file handle positioned at the beginning.
+// opts are passed through to the DICOM library and control e.g. whether pixel data is read.
 //
 // Returns the parsed DICOM dataset and any error encountered during parsing.
 // If a panic occurs, it is converted to an error with a descriptive message.
-func saveParseUntilEOF(file *os.File) (dataset dicom.Dataset, err error) {
+func saveParseUntilEOF(file io.Reader, opts ...dicom.ParseOption) (dataset dicom.Dataset, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			// Convert panic to error
@@ -162,9 +184,28 @@ func saveParseUntilEOF(file *os.File) (dataset dicom.Dataset, err error) {
 		}
 	}()
 
-	dataset, err = dicom.ParseUntilEOF(file, nil, dicom.ParseOption(dicom.SkipPixelData()))
+	// Deflated data sets are inflated transparently before they reach the DICOM library.
+	reader, err := inflatingReader(file)
+	if err != nil {
+		return dicom.Dataset{}, err
+	}
+
+	dataset, err = dicom.ParseUntilEOF(reader, nil, opts...)
 	if err != nil {
 		return dicom.Dataset{}, err
 	}
 	return dataset, nil
 }
+
+// parseFile opens the DICOM file at path and parses it using saveParseUntilEOF.
+//
+// The file handle is closed before returning. opts are passed through to the DICOM library.
+func parseFile(path string, opts ...dicom.ParseOption) (dicom.Dataset, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return dicom.Dataset{}, err
+	}
+	defer file.Close()
+
+	return saveParseUntilEOF(file, opts...)
+}
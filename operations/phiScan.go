@@ -0,0 +1,498 @@
+// phiScan.go implements the scan for residual protected health information (PHI), e.g. patient
+// names left in a Study Description after de-identification.
+//
+// Every text valued element, including elements nested in sequences, and every private element
+// is searched for the words of the patient's names, the patient's IDs and accession numbers, the
+// birth date in common notations and a set of configurable regular expressions, e.g. for phone
+// numbers. Binary private values are searched for the printable text they contain.
+//
+// The identity of the patient is taken from the Patient Name, Patient ID and related attributes of
+// each file. These attributes are not searched for the identity themselves, since they are where
+// it belongs. If the Patient ID is a pseudonym of the pseudonym store, the original name and ID of
+// the patient are searched for as well and are reported wherever they occur, so identities that
+// survived a pseudonymisation are found. An encrypted store cannot be read without its passphrase,
+// in which case the scan goes on without the original identities and the report carries a warning.
+//
+// Additional patterns are read from the file named by the TYRO_PHI_PATTERNS environment variable,
+// ~/.tyro/phi-patterns by default. Each line holds a name followed by a regular expression, e.g.
+// "mrn \bM[0-9]{7}\b". Empty lines and lines starting with # are ignored. Patterns with the name of
+// a built-in pattern replace it.
+package operations
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// PHIPatternsEnv is the environment variable naming the file additional PHI patterns are read from.
+const PHIPatternsEnv = "TYRO_PHI_PATTERNS"
+
+// minPrintableRun is the minimum length of the printable runs of binary private values that are
+// searched.
+const minPrintableRun = 4
+
+// ErrorInvalidPHIPattern is returned when a PHI pattern cannot be parsed.
+var ErrorInvalidPHIPattern = errors.New("invalid PHI pattern")
+
+// Kinds of PHI found by a PHIScanner besides the names of its patterns.
+const (
+	PHIPatientName = "patient name"
+	PHIPatientID   = "patient ID"
+	PHIBirthDate   = "birth date"
+)
+
+// PHIPattern is a named regular expression matching PHI.
+type PHIPattern struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// defaultPHIPatterns are the built-in patterns of a PHIScanner.
+var defaultPHIPatterns = []PHIPattern{
+	{"phone", regexp.MustCompile(`(?:\+\d{1,3}[ /-]?|\b0)\d{2,5}[ /-]?\d{3,}(?:[ -]?\d+)*\b|\(\d{3}\) ?\d{3}-\d{4}\b|\b\d{3}-\d{3}-\d{4}\b`)},
+	{"email", regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)},
+	{"mrn", regexp.MustCompile(`(?i)\b(?:MRN|medical record(?: number)?)\b[ #:.]*[A-Z0-9-]+`)},
+	{"date of birth", regexp.MustCompile(`(?i)\b(?:DOB|date of birth|born)\b[ :.]*\S+`)},
+}
+
+// identityAttributes hold the identity of the patient. At the top level of a file they are only
+// searched for the original identity of a pseudonym and the patterns.
+var identityAttributes = map[tag.Tag]bool{
+	tag.PatientName:            true,
+	tag.OtherPatientNames:      true,
+	tag.PatientBirthName:       true,
+	tag.PatientMotherBirthName: true,
+	tag.PatientID:              true,
+	tag.OtherPatientIDs:        true,
+	tag.AccessionNumber:        true,
+	tag.PatientBirthDate:       true,
+}
+
+// textVRs are the VRs of the searched elements besides private elements.
+var textVRs = map[string]bool{
+	"SH": true, "LO": true, "ST": true, "LT": true, "UT": true, "UC": true, "PN": true,
+	"DA": true, "DT": true,
+}
+
+// PHIHit is a piece of PHI found in an element.
+type PHIHit struct {
+	// File is the path of the DICOM file containing the element.
+	File string
+	// Path addresses the element within the file's dataset.
+	Path TagPath
+	// Kind is what has been found, e.g. PHIPatientName or the name of a pattern.
+	Kind string
+	// Match is the text that has been found.
+	Match string
+	// Value is the value of the element or, for binary private values, its printable text.
+	Value string
+}
+
+// PHIReport is the result of scanning files for PHI.
+type PHIReport struct {
+	// Files is the number of scanned files.
+	Files int
+	// Hits are the hits of all files in the order of the files and their datasets.
+	Hits []PHIHit
+	// Errors holds an error for every file that could not be read.
+	Errors *tyroErrors.MultiError
+	// Warning explains why the scan searched less than it should have, e.g. without the original
+	// identities of a locked pseudonym store. It is empty if the scan was complete.
+	Warning string
+}
+
+// PHIScanner searches datasets for PHI.
+type PHIScanner struct {
+	patterns []PHIPattern
+	// pseudonyms looks up the original identity of pseudonymised files. It may be nil.
+	pseudonyms *PseudonymStore
+	// warning is the Warning of the reports of the scanner.
+	warning string
+}
+
+// NewPHIScanner creates a scanner searching for the built-in patterns and patterns. Patterns
+// replace built-in patterns of the same name. If pseudonyms is not nil, the original identity of
+// files whose Patient ID is one of its pseudonyms is searched as well.
+func NewPHIScanner(patterns []PHIPattern, pseudonyms *PseudonymStore) *PHIScanner {
+	s := &PHIScanner{pseudonyms: pseudonyms}
+	for _, pattern := range defaultPHIPatterns {
+		if !slices.ContainsFunc(patterns, func(p PHIPattern) bool { return p.Name == pattern.Name }) {
+			s.patterns = append(s.patterns, pattern)
+		}
+	}
+	s.patterns = append(s.patterns, patterns...)
+	return s
+}
+
+// NewDefaultPHIScanner creates a scanner searching for the patterns of LoadPHIPatterns followed by
+// patterns, using the default pseudonym store if it exists. If the store is encrypted and
+// PseudonymPassphraseEnv is not set, the scanner does without it and its reports carry a warning.
+func NewDefaultPHIScanner(patterns ...PHIPattern) (*PHIScanner, error) {
+	loaded, err := LoadPHIPatterns()
+	if err != nil {
+		return nil, err
+	}
+	path, err := PseudonymStorePath()
+	if err != nil {
+		return nil, err
+	}
+	var store *PseudonymStore
+	warning := ""
+	if _, err := os.Stat(path); err == nil {
+		store, err = OpenDefaultPseudonymStore()
+		switch {
+		case errors.Is(err, ErrorPseudonymPassphrase) && os.Getenv(PseudonymPassphraseEnv) == "":
+			warning = fmt.Sprintf("%s is encrypted and %s is not set, original identities of pseudonymised files are not searched", path, PseudonymPassphraseEnv)
+		case err != nil:
+			return nil, err
+		}
+	}
+	scanner := NewPHIScanner(append(loaded, patterns...), store)
+	scanner.warning = warning
+	return scanner, nil
+}
+
+// ParsePHIPattern parses a pattern written as "<name> <regular expression>".
+func ParsePHIPattern(spec string) (PHIPattern, error) {
+	name, expression := strings.TrimSpace(spec), ""
+	if i := strings.IndexFunc(name, unicode.IsSpace); i >= 0 {
+		name, expression = name[:i], strings.TrimSpace(name[i:])
+	}
+	if name == "" || expression == "" {
+		return PHIPattern{}, fmt.Errorf("%w: %q, expected <name> <regular expression>", ErrorInvalidPHIPattern, spec)
+	}
+	pattern, err := regexp.Compile(expression)
+	if err != nil {
+		return PHIPattern{}, fmt.Errorf("%w: %s: %v", ErrorInvalidPHIPattern, name, err)
+	}
+	return PHIPattern{Name: name, Pattern: pattern}, nil
+}
+
+// PHIPatternsPath returns the location of the file holding additional PHI patterns.
+func PHIPatternsPath() (string, error) {
+	if path := os.Getenv(PHIPatternsEnv); path != "" {
+		return filepath.Abs(path)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine PHI pattern location, set %s: %w", PHIPatternsEnv, err)
+	}
+	return filepath.Join(home, ".tyro", "phi-patterns"), nil
+}
+
+// LoadPHIPatterns reads the patterns of the file at PHIPatternsPath. A missing file holds no
+// patterns.
+func LoadPHIPatterns() ([]PHIPattern, error) {
+	path, err := PHIPatternsPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []PHIPattern
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		pattern, err := ParsePHIPattern(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, scanner.Err()
+}
+
+// Patterns returns the names of the patterns searched for.
+func (s *PHIScanner) Patterns() []string {
+	names := make([]string, len(s.patterns))
+	for i, pattern := range s.patterns {
+		names[i] = pattern.Name
+	}
+	return names
+}
+
+// ScanFiles reads the files at paths concurrently, reports the progress to progress and returns
+// the hits of all files.
+func (s *PHIScanner) ScanFiles(paths []string, progress Progress) PHIReport {
+	fileHits := make([][]PHIHit, len(paths))
+	fileErrs := make([]error, len(paths))
+	forEachFile(len(paths), progress, func(i int) {
+		ds, err := parseFile(paths[i], dicom.SkipPixelData())
+		if err == nil {
+			fileHits[i] = s.Scan(paths[i], ds)
+		}
+		fileErrs[i] = err
+	})
+
+	report := PHIReport{Files: len(paths), Errors: tyroErrors.New(), Warning: s.warning}
+	for i, path := range paths {
+		if fileErrs[i] != nil {
+			report.Errors.Add(fmt.Errorf("%s: %w", path, fileErrs[i]))
+		}
+		report.Hits = append(report.Hits, fileHits[i]...)
+	}
+	return report
+}
+
+// Scan returns the hits within ds, the dataset of the file at path, in dataset order.
+func (s *PHIScanner) Scan(path string, ds dicom.Dataset) []PHIHit {
+	scan := &phiScan{PHIScanner: s, path: path, own: identityMatchers(ds.Elements)}
+	if s.pseudonyms != nil {
+		if original, ok := s.pseudonyms.Original(elementString(ds.Elements, tag.PatientID)); ok {
+			scan.original = originalMatchers(original)
+		}
+	}
+	scan.elements(ds.Elements, nil)
+	return scan.hits
+}
+
+// identityMatcher matches one kind of identifier of the patient.
+type identityMatcher struct {
+	kind    string
+	pattern *regexp.Regexp
+	// joins reports whether a character next to a match continues it, e.g. a letter following a
+	// name. Matches continued by such characters are not identifiers.
+	joins func(r rune) bool
+}
+
+// matches returns the distinct matches of the identifiers in value.
+func (m identityMatcher) matches(value string) []string {
+	var matches []string
+	for _, loc := range m.pattern.FindAllStringIndex(value, -1) {
+		before, _ := utf8.DecodeLastRuneInString(value[:loc[0]])
+		after, _ := utf8.DecodeRuneInString(value[loc[1]:])
+		if (loc[0] > 0 && m.joins(before)) || (loc[1] < len(value) && m.joins(after)) {
+			continue
+		}
+		if match := value[loc[0]:loc[1]]; !slices.Contains(matches, match) {
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// isWordRune reports whether r is part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// phiScan collects the hits of a single file.
+type phiScan struct {
+	*PHIScanner
+	path string
+	// own matches the identity held by the file, original the identity a pseudonym stands for.
+	own      []identityMatcher
+	original []identityMatcher
+	hits     []PHIHit
+}
+
+// elements scans elems, whose paths are returned by child. A nil child addresses the top level
+// elements of the dataset.
+func (s *phiScan) elements(elems []*dicom.Element, child func(t tag.Tag) TagPath) {
+	topLevel := child == nil
+	if topLevel {
+		child = NewTagPath
+	}
+	for _, elem := range elems {
+		path := child(elem.Tag)
+		if elem.Value != nil && elem.Value.ValueType() == dicom.Sequences {
+			for i, item := range itemsOf(elem) {
+				s.elements(item, func(t tag.Tag) TagPath { return path.Child(i, t) })
+			}
+			continue
+		}
+
+		value, ok := searchableText(elem)
+		if !ok || value == "" {
+			continue
+		}
+		// Dates can only hold the birth date, and their digits would match the patterns.
+		date := elem.RawValueRepresentation == "DA" || elem.RawValueRepresentation == "DT"
+		if !topLevel || !identityAttributes[elem.Tag] {
+			s.match(path, value, s.own, date)
+		}
+		s.match(path, value, s.original, date)
+		if date {
+			continue
+		}
+		for _, pattern := range s.patterns {
+			for _, match := range uniqueMatches(pattern.Pattern, value) {
+				s.hits = append(s.hits, PHIHit{File: s.path, Path: path, Kind: pattern.Name, Match: match, Value: value})
+			}
+		}
+	}
+}
+
+// match adds a hit for every identifier of matchers found in value. If datesOnly is set, value
+// is only searched for the birth date.
+func (s *phiScan) match(path TagPath, value string, matchers []identityMatcher, datesOnly bool) {
+	for _, matcher := range matchers {
+		if datesOnly && matcher.kind != PHIBirthDate {
+			continue
+		}
+		for _, match := range matcher.matches(value) {
+			s.hits = append(s.hits, PHIHit{File: s.path, Path: path, Kind: matcher.kind, Match: match, Value: value})
+		}
+	}
+}
+
+// uniqueMatches returns the distinct matches of pattern in value.
+func uniqueMatches(pattern *regexp.Regexp, value string) []string {
+	var matches []string
+	for _, match := range pattern.FindAllString(value, -1) {
+		if !slices.Contains(matches, match) {
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// searchableText returns the text of elem that is searched and whether elem is searched at all.
+// Private elements are searched whatever their VR; binary values contribute their printable runs.
+func searchableText(elem *dicom.Element) (string, bool) {
+	private := tag.IsPrivate(elem.Tag.Group)
+	if !private && !textVRs[elem.RawValueRepresentation] {
+		return "", false
+	}
+	if IsEditable(elem) {
+		return ElementText(elem), true
+	}
+	if !private || elem.Value == nil || elem.Value.ValueType() != dicom.Bytes {
+		return "", false
+	}
+	data, _ := elem.Value.GetValue().([]byte)
+	return strings.Join(printableRuns(data), " "), true
+}
+
+// printableRuns returns the runs of at least minPrintableRun printable ASCII characters in data.
+func printableRuns(data []byte) []string {
+	var runs []string
+	start := -1
+	for i := 0; i <= len(data); i++ {
+		if i < len(data) && data[i] >= 0x20 && data[i] < 0x7f {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= minPrintableRun {
+			runs = append(runs, strings.TrimSpace(string(data[start:i])))
+		}
+		start = -1
+	}
+	return runs
+}
+
+// identityMatchers returns the matchers of the identity held by the top level elements elems.
+func identityMatchers(elems []*dicom.Element) []identityMatcher {
+	var names, ids []string
+	for _, t := range []tag.Tag{tag.PatientName, tag.OtherPatientNames, tag.PatientBirthName, tag.PatientMotherBirthName} {
+		for _, value := range elementStrings(elems, t) {
+			names = append(names, nameWords(value)...)
+		}
+	}
+	for _, t := range []tag.Tag{tag.PatientID, tag.OtherPatientIDs, tag.AccessionNumber} {
+		for _, value := range elementStrings(elems, t) {
+			if value = strings.TrimSpace(value); value != "" {
+				ids = append(ids, value)
+			}
+		}
+	}
+	return buildMatchers(names, ids, elementString(elems, tag.PatientBirthDate))
+}
+
+// originalMatchers returns the matchers of the original identity of a pseudonymised patient.
+func originalMatchers(original PatientPseudonym) []identityMatcher {
+	ids := []string{}
+	if original.ID != "" {
+		ids = append(ids, original.ID)
+	}
+	for accession := range original.Accessions {
+		ids = append(ids, accession)
+	}
+	slices.Sort(ids)
+	return buildMatchers(nameWords(original.Name), ids, "")
+}
+
+// nameWords returns the words of a PN value. Single letters, e.g. initials, are left out, since
+// they would match arbitrary text.
+func nameWords(name string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return r == '^' || r == '=' || unicode.IsSpace(r) }) {
+		if len([]rune(word)) > 1 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// buildMatchers returns the matchers of the given name words, IDs and birth date. Empty kinds get
+// no matcher.
+func buildMatchers(names []string, ids []string, birthDate string) []identityMatcher {
+	var matchers []identityMatcher
+	if pattern := wordPattern(names); pattern != nil {
+		matchers = append(matchers, identityMatcher{PHIPatientName, pattern, isWordRune})
+	}
+	if pattern := wordPattern(ids); pattern != nil {
+		matchers = append(matchers, identityMatcher{PHIPatientID, pattern, isWordRune})
+	}
+	if pattern := birthDatePattern(birthDate); pattern != nil {
+		matchers = append(matchers, identityMatcher{PHIBirthDate, pattern, unicode.IsDigit})
+	}
+	return matchers
+}
+
+// wordPattern returns an expression matching any of words, ignoring case, or nil if there are no
+// words. Longer words are tried first, so a word is not cut short by another word it starts with.
+func wordPattern(words []string) *regexp.Regexp {
+	if len(words) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+	slices.SortStableFunc(quoted, func(a, b string) int { return len(b) - len(a) })
+	return regexp.MustCompile(`(?i)` + strings.Join(slices.Compact(quoted), "|"))
+}
+
+// birthDateLayouts are the notations a birth date is searched in.
+var birthDateLayouts = []string{"20060102", "2006-01-02", "2006.01.02", "02.01.2006", "01/02/2006", "02/01/2006"}
+
+// birthDatePattern returns an expression matching the DA value birthDate in the notations of
+// birthDateLayouts, or nil if birthDate is not a valid date.
+func birthDatePattern(birthDate string) *regexp.Regexp {
+	date, err := time.Parse("20060102", strings.TrimSpace(birthDate))
+	if err != nil {
+		return nil
+	}
+	var notations []string
+	for _, layout := range birthDateLayouts {
+		if notation := regexp.QuoteMeta(date.Format(layout)); !slices.Contains(notations, notation) {
+			notations = append(notations, notation)
+		}
+	}
+	return regexp.MustCompile(strings.Join(notations, "|"))
+}
@@ -0,0 +1,561 @@
+// pixelData.go provides utilities for loading and rendering the pixel data of DICOM files.
+//
+// The discovery parser intentionally skips pixel data to keep memory usage low. The functions in
+// this file re-read a single file including its pixel data on demand, decode native frames into
+// plain sample slices and render them to images using the Modality LUT (Rescale Slope/Intercept)
+// and VOI LUT (Window Center/Width) attributes of the dataset.
+package operations
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+	"github.com/suyashkumar/dicom/pkg/uid"
+)
+
+var (
+	// ErrorNoPixelData is returned when a dataset does not contain a pixel data element.
+	ErrorNoPixelData = errors.New("dataset does not contain pixel data")
+	// ErrorUnsupportedPixelData is returned when the pixel data is encoded in a way that cannot be decoded.
+	ErrorUnsupportedPixelData = errors.New("unsupported pixel data encoding")
+	// ErrorFrameOutOfRange is returned when a frame index outside of the available frames is requested.
+	ErrorFrameOutOfRange = errors.New("frame index out of range")
+)
+
+// ImageInfo describes the geometry and presentation attributes of the pixel data of a dataset.
+type ImageInfo struct {
+	// Rows is the number of rows of each frame.
+	Rows int
+	// Columns is the number of columns of each frame.
+	Columns int
+	// NumberOfFrames is the number of frames stored in the pixel data.
+	NumberOfFrames int
+	// SamplesPerPixel is 1 for grayscale and 3 for color images.
+	SamplesPerPixel int
+	// BitsAllocated is the number of bits used to store a single sample.
+	BitsAllocated int
+	// BitsStored is the number of bits of each sample that hold actual data.
+	BitsStored int
+	// HighBit is the most significant bit of the stored sample.
+	HighBit int
+	// PixelRepresentation is 0 for unsigned and 1 for two's complement samples.
+	PixelRepresentation int
+	// PlanarConfiguration is 0 for color-by-pixel and 1 for color-by-plane.
+	PlanarConfiguration int
+	// PhotometricInterpretation describes how the samples are to be interpreted (e.g. MONOCHROME2, RGB).
+	PhotometricInterpretation string
+	// RescaleSlope is the slope of the Modality LUT.
+	RescaleSlope float64
+	// RescaleIntercept is the intercept of the Modality LUT.
+	RescaleIntercept float64
+	// Window is the first VOI window stored in the dataset, if HasWindow is true.
+	Window Window
+	// HasWindow reports whether the dataset defines a valid VOI window.
+	HasWindow bool
+}
+
+// Window is a linear VOI LUT defined by its center and width as described in PS3.3 C.11.2.1.2.
+type Window struct {
+	// Center is the Window Center in modality units.
+	Center float64 `json:"center"`
+	// Width is the Window Width in modality units. It must be at least 1.
+	Width float64 `json:"width"`
+}
+
+// PixelData holds the decoded frames of a DICOM file together with their geometry.
+type PixelData struct {
+	// Path is the filesystem location of the DICOM file.
+	Path string
+	// Info describes the geometry and presentation attributes of the frames.
+	Info ImageInfo
+	// Frames contains one slice of stored sample values per frame. Samples are interleaved
+	// (color-by-pixel) regardless of the planar configuration of the file and already sign extended.
+	// YBR_FULL_422 pixel data is expanded to three samples per pixel and described as YBR_FULL by
+	// Info.
+	Frames [][]int
+}
+
+// IsMonochrome reports whether the image is a grayscale image that the VOI LUT applies to.
+func (i ImageInfo) IsMonochrome() bool {
+	return i.PhotometricInterpretation == "MONOCHROME1" || i.PhotometricInterpretation == "MONOCHROME2"
+}
+
+// FrameSize returns the number of samples contained in a single decoded frame.
+func (i ImageInfo) FrameSize() int {
+	return i.Rows * i.Columns * i.SamplesPerPixel
+}
+
+// isSubsampled reports whether info describes YBR_FULL_422 pixel data, which stores every two
+// horizontally adjacent pixels of a row in four samples, Y1 Y2 Cb Cr.
+func isSubsampled(info ImageInfo) bool {
+	return info.PhotometricInterpretation == "YBR_FULL_422"
+}
+
+// frameSamples returns the number of samples stored for a single native frame described by info.
+func frameSamples(info ImageInfo) int {
+	if isSubsampled(info) {
+		return info.Rows * info.Columns * 2
+	}
+	return info.FrameSize()
+}
+
+// ModalityValue applies the Modality LUT (Rescale Slope/Intercept) to a stored sample value.
+func (i ImageInfo) ModalityValue(stored int) float64 {
+	return float64(stored)*i.RescaleSlope + i.RescaleIntercept
+}
+
+// ReadImageInfo extracts the pixel geometry and presentation attributes from a dataset.
+//
+// Missing optional attributes are replaced by their defaults as defined by the standard.
+// Rows, Columns and Bits Allocated are required and an error is returned if any of them is missing.
+// Bits Stored and High Bit must fit into Bits Allocated, otherwise ErrorUnsupportedPixelData is
+// returned.
+func ReadImageInfo(ds dicom.Dataset) (ImageInfo, error) {
+	info := ImageInfo{
+		NumberOfFrames:            1,
+		SamplesPerPixel:           1,
+		PhotometricInterpretation: "MONOCHROME2",
+		RescaleSlope:              1,
+	}
+
+	var err error
+	if info.Rows, err = intAttribute(ds, tag.Rows); err != nil {
+		return info, err
+	}
+	if info.Columns, err = intAttribute(ds, tag.Columns); err != nil {
+		return info, err
+	}
+	if info.BitsAllocated, err = intAttribute(ds, tag.BitsAllocated); err != nil {
+		return info, err
+	}
+
+	info.BitsStored = info.BitsAllocated
+	info.HighBit = info.BitsAllocated - 1
+
+	if v, err := intAttribute(ds, tag.SamplesPerPixel); err == nil && v > 0 {
+		info.SamplesPerPixel = v
+	}
+	if v, err := intAttribute(ds, tag.BitsStored); err == nil && v > 0 {
+		info.BitsStored = v
+		info.HighBit = v - 1
+	}
+	if v, err := intAttribute(ds, tag.HighBit); err == nil {
+		info.HighBit = v
+	}
+	if v, err := intAttribute(ds, tag.PixelRepresentation); err == nil {
+		info.PixelRepresentation = v
+	}
+	if v, err := intAttribute(ds, tag.PlanarConfiguration); err == nil {
+		info.PlanarConfiguration = v
+	}
+	if v, err := intAttribute(ds, tag.NumberOfFrames); err == nil && v > 0 {
+		info.NumberOfFrames = v
+	}
+	if v, err := stringAttribute(ds, tag.PhotometricInterpretation); err == nil && v != "" {
+		info.PhotometricInterpretation = strings.ToUpper(v)
+	}
+	if v, err := floatAttribute(ds, tag.RescaleSlope); err == nil && v != 0 {
+		info.RescaleSlope = v
+	}
+	if v, err := floatAttribute(ds, tag.RescaleIntercept); err == nil {
+		info.RescaleIntercept = v
+	}
+
+	center, centerErr := floatAttribute(ds, tag.WindowCenter)
+	width, widthErr := floatAttribute(ds, tag.WindowWidth)
+	if centerErr == nil && widthErr == nil && width >= 1 {
+		info.Window = Window{Center: center, Width: width}
+		info.HasWindow = true
+	}
+
+	// The samples are shifted by High Bit + 1 - Bits Stored, so inconsistent values must not reach
+	// the decoder.
+	if info.BitsStored > info.BitsAllocated || info.HighBit < info.BitsStored-1 || info.HighBit >= info.BitsAllocated {
+		return info, fmt.Errorf("%w: %d bits stored with high bit %d in %d bits allocated", ErrorUnsupportedPixelData, info.BitsStored, info.HighBit, info.BitsAllocated)
+	}
+	return info, nil
+}
+
+// LoadPixelData reads the DICOM file at path including its pixel data and decodes all frames.
+//
+// Native (uncompressed) and RLE Lossless pixel data can be decoded. Any other encapsulated
+// pixel data results in ErrorUnsupportedPixelData.
+func LoadPixelData(path string) (*PixelData, error) {
+	ds, err := parseFile(path, dicom.SkipProcessingPixelDataValue())
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodePixelData(path, ds)
+}
+
+// DecodePixelData decodes the pixel data element of an already parsed dataset.
+//
+// The dataset must have been parsed with dicom.SkipProcessingPixelDataValue so that the raw
+// value bytes of the pixel data element are available.
+func DecodePixelData(path string, ds dicom.Dataset) (*PixelData, error) {
+	info, err := ReadImageInfo(ds)
+	if err != nil {
+		return nil, err
+	}
+
+	elem, err := ds.FindElementByTag(tag.PixelData)
+	if err != nil {
+		return nil, ErrorNoPixelData
+	}
+	pixelInfo := dicom.MustGetPixelDataInfo(elem.Value)
+	if pixelInfo.IsEncapsulated {
+		if transferSyntaxOf(ds) != RLELosslessTransferSyntax {
+			return nil, fmt.Errorf("%w: encapsulated transfer syntax %s", ErrorUnsupportedPixelData, transferSyntaxOf(ds))
+		}
+		frames, err := decodeRLEFrames(pixelInfo, info)
+		if err != nil {
+			return nil, err
+		}
+		return &PixelData{Path: path, Info: info, Frames: frames}, nil
+	}
+	if !pixelInfo.IntentionallyUnprocessed {
+		return nil, fmt.Errorf("%w: pixel data was not read", ErrorUnsupportedPixelData)
+	}
+
+	frames, err := decodeNativeFrames(pixelInfo.UnprocessedValueData, info, byteOrderOf(ds))
+	if err != nil {
+		return nil, err
+	}
+	if isSubsampled(info) {
+		// The frames hold a sample of every component for every pixel now.
+		info.PhotometricInterpretation = "YBR_FULL"
+	}
+
+	return &PixelData{Path: path, Info: info, Frames: frames}, nil
+}
+
+// DefaultWindow returns the window stored in the dataset or, if there is none, a window
+// spanning the full range of modality values of the given frame.
+func (p *PixelData) DefaultWindow(frameIndex int) Window {
+	if p.Info.HasWindow {
+		return p.Info.Window
+	}
+	if frameIndex < 0 || frameIndex >= len(p.Frames) || len(p.Frames[frameIndex]) == 0 {
+		return Window{Center: 127.5, Width: 256}
+	}
+
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, sample := range p.Frames[frameIndex] {
+		v := p.Info.ModalityValue(sample)
+		minValue = math.Min(minValue, v)
+		maxValue = math.Max(maxValue, v)
+	}
+
+	return Window{Center: (minValue + maxValue) / 2, Width: math.Max(maxValue-minValue+1, 1)}
+}
+
+// RenderFrame renders the frame at frameIndex to an 8-bit image.
+//
+// Monochrome images are converted using the Modality LUT followed by the given window and are
+// returned as *image.Gray. MONOCHROME1 images are inverted. Color images ignore the window and are
+// returned as *image.RGBA.
+func (p *PixelData) RenderFrame(frameIndex int, window Window) (image.Image, error) {
+	if frameIndex < 0 || frameIndex >= len(p.Frames) {
+		return nil, ErrorFrameOutOfRange
+	}
+	samples := p.Frames[frameIndex]
+	rect := image.Rect(0, 0, p.Info.Columns, p.Info.Rows)
+	pixels := p.Info.Rows * p.Info.Columns
+
+	if p.Info.SamplesPerPixel == 1 {
+		img := image.NewGray(rect)
+		invert := p.Info.PhotometricInterpretation == "MONOCHROME1"
+		for i := 0; i < pixels; i++ {
+			v := ApplyWindow(p.Info.ModalityValue(samples[i]), window)
+			if invert {
+				v = 255 - v
+			}
+			img.Pix[i] = v
+		}
+		return img, nil
+	}
+
+	img := image.NewRGBA(rect)
+	shift := max(p.Info.BitsStored-8, 0)
+	for i := 0; i < pixels; i++ {
+		c := p.colorAt(samples[i*p.Info.SamplesPerPixel:], shift)
+		img.Pix[i*4] = c.R
+		img.Pix[i*4+1] = c.G
+		img.Pix[i*4+2] = c.B
+		img.Pix[i*4+3] = 255
+	}
+	return img, nil
+}
+
+// colorAt converts the samples of a single color pixel to an RGBA color.
+//
+// shift is the number of bits the samples are shifted to the right to fit into 8 bits.
+func (p *PixelData) colorAt(samples []int, shift int) color.RGBA {
+	a, b, c := uint8(samples[0]>>shift), uint8(samples[1]>>shift), uint8(samples[2]>>shift)
+	if strings.HasPrefix(p.Info.PhotometricInterpretation, "YBR") {
+		r, g, bl := color.YCbCrToRGB(a, b, c)
+		return color.RGBA{R: r, G: g, B: bl, A: 255}
+	}
+	return color.RGBA{R: a, G: b, B: c, A: 255}
+}
+
+// ApplyWindow maps a modality value to an 8-bit output value using the linear VOI LUT
+// function defined in PS3.3 C.11.2.1.2.1.
+func ApplyWindow(value float64, window Window) uint8 {
+	return uint8(windowToRange(value, window, 255))
+}
+
+// decodeNativeFrames splits raw native pixel data into frames of sample values.
+//
+// Samples are masked to Bits Stored, sign extended for signed data and converted to
+// color-by-pixel order for planar configuration 1. YBR_FULL_422 pixel pairs are expanded to three
+// samples per pixel.
+func decodeNativeFrames(data []byte, info ImageInfo, bo binary.ByteOrder) ([][]int, error) {
+	frameSize := frameSamples(info)
+	if frameSize == 0 {
+		return nil, fmt.Errorf("%w: empty image geometry", ErrorUnsupportedPixelData)
+	}
+	subsampled := isSubsampled(info)
+	if subsampled && (info.Columns%2 != 0 || info.PlanarConfiguration != 0 || info.SamplesPerPixel != 3) {
+		return nil, fmt.Errorf("%w: YBR_FULL_422 with %d columns, %d samples per pixel and planar configuration %d", ErrorUnsupportedPixelData, info.Columns, info.SamplesPerPixel, info.PlanarConfiguration)
+	}
+
+	var samples []int
+	switch info.BitsAllocated {
+	case 1:
+		samples = make([]int, len(data)*8)
+		for i, b := range data {
+			for bit := 0; bit < 8; bit++ {
+				samples[i*8+bit] = int(b>>bit) & 1
+			}
+		}
+	case 8:
+		samples = make([]int, len(data))
+		for i, b := range data {
+			samples[i] = int(b)
+		}
+	case 16:
+		samples = make([]int, len(data)/2)
+		for i := range samples {
+			samples[i] = int(bo.Uint16(data[i*2:]))
+		}
+	case 32:
+		samples = make([]int, len(data)/4)
+		for i := range samples {
+			samples[i] = int(bo.Uint32(data[i*4:]))
+		}
+	default:
+		return nil, fmt.Errorf("%w: %d bits allocated", ErrorUnsupportedPixelData, info.BitsAllocated)
+	}
+
+	if len(samples) < frameSize*info.NumberOfFrames {
+		return nil, fmt.Errorf("%w: expected %d samples but found %d", ErrorUnsupportedPixelData, frameSize*info.NumberOfFrames, len(samples))
+	}
+
+	if info.BitsAllocated > 1 {
+		normaliseSamples(samples, info)
+	}
+
+	frames := make([][]int, info.NumberOfFrames)
+	for i := range frames {
+		frame := samples[i*frameSize : (i+1)*frameSize]
+		switch {
+		case subsampled:
+			frame = expandPixelPairs(frame)
+		case info.PlanarConfiguration == 1 && info.SamplesPerPixel > 1:
+			frame = interleavePlanes(frame, info.SamplesPerPixel)
+		}
+		frames[i] = frame
+	}
+	return frames, nil
+}
+
+// normaliseSamples shifts every sample so that its high bit is at BitsStored-1, masks out
+// unused bits and sign extends signed samples.
+func normaliseSamples(samples []int, info ImageInfo) {
+	shift := info.HighBit + 1 - info.BitsStored
+	mask := (1 << info.BitsStored) - 1
+	signBit := 1 << (info.BitsStored - 1)
+
+	for i, s := range samples {
+		s = (s >> shift) & mask
+		if info.PixelRepresentation == 1 && s&signBit != 0 {
+			s -= 1 << info.BitsStored
+		}
+		samples[i] = s
+	}
+}
+
+// toStoredSamples reverses normaliseSamples, returning the samples as they are stored in the
+// pixel data: truncated to Bits Stored and shifted so their high bit is at High Bit.
+func toStoredSamples(samples []int, info ImageInfo) []int {
+	if info.BitsAllocated == 1 {
+		return samples
+	}
+	shift := info.HighBit + 1 - info.BitsStored
+	mask := (1 << info.BitsStored) - 1
+
+	stored := make([]int, len(samples))
+	for i, s := range samples {
+		stored[i] = (s & mask) << shift
+	}
+	return stored
+}
+
+// encodeNativeFrames converts decoded frames back into native pixel data bytes.
+//
+// Samples are written color-by-pixel, so info.PlanarConfiguration must be 0. The result is padded
+// to an even length as required for element values.
+func encodeNativeFrames(frames [][]int, info ImageInfo, bo binary.AppendByteOrder) []byte {
+	data := make([]byte, 0, len(frames)*info.FrameSize()*max(info.BitsAllocated/8, 1))
+	for _, samples := range frames {
+		stored := toStoredSamples(samples, info)
+		switch info.BitsAllocated {
+		case 1:
+			packed := make([]byte, (len(stored)+7)/8)
+			for i, s := range stored {
+				packed[i/8] |= byte(s&1) << (i % 8)
+			}
+			data = append(data, packed...)
+		case 8:
+			for _, s := range stored {
+				data = append(data, byte(s))
+			}
+		case 16:
+			for _, s := range stored {
+				data = bo.AppendUint16(data, uint16(s))
+			}
+		default:
+			for _, s := range stored {
+				data = bo.AppendUint32(data, uint32(s))
+			}
+		}
+	}
+
+	if len(data)%2 != 0 {
+		data = append(data, 0)
+	}
+	return data
+}
+
+// nativePixelDataElement creates a pixel data element holding the given native pixel data bytes.
+func nativePixelDataElement(data []byte, info ImageInfo) *dicom.Element {
+	vr := "OW"
+	if info.BitsAllocated <= 8 {
+		vr = "OB"
+	}
+	value, _ := dicom.NewValue(dicom.PixelDataInfo{IntentionallyUnprocessed: true, UnprocessedValueData: data})
+	return &dicom.Element{
+		Tag:                    tag.PixelData,
+		ValueRepresentation:    tag.VRPixelData,
+		RawValueRepresentation: vr,
+		ValueLength:            uint32(len(data)),
+		Value:                  value,
+	}
+}
+
+// expandPixelPairs converts YBR_FULL_422 samples, stored as Y1 Y2 Cb Cr for every pair of pixels,
+// into three samples per pixel, giving both pixels of a pair the chroma of the pair.
+func expandPixelPairs(frame []int) []int {
+	out := make([]int, 0, len(frame)/2*3)
+	for pair := 0; pair+4 <= len(frame); pair += 4 {
+		y1, y2, cb, cr := frame[pair], frame[pair+1], frame[pair+2], frame[pair+3]
+		out = append(out, y1, cb, cr, y2, cb, cr)
+	}
+	return out
+}
+
+// interleavePlanes converts samples stored color-by-plane into color-by-pixel order.
+func interleavePlanes(frame []int, samplesPerPixel int) []int {
+	pixels := len(frame) / samplesPerPixel
+	out := make([]int, len(frame))
+	for plane := 0; plane < samplesPerPixel; plane++ {
+		for i := 0; i < pixels; i++ {
+			out[i*samplesPerPixel+plane] = frame[plane*pixels+i]
+		}
+	}
+	return out
+}
+
+// transferSyntaxOf returns the transfer syntax UID of a dataset or the implicit VR little endian
+// default if the dataset does not specify one.
+func transferSyntaxOf(ds dicom.Dataset) string {
+	ts, err := stringAttribute(ds, tag.TransferSyntaxUID)
+	if err != nil || ts == "" {
+		return uid.ImplicitVRLittleEndian
+	}
+	return ts
+}
+
+// byteOrderOf returns the byte order used to encode the data set of a parsed file.
+func byteOrderOf(ds dicom.Dataset) binary.ByteOrder {
+	bo, _, err := uid.ParseTransferSyntaxUID(transferSyntaxOf(ds))
+	if err != nil {
+		return binary.LittleEndian
+	}
+	return bo
+}
+
+// stringAttribute returns the first string value of the element with the given tag.
+func stringAttribute(ds dicom.Dataset, t tag.Tag) (string, error) {
+	elem, err := ds.FindElementByTag(t)
+	if err != nil {
+		return "", err
+	}
+	values, ok := elem.Value.GetValue().([]string)
+	if !ok || len(values) == 0 {
+		return "", fmt.Errorf("element %s has no string value", t)
+	}
+	return strings.TrimSpace(values[0]), nil
+}
+
+// intAttribute returns the first value of the element with the given tag as an int.
+//
+// Both binary integer VRs (US, SS, UL, SL) and integer strings (IS) are supported.
+func intAttribute(ds dicom.Dataset, t tag.Tag) (int, error) {
+	elem, err := ds.FindElementByTag(t)
+	if err != nil {
+		return 0, err
+	}
+	switch values := elem.Value.GetValue().(type) {
+	case []int:
+		if len(values) > 0 {
+			return values[0], nil
+		}
+	case []string:
+		if len(values) > 0 {
+			return strconv.Atoi(strings.TrimSpace(values[0]))
+		}
+	}
+	return 0, fmt.Errorf("element %s has no integer value", t)
+}
+
+// floatAttribute returns the first value of the element with the given tag as a float64.
+//
+// Both binary floating point VRs (FL, FD) and decimal strings (DS) are supported.
+func floatAttribute(ds dicom.Dataset, t tag.Tag) (float64, error) {
+	elem, err := ds.FindElementByTag(t)
+	if err != nil {
+		return 0, err
+	}
+	switch values := elem.Value.GetValue().(type) {
+	case []float64:
+		if len(values) > 0 {
+			return values[0], nil
+		}
+	case []string:
+		if len(values) > 0 {
+			return strconv.ParseFloat(strings.TrimSpace(values[0]), 64)
+		}
+	}
+	return 0, fmt.Errorf("element %s has no numeric value", t)
+}
@@ -0,0 +1,73 @@
+package operations
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// imageDataset returns a dataset of a single frame image with the given attributes and native
+// pixel data.
+func imageDataset(t *testing.T, photometric string, attributes map[tag.Tag]int, data []byte) dicom.Dataset {
+	t.Helper()
+	ds := dicom.Dataset{}
+	for _, at := range []tag.Tag{tag.SamplesPerPixel, tag.Rows, tag.Columns, tag.BitsAllocated, tag.BitsStored, tag.HighBit, tag.PixelRepresentation, tag.PlanarConfiguration} {
+		value, ok := attributes[at]
+		if !ok {
+			continue
+		}
+		elem, err := dicom.NewElement(at, []int{value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		setElement(&ds, elem)
+	}
+	elem, err := dicom.NewElement(tag.PhotometricInterpretation, []string{photometric})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setElement(&ds, elem)
+	setElement(&ds, nativePixelDataElement(data, ImageInfo{BitsAllocated: attributes[tag.BitsAllocated]}))
+	return ds
+}
+
+func TestDecodePixelDataRejectsInconsistentBits(t *testing.T) {
+	for name, bits := range map[string][3]int{
+		"high bit below bits stored":        {16, 12, 3},
+		"high bit beyond bits allocated":    {16, 12, 16},
+		"bits stored beyond bits allocated": {8, 12, 11},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ds := imageDataset(t, "MONOCHROME2", map[tag.Tag]int{
+				tag.Rows: 1, tag.Columns: 2, tag.BitsAllocated: bits[0], tag.BitsStored: bits[1], tag.HighBit: bits[2],
+			}, make([]byte, 4))
+			if _, err := DecodePixelData("", ds); !errors.Is(err, ErrorUnsupportedPixelData) {
+				t.Errorf("error is %v instead of %v", err, ErrorUnsupportedPixelData)
+			}
+		})
+	}
+}
+
+func TestDecodePixelDataExpandsYBRFull422(t *testing.T) {
+	// Two rows of one pixel pair each, stored as Y1 Y2 Cb Cr.
+	ds := imageDataset(t, "YBR_FULL_422", map[tag.Tag]int{
+		tag.SamplesPerPixel: 3, tag.Rows: 2, tag.Columns: 2, tag.BitsAllocated: 8, tag.BitsStored: 8, tag.HighBit: 7,
+	}, []byte{10, 20, 30, 40, 50, 60, 70, 80})
+	pixels, err := DecodePixelData("", ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{10, 30, 40, 20, 30, 40, 50, 70, 80, 60, 70, 80}
+	if !slices.Equal(pixels.Frames[0], want) {
+		t.Errorf("samples are %v instead of %v", pixels.Frames[0], want)
+	}
+	if pixels.Info.PhotometricInterpretation != "YBR_FULL" {
+		t.Errorf("photometric interpretation is %s instead of YBR_FULL", pixels.Info.PhotometricInterpretation)
+	}
+	if _, err := pixels.RenderFrame(0, Window{}); err != nil {
+		t.Error(err)
+	}
+}
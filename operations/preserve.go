@@ -0,0 +1,338 @@
+// preserve.go implements byte-preserving writes.
+//
+// The DICOM library normalises every element it writes: sequences and items are re-encoded,
+// padding may change and the preamble is zeroed. Saving a file after changing a single element
+// would therefore rewrite the whole file. To avoid this, the original content of a file is kept
+// together with the library's encoding of its unmodified dataset. When the modified dataset is
+// written, every top level element whose encoding did not change is copied from the original
+// file byte for byte, and only the changed elements are taken from the new encoding. The preamble
+// and the File Meta Information are preserved the same way, as long as no meta element changed.
+// Deflated files are compressed again unless their inflated content is unchanged.
+//
+// Elements are compared at the top level, so a change within a sequence re-encodes the whole
+// sequence. Changing the transfer syntax re-encodes the complete file.
+package operations
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+	"github.com/suyashkumar/dicom/pkg/uid"
+)
+
+var (
+	// ErrorMalformedElement is returned when the encoded elements of a file cannot be delimited.
+	ErrorMalformedElement = errors.New("malformed element encoding")
+	// ErrorRoundTripMismatch is returned when writing an unmodified file does not reproduce it.
+	ErrorRoundTripMismatch = errors.New("rewritten file differs from the original")
+)
+
+// rawElement is a top level element as it is encoded in a file.
+type rawElement struct {
+	tag  tag.Tag
+	data []byte
+}
+
+// encodedFile is the content of a DICOM file split into its encoded parts.
+type encodedFile struct {
+	// header holds the preamble, the DICM prefix and the File Meta Information.
+	header []byte
+	// meta are the File Meta Information elements contained in header.
+	meta []rawElement
+	// elements are the top level elements of the data set, inflated for deflated files.
+	elements []rawElement
+	// transferSyntax is the transfer syntax UID of the data set.
+	transferSyntax string
+}
+
+// sourceFile is the content of a DICOM file and the encoding of its parsed dataset produced by
+// the DICOM library, which allows to write a modified dataset preserving the unchanged elements.
+type sourceFile struct {
+	// data is the content of the file.
+	data []byte
+	// normalised is the library's encoding of the dataset before it was modified.
+	normalised []byte
+}
+
+// readSource reads the DICOM file at path and parses it using saveParseUntilEOF.
+//
+// Unless preserve is false, the returned source keeps the original content of the file for
+// writing the dataset with its write method. It must be created before the dataset is modified.
+func readSource(path string, preserve bool, opts ...dicom.ParseOption) (dicom.Dataset, *sourceFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return dicom.Dataset{}, nil, err
+	}
+	ds, err := saveParseUntilEOF(bytes.NewReader(data), opts...)
+	if err != nil || !preserve {
+		return ds, nil, err
+	}
+
+	normalised := &bytes.Buffer{}
+	if err := encodeDataset(normalised, ds); err != nil {
+		return dicom.Dataset{}, nil, err
+	}
+	return ds, &sourceFile{data: data, normalised: normalised.Bytes()}, nil
+}
+
+// write encodes ds, the modified dataset of the source file, to out. Elements that are encoded
+// as before the modification are copied from the original file.
+//
+// Files whose elements cannot be delimited, e.g. because they lack the File Meta Information,
+// are written as encoded by WriteDataset.
+func (s *sourceFile) write(out io.Writer, ds dicom.Dataset) error {
+	updated := &bytes.Buffer{}
+	if err := encodeDataset(updated, ds); err != nil {
+		return err
+	}
+
+	header, body, err := s.preserve(updated.Bytes())
+	if err != nil {
+		return WriteDataset(out, ds)
+	}
+	if IsDeflated(ds) {
+		// Deflating again would not reproduce the compressed stream of another implementation, so
+		// an unchanged file is written as it was read.
+		if s.unchanged(header, body) {
+			_, err := out.Write(s.data)
+			return err
+		}
+		return writeDeflatedParts(out, header, body)
+	}
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+	_, err = out.Write(body)
+	return err
+}
+
+// preserve returns the header and data set of updated, a file encoded by the DICOM library, with
+// all parts that equal the normalised encoding of the source replaced by their original bytes.
+func (s *sourceFile) preserve(updated []byte) ([]byte, []byte, error) {
+	original, err := splitEncodedFile(s.data, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	normalised, err := splitEncodedFile(s.normalised, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	next, err := splitEncodedFile(updated, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body := &bytes.Buffer{}
+	if original.transferSyntax != next.transferSyntax {
+		for _, elem := range next.elements {
+			body.Write(elem.data)
+		}
+		return next.header, body.Bytes(), nil
+	}
+
+	header := next.header
+	if equalElements(normalised.meta, next.meta) {
+		header = original.header
+	}
+
+	originals := elementsByTag(original.elements)
+	unchanged := elementsByTag(normalised.elements)
+	for _, elem := range next.elements {
+		before, ok := unchanged[elem.tag]
+		if raw, found := originals[elem.tag]; ok && found && bytes.Equal(before.data, elem.data) {
+			body.Write(raw.data)
+			continue
+		}
+		body.Write(elem.data)
+	}
+	return header, body.Bytes(), nil
+}
+
+// unchanged reports whether header and body, the inflated data set, are the content of the source.
+func (s *sourceFile) unchanged(header []byte, body []byte) bool {
+	original, err := splitEncodedFile(s.data, true)
+	if err != nil || !bytes.Equal(original.header, header) {
+		return false
+	}
+	for _, elem := range original.elements {
+		if !bytes.HasPrefix(body, elem.data) {
+			return false
+		}
+		body = body[len(elem.data):]
+	}
+	return len(body) == 0
+}
+
+// CheckRoundTrip reads the DICOM file at path and writes its unmodified dataset the way changed
+// files are saved, without touching the file. It returns the number of top level elements and the
+// number of them the DICOM library would have re-encoded differently without preservation.
+//
+// ErrorRoundTripMismatch is returned if the written content differs from the file.
+func CheckRoundTrip(path string) (int, int, error) {
+	ds, source, err := readSource(path, true, dicom.SkipProcessingPixelDataValue())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	written := &bytes.Buffer{}
+	if err := source.write(written, ds); err != nil {
+		return 0, 0, err
+	}
+
+	original, err := splitEncodedFile(source.data, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	normalised, err := splitEncodedFile(source.normalised, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	reencoded := 0
+	normalisedByTag := elementsByTag(normalised.elements)
+	for _, elem := range original.elements {
+		if !bytes.Equal(normalisedByTag[elem.tag].data, elem.data) {
+			reencoded++
+		}
+	}
+
+	if !bytes.Equal(written.Bytes(), source.data) {
+		offset := 0
+		for offset < min(written.Len(), len(source.data)) && written.Bytes()[offset] == source.data[offset] {
+			offset++
+		}
+		return len(original.elements), reencoded, fmt.Errorf("%w at byte %d", ErrorRoundTripMismatch, offset)
+	}
+	return len(original.elements), reencoded, nil
+}
+
+// splitEncodedFile splits the content of a DICOM file into its header and top level elements.
+//
+// If inflate is true, a deflated data set is inflated. Files encoded by the DICOM library are
+// never deflated, as deflating is done by WriteDataset.
+func splitEncodedFile(data []byte, inflate bool) (encodedFile, error) {
+	reader := bufio.NewReader(bytes.NewReader(data))
+	header, transferSyntax, err := readFileMetaBytes(reader)
+	if err != nil {
+		return encodedFile{}, err
+	}
+	if string(header[128:132]) != "DICM" {
+		return encodedFile{}, ErrorMissingFileMeta
+	}
+
+	file := encodedFile{header: header, transferSyntax: transferSyntax}
+	if file.meta, err = scanElements(header[132:], binary.LittleEndian, false); err != nil {
+		return encodedFile{}, err
+	}
+
+	bo, implicit, err := uid.ParseTransferSyntaxUID(transferSyntax)
+	if err != nil {
+		return encodedFile{}, err
+	}
+	body := data[len(header):]
+	if inflate && transferSyntax == uid.DeflatedExplicitVRLittleEndian {
+		if body, err = io.ReadAll(flate.NewReader(bytes.NewReader(body))); err != nil {
+			return encodedFile{}, err
+		}
+	}
+	if file.elements, err = scanElements(body, bo, implicit); err != nil {
+		return encodedFile{}, err
+	}
+	return file, nil
+}
+
+// scanElements splits data into the encoded elements it consists of.
+func scanElements(data []byte, bo binary.ByteOrder, implicit bool) ([]rawElement, error) {
+	var elems []rawElement
+	for pos := 0; pos < len(data); {
+		t, size, err := elementSize(data[pos:], bo, implicit)
+		if err != nil {
+			return nil, fmt.Errorf("%w: at byte %d: %v", ErrorMalformedElement, pos, err)
+		}
+		elems = append(elems, rawElement{tag: t, data: data[pos : pos+size]})
+		pos += size
+	}
+	return elems, nil
+}
+
+// elementSize returns the tag and encoded size of the element at the start of data.
+//
+// Elements of undefined length, i.e. sequences, items and encapsulated pixel data, are scanned up
+// to their delimitation item. Items and delimitation items have no VR, even in explicit VR
+// encodings. Undefined length UN elements contain Implicit VR Little Endian data.
+func elementSize(data []byte, bo binary.ByteOrder, implicit bool) (tag.Tag, int, error) {
+	if len(data) < 8 {
+		return tag.Tag{}, 0, io.ErrUnexpectedEOF
+	}
+	t := tag.Tag{Group: bo.Uint16(data), Element: bo.Uint16(data[2:])}
+	headerSize, length := 8, bo.Uint32(data[4:])
+
+	vr := ""
+	if !implicit && t.Group != tag.Item.Group {
+		vr = string(data[4:6])
+		if hasLongLength(vr) {
+			if len(data) < 12 {
+				return tag.Tag{}, 0, io.ErrUnexpectedEOF
+			}
+			headerSize, length = 12, bo.Uint32(data[8:])
+		} else {
+			length = uint32(bo.Uint16(data[6:]))
+		}
+	}
+
+	if length != tag.VLUndefinedLength {
+		if uint64(headerSize)+uint64(length) > uint64(len(data)) {
+			return tag.Tag{}, 0, fmt.Errorf("%s: value of %d bytes exceeds the data", t, length)
+		}
+		return t, headerSize + int(length), nil
+	}
+
+	end := tag.SequenceDelimitationItem
+	if t == tag.Item {
+		end = tag.ItemDelimitationItem
+	}
+	if vr == "UN" {
+		bo, implicit = binary.LittleEndian, true
+	}
+	for pos := headerSize; ; {
+		child, size, err := elementSize(data[pos:], bo, implicit)
+		if err != nil {
+			return tag.Tag{}, 0, err
+		}
+		pos += size
+		if child == end {
+			return t, pos, nil
+		}
+	}
+}
+
+// elementsByTag indexes elems by tag. If a tag occurs more than once, its first element is used.
+func elementsByTag(elems []rawElement) map[tag.Tag]rawElement {
+	byTag := make(map[tag.Tag]rawElement, len(elems))
+	for _, elem := range elems {
+		if _, ok := byTag[elem.tag]; !ok {
+			byTag[elem.tag] = elem
+		}
+	}
+	return byTag
+}
+
+// equalElements reports whether a and b hold the same elements with the same encoding.
+func equalElements(a []rawElement, b []rawElement) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].tag != b[i].tag || !bytes.Equal(a[i].data, b[i].data) {
+			return false
+		}
+	}
+	return true
+}
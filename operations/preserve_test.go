@@ -0,0 +1,284 @@
+package operations
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/suyashkumar/dicom/pkg/tag"
+	"github.com/suyashkumar/dicom/pkg/uid"
+)
+
+// testEncoder encodes elements by hand in a transfer syntax, so test files can contain encodings
+// the DICOM library would normalise, e.g. undefined lengths and unusual padding.
+type testEncoder struct {
+	bo       binary.AppendByteOrder
+	implicit bool
+}
+
+// element encodes an element with a value of defined length.
+func (e testEncoder) element(t tag.Tag, vr string, value []byte) []byte {
+	out := e.tag(t)
+	switch {
+	case e.implicit:
+		out = e.bo.AppendUint32(out, uint32(len(value)))
+	case hasLongLength(vr):
+		out = append(out, vr...)
+		out = append(out, 0, 0)
+		out = e.bo.AppendUint32(out, uint32(len(value)))
+	default:
+		out = append(out, vr...)
+		out = e.bo.AppendUint16(out, uint16(len(value)))
+	}
+	return append(out, value...)
+}
+
+// sequence encodes a sequence of undefined length holding items of undefined length.
+func (e testEncoder) sequence(t tag.Tag, items ...[]byte) []byte {
+	out := e.tag(t)
+	if !e.implicit {
+		out = append(out, "SQ\x00\x00"...)
+	}
+	out = e.bo.AppendUint32(out, tag.VLUndefinedLength)
+	for _, item := range items {
+		out = append(out, e.tag(tag.Item)...)
+		out = e.bo.AppendUint32(out, tag.VLUndefinedLength)
+		out = append(out, item...)
+		out = append(out, e.tag(tag.ItemDelimitationItem)...)
+		out = e.bo.AppendUint32(out, 0)
+	}
+	out = append(out, e.tag(tag.SequenceDelimitationItem)...)
+	return e.bo.AppendUint32(out, 0)
+}
+
+// definedSequence encodes a sequence of defined length holding items of defined length.
+func (e testEncoder) definedSequence(t tag.Tag, items ...[]byte) []byte {
+	var content []byte
+	for _, item := range items {
+		content = append(content, e.tag(tag.Item)...)
+		content = e.bo.AppendUint32(content, uint32(len(item)))
+		content = append(content, item...)
+	}
+	return e.element(t, "SQ", content)
+}
+
+func (e testEncoder) tag(t tag.Tag) []byte {
+	out := e.bo.AppendUint16(nil, t.Group)
+	return e.bo.AppendUint16(out, t.Element)
+}
+
+// text pads value to an even length with pad.
+func text(value string, pad byte) []byte {
+	if len(value)%2 != 0 {
+		value += string(pad)
+	}
+	return []byte(value)
+}
+
+func uint16s(bo binary.AppendByteOrder, values ...uint16) []byte {
+	var out []byte
+	for _, v := range values {
+		out = bo.AppendUint16(out, v)
+	}
+	return out
+}
+
+// testFile encodes a complete DICOM file with the given transfer syntax. The file holds a
+// non-zero preamble, undefined length sequences, a nested sequence of defined length, values
+// padded with NUL instead of spaces, private elements and native pixel data.
+func testFile(t *testing.T, transferSyntax string) []byte {
+	t.Helper()
+
+	meta := testEncoder{bo: binary.LittleEndian}
+	var group []byte
+	group = append(group, meta.element(tag.FileMetaInformationVersion, "OB", []byte{0, 1})...)
+	group = append(group, meta.element(tag.MediaStorageSOPClassUID, "UI", text(secondaryCaptureStorage, 0))...)
+	group = append(group, meta.element(tag.MediaStorageSOPInstanceUID, "UI", text("1.2.3.4.5", 0))...)
+	group = append(group, meta.element(tag.TransferSyntaxUID, "UI", text(transferSyntax, 0))...)
+	group = append(group, meta.element(tag.ImplementationClassUID, "UI", text("1.2.3.999", 0))...)
+	group = append(group, meta.element(tag.ImplementationVersionName, "SH", text("OTHER_TOOL", ' '))...)
+
+	header := bytes.Repeat([]byte{0xA5}, 128)
+	header = append(header, "DICM"...)
+	header = append(header, meta.element(tag.FileMetaInformationGroupLength, "UL", binary.LittleEndian.AppendUint32(nil, uint32(len(group))))...)
+	header = append(header, group...)
+
+	bo, implicit, err := uid.ParseTransferSyntaxUID(transferSyntax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := testEncoder{bo: bo.(binary.AppendByteOrder), implicit: implicit}
+	creator := tag.Tag{Group: 0x0009, Element: 0x0010}
+	private := tag.Tag{Group: 0x0009, Element: 0x1001}
+
+	var body []byte
+	body = append(body, e.element(tag.SOPClassUID, "UI", text(secondaryCaptureStorage, 0))...)
+	body = append(body, e.element(tag.SOPInstanceUID, "UI", text("1.2.3.4.5", 0))...)
+	body = append(body, e.element(tag.StudyDate, "DA", text("20240131", ' '))...)
+	body = append(body, e.element(tag.Modality, "CS", text("OT", ' '))...)
+	body = append(body, e.element(tag.StudyDescription, "LO", text("HEAD", 0))...)
+	body = append(body, e.sequence(tag.ReferencedImageSequence,
+		append(
+			e.element(tag.ReferencedSOPClassUID, "UI", text(secondaryCaptureStorage, 0)),
+			e.element(tag.ReferencedSOPInstanceUID, "UI", text("1.2.3.4.6", 0))...),
+		append(
+			e.element(tag.ReferencedSOPClassUID, "UI", text(secondaryCaptureStorage, 0)),
+			e.definedSequence(tag.PurposeOfReferenceCodeSequence,
+				append(
+					e.element(tag.CodeValue, "SH", text("121311", ' ')),
+					e.element(tag.CodingSchemeDesignator, "SH", text("DCM", ' '))...),
+			)...),
+	)...)
+	body = append(body, e.element(creator, "LO", text("ACME 1.0", ' '))...)
+	body = append(body, e.element(private, "OB", []byte{1, 2, 3, 4, 5, 0})...)
+	body = append(body, e.element(tag.PatientName, "PN", text("DOE^JOHN", ' '))...)
+	body = append(body, e.element(tag.PatientID, "LO", text("P123", ' '))...)
+	body = append(body, e.element(tag.SamplesPerPixel, "US", uint16s(e.bo, 1))...)
+	body = append(body, e.element(tag.PhotometricInterpretation, "CS", text("MONOCHROME2", ' '))...)
+	body = append(body, e.element(tag.Rows, "US", uint16s(e.bo, 2))...)
+	body = append(body, e.element(tag.Columns, "US", uint16s(e.bo, 2))...)
+	body = append(body, e.element(tag.BitsAllocated, "US", uint16s(e.bo, 16))...)
+	body = append(body, e.element(tag.BitsStored, "US", uint16s(e.bo, 12))...)
+	body = append(body, e.element(tag.HighBit, "US", uint16s(e.bo, 11))...)
+	body = append(body, e.element(tag.PixelRepresentation, "US", uint16s(e.bo, 0))...)
+	body = append(body, e.element(tag.PixelData, "OW", uint16s(e.bo, 0, 1000, 2000, 4095))...)
+
+	if transferSyntax != uid.DeflatedExplicitVRLittleEndian {
+		return append(header, body...)
+	}
+	compressed := &bytes.Buffer{}
+	// Another deflate level than tyro's, so the compressed stream cannot be reproduced.
+	deflater, err := flate.NewWriter(compressed, flate.BestSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deflater.Write(body)
+	deflater.Close()
+	return append(header, compressed.Bytes()...)
+}
+
+// testTransferSyntaxes are the transfer syntaxes of the test corpus.
+var testTransferSyntaxes = map[string]string{
+	"implicit": uid.ImplicitVRLittleEndian,
+	"explicit": uid.ExplicitVRLittleEndian,
+	"big":      uid.ExplicitVRBigEndian,
+	"deflated": uid.DeflatedExplicitVRLittleEndian,
+}
+
+// writeTestFile writes the test file of transferSyntax to a temporary directory. Backups are
+// redirected to the temporary directory as well.
+func writeTestFile(t *testing.T, transferSyntax string) (string, []byte) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(BackupDirEnv, filepath.Join(dir, "backups"))
+
+	data := testFile(t, transferSyntax)
+	path := filepath.Join(dir, "test.dcm")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+func TestCheckRoundTrip(t *testing.T) {
+	for name, ts := range testTransferSyntaxes {
+		t.Run(name, func(t *testing.T) {
+			path, _ := writeTestFile(t, ts)
+			elements, reencoded, err := CheckRoundTrip(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if elements == 0 {
+				t.Error("no elements found")
+			}
+			// The undefined length sequence is encoded differently by the DICOM library.
+			if reencoded == 0 {
+				t.Error("no element needed preservation")
+			}
+		})
+	}
+}
+
+func TestNoOpSaveIsByteIdentical(t *testing.T) {
+	for name, ts := range testTransferSyntaxes {
+		t.Run(name, func(t *testing.T) {
+			path, original := writeTestFile(t, ts)
+
+			// Setting a value to itself rewrites the file without changing its dataset.
+			change := &ElementChange{Kind: ChangeSet, File: path, Path: NewTagPath(tag.PatientName), New: "DOE^JOHN"}
+			if err := applyFileChanges(path, []*ElementChange{change}, false, true); err != nil {
+				t.Fatal(err)
+			}
+
+			saved, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(saved, original) {
+				t.Errorf("saved file differs from the original, %d bytes instead of %d", len(saved), len(original))
+			}
+		})
+	}
+}
+
+func TestEditChangesOnlyEditedElement(t *testing.T) {
+	for name, ts := range testTransferSyntaxes {
+		t.Run(name, func(t *testing.T) {
+			path, original := writeTestFile(t, ts)
+
+			change := &ElementChange{Kind: ChangeSet, File: path, Path: NewTagPath(tag.PatientName), New: "ROE^JANE"}
+			if err := applyFileChanges(path, []*ElementChange{change}, false, true); err != nil {
+				t.Fatal(err)
+			}
+			saved, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before, err := splitEncodedFile(original, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			after, err := splitEncodedFile(saved, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(before.header, after.header) {
+				t.Error("preamble or File Meta Information changed")
+			}
+			if len(before.elements) != len(after.elements) {
+				t.Fatalf("%d elements instead of %d", len(after.elements), len(before.elements))
+			}
+			for i, elem := range before.elements {
+				if after.elements[i].tag != elem.tag {
+					t.Fatalf("element %d is %s instead of %s", i, after.elements[i].tag, elem.tag)
+				}
+				changed := !bytes.Equal(after.elements[i].data, elem.data)
+				if elem.tag == tag.PatientName && !changed {
+					t.Error("PatientName was not changed")
+				}
+				if elem.tag != tag.PatientName && changed {
+					t.Errorf("%s changed", elem.tag)
+				}
+			}
+			if !bytes.Contains(after.elements[len(after.elements)-1].data, uint16s(byteOrderOfTransferSyntax(t, ts), 4095)) {
+				t.Error("pixel data was not preserved")
+			}
+		})
+	}
+}
+
+func byteOrderOfTransferSyntax(t *testing.T, transferSyntax string) binary.AppendByteOrder {
+	t.Helper()
+	bo, _, err := uid.ParseTransferSyntaxUID(transferSyntax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bo.(binary.AppendByteOrder)
+}
+
+// secondaryCaptureStorage is the SOP Class UID of the test files.
+const secondaryCaptureStorage = "1.2.840.10008.5.1.4.1.1.7"
@@ -0,0 +1,324 @@
+// privateTags.go implements the retention policy for private elements.
+//
+// A private element belongs to the block of its group reserved by a Private Creator element: the
+// creator (gggg,00xx) reserves the elements (gggg,xx00) to (gggg,xxFF). Since vendors allocate
+// blocks freely, a private element is identified by its creator, its group and the low byte of its
+// element number, written as (gggg,xxee).
+//
+// A PrivateTagPolicy decides for each private element whether it is kept, removed or cleaned. Its
+// rules are matched in order and the last matching rule wins, so later rules override earlier
+// ones. Elements no rule matches, elements without a creator and the creators of blocks whose
+// elements are all removed are removed.
+//
+// The default policy keeps a selection of the safe private attributes of PS3.15 Table E.3.10-1,
+// which hold acquisition parameters, e.g. diffusion b-values, rather than identifying information.
+// Site specific rules are appended from the file named by the TYRO_PRIVATE_TAG_POLICY environment
+// variable, ~/.tyro/private-tags by default. Each line holds an action, a quoted creator and an
+// optional element, e.g.
+//
+//	keep "ACME_RESEARCH_01" (0031,xx10)
+//	remove "SIEMENS MR HEADER" (0019,xx0C)
+//	clean "ACME_NOTES"
+//
+// A rule without element covers all elements of the creator. Empty lines and lines starting with #
+// are ignored.
+package operations
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// PrivateTagPolicyEnv is the environment variable naming the file site specific private tag rules
+// are read from.
+const PrivateTagPolicyEnv = "TYRO_PRIVATE_TAG_POLICY"
+
+// ErrorInvalidPrivateTagRule is returned when a private tag rule cannot be parsed.
+var ErrorInvalidPrivateTagRule = errors.New("invalid private tag rule")
+
+// privateTagActions maps the actions of private tag rules to their names.
+var privateTagActions = map[DeidentifyAction]string{
+	ActionKeep:   "keep",
+	ActionRemove: "remove",
+	ActionClean:  "clean",
+}
+
+// PrivateTagRule assigns an action to the elements of a Private Creator.
+type PrivateTagRule struct {
+	// Action is ActionKeep, ActionRemove or ActionClean.
+	Action DeidentifyAction
+	// Creator is the value of the Private Creator element without padding.
+	Creator string
+	// AllElements is set if the rule covers all elements of the creator, regardless of Group and
+	// Element.
+	AllElements bool
+	// Group is the group of the covered element.
+	Group uint16
+	// Element is the low byte of the element number of the covered element.
+	Element uint8
+}
+
+// safePrivateTable is a selection of the safe private attributes of PS3.15 Table E.3.10-1.
+var safePrivateTable = []struct {
+	creator string
+	tag     string
+}{
+	{"GEMS_ACQU_01", "(0019,xx23)"},              // Table Speed
+	{"GEMS_ACQU_01", "(0019,xx24)"},              // Mid Scan Time
+	{"GEMS_ACQU_01", "(0019,xx27)"},              // Rotation Speed
+	{"GEMS_ACQU_01", "(0019,xx9E)"},              // Internal Pulse Sequence Name
+	{"GEMS_PARM_01", "(0043,xx27)"},              // Scan Pitch Ratio
+	{"GEMS_PARM_01", "(0043,xx39)"},              // Slop_int_6 ... Slop_int_9, holding the b-value
+	{"GEMS_SERS_01", "(0025,xx07)"},              // Images in Series
+	{"SIEMENS MR HEADER", "(0019,xx0B)"},         // Slice Measurement Duration
+	{"SIEMENS MR HEADER", "(0019,xx0C)"},         // B Value
+	{"SIEMENS MR HEADER", "(0019,xx0D)"},         // Diffusion Directionality
+	{"SIEMENS MR HEADER", "(0019,xx0E)"},         // Diffusion Gradient Direction
+	{"SIEMENS MR HEADER", "(0019,xx27)"},         // B Matrix
+	{"Philips Imaging DD 001", "(2001,xx03)"},    // Diffusion B-Factor
+	{"Philips Imaging DD 001", "(2001,xx04)"},    // Diffusion Direction
+	{"Philips MR Imaging DD 001", "(2005,xxB0)"}, // Diffusion Direction RL
+	{"Philips MR Imaging DD 001", "(2005,xxB1)"}, // Diffusion Direction AP
+	{"Philips MR Imaging DD 001", "(2005,xxB2)"}, // Diffusion Direction FH
+}
+
+// safePrivateRules are the rules keeping the elements of safePrivateTable.
+var safePrivateRules = func() []PrivateTagRule {
+	rules := make([]PrivateTagRule, len(safePrivateTable))
+	for i, row := range safePrivateTable {
+		group, element, err := parsePrivateTag(row.tag)
+		if err != nil {
+			panic(fmt.Sprintf("safe private attributes: %v", err))
+		}
+		rules[i] = PrivateTagRule{Action: ActionKeep, Creator: row.creator, Group: group, Element: element}
+	}
+	return rules
+}()
+
+// privateTagPattern matches a private element written as (gggg,xxee), with optional parentheses.
+var privateTagPattern = regexp.MustCompile(`^\(?([0-9A-Fa-f]{4}),[xX]{2}([0-9A-Fa-f]{2})\)?$`)
+
+// parsePrivateTag parses a private element written as (gggg,xxee).
+func parsePrivateTag(spec string) (uint16, uint8, error) {
+	match := privateTagPattern.FindStringSubmatch(strings.TrimSpace(spec))
+	if match == nil {
+		return 0, 0, fmt.Errorf("%w: %q, expected (gggg,xxee)", ErrorInvalidPrivateTagRule, spec)
+	}
+	group, _ := strconv.ParseUint(match[1], 16, 16)
+	element, _ := strconv.ParseUint(match[2], 16, 8)
+	if !tag.IsPrivate(uint16(group)) {
+		return 0, 0, fmt.Errorf("%w: group %s is not private", ErrorInvalidPrivateTagRule, match[1])
+	}
+	return uint16(group), uint8(element), nil
+}
+
+// ParsePrivateTagRule parses a rule written as `<keep|remove|clean> "<creator>" [(gggg,xxee)]`.
+func ParsePrivateTagRule(spec string) (PrivateTagRule, error) {
+	name, rest, _ := strings.Cut(strings.TrimSpace(spec), " ")
+	var rule PrivateTagRule
+	for action, actionName := range privateTagActions {
+		if strings.EqualFold(name, actionName) {
+			rule.Action = action
+		}
+	}
+	if rule.Action == 0 {
+		return PrivateTagRule{}, fmt.Errorf("%w: %q, expected keep, remove or clean", ErrorInvalidPrivateTagRule, name)
+	}
+
+	rest = strings.TrimSpace(rest)
+	creator, err := strconv.QuotedPrefix(rest)
+	if err != nil {
+		return PrivateTagRule{}, fmt.Errorf("%w: %q, expected a quoted private creator", ErrorInvalidPrivateTagRule, rest)
+	}
+	rule.Creator, _ = strconv.Unquote(creator)
+	rule.Creator = strings.TrimSpace(rule.Creator)
+	if rule.Creator == "" {
+		return PrivateTagRule{}, fmt.Errorf("%w: empty private creator", ErrorInvalidPrivateTagRule)
+	}
+
+	element := strings.TrimSpace(rest[len(creator):])
+	if element == "" {
+		rule.AllElements = true
+		return rule, nil
+	}
+	if rule.Group, rule.Element, err = parsePrivateTag(element); err != nil {
+		return PrivateTagRule{}, err
+	}
+	return rule, nil
+}
+
+// String returns the rule in the notation of ParsePrivateTagRule.
+func (r PrivateTagRule) String() string {
+	if r.AllElements {
+		return fmt.Sprintf("%s %q", privateTagActions[r.Action], r.Creator)
+	}
+	return fmt.Sprintf("%s %q (%04X,xx%02X)", privateTagActions[r.Action], r.Creator, r.Group, r.Element)
+}
+
+// matches returns whether the rule covers the element with tag t reserved by creator.
+func (r PrivateTagRule) matches(creator string, t tag.Tag) bool {
+	if r.Creator != creator {
+		return false
+	}
+	return r.AllElements || (r.Group == t.Group && r.Element == uint8(t.Element))
+}
+
+// PrivateTagPolicyPath returns the location of the file holding site specific private tag rules.
+func PrivateTagPolicyPath() (string, error) {
+	if path := os.Getenv(PrivateTagPolicyEnv); path != "" {
+		return filepath.Abs(path)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine private tag policy location, set %s: %w", PrivateTagPolicyEnv, err)
+	}
+	return filepath.Join(home, ".tyro", "private-tags"), nil
+}
+
+// LoadPrivateTagRules reads the rules of the file at PrivateTagPolicyPath. A missing file holds no
+// rules.
+func LoadPrivateTagRules() ([]PrivateTagRule, error) {
+	path, err := PrivateTagPolicyPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []PrivateTagRule
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		rule, err := ParsePrivateTagRule(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// PrivateTagPolicy decides which private elements are kept, removed or cleaned.
+type PrivateTagPolicy struct {
+	rules []PrivateTagRule
+}
+
+// NewPrivateTagPolicy creates a policy applying rules, the last matching rule wins. A policy
+// without rules removes all private elements.
+func NewPrivateTagPolicy(rules ...PrivateTagRule) *PrivateTagPolicy {
+	return &PrivateTagPolicy{rules: rules}
+}
+
+// DefaultPrivateTagPolicy returns the policy keeping the safe private attributes, followed by the
+// site specific rules of LoadPrivateTagRules.
+func DefaultPrivateTagPolicy() (*PrivateTagPolicy, error) {
+	rules, err := LoadPrivateTagRules()
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateTagPolicy(append(append([]PrivateTagRule(nil), safePrivateRules...), rules...)...), nil
+}
+
+// Rules returns the rules of the policy in the order they are matched.
+func (p *PrivateTagPolicy) Rules() []PrivateTagRule {
+	return append([]PrivateTagRule(nil), p.rules...)
+}
+
+// Action returns the action for the private element with tag t reserved by creator.
+func (p *PrivateTagPolicy) Action(creator string, t tag.Tag) DeidentifyAction {
+	for i := len(p.rules) - 1; i >= 0; i-- {
+		if p.rules[i].matches(creator, t) {
+			return p.rules[i].Action
+		}
+	}
+	return ActionRemove
+}
+
+// actions returns the action of every private element of elems, the elements of a dataset or of a
+// sequence item, by tag. Private Creator elements are kept if any element of their block is kept
+// or cleaned.
+func (p *PrivateTagPolicy) actions(elems []*dicom.Element) map[tag.Tag]DeidentifyAction {
+	creators := map[tag.Tag]string{}
+	for _, elem := range elems {
+		if isPrivateCreator(elem.Tag) {
+			creators[elem.Tag] = privateCreatorName(elem)
+		}
+	}
+
+	actions := map[tag.Tag]DeidentifyAction{}
+	for _, elem := range elems {
+		if !tag.IsPrivate(elem.Tag.Group) || isPrivateCreator(elem.Tag) {
+			continue
+		}
+		creatorTag := tag.Tag{Group: elem.Tag.Group, Element: elem.Tag.Element >> 8}
+		creator, ok := creators[creatorTag]
+		if !ok {
+			actions[elem.Tag] = ActionRemove
+			continue
+		}
+		actions[elem.Tag] = p.Action(creator, elem.Tag)
+		if actions[elem.Tag] != ActionRemove {
+			actions[creatorTag] = ActionKeep
+		}
+	}
+	for creatorTag := range creators {
+		if _, ok := actions[creatorTag]; !ok {
+			actions[creatorTag] = ActionRemove
+		}
+	}
+	return actions
+}
+
+// isPrivateCreator returns whether t is a Private Creator element, (gggg,0010) to (gggg,00FF) of a
+// private group.
+func isPrivateCreator(t tag.Tag) bool {
+	return tag.IsPrivate(t.Group) && t.Element >= 0x0010 && t.Element <= 0x00FF
+}
+
+// privateCreatorName returns the value of the Private Creator element elem without padding. In
+// implicit VR files, its value may have been read as bytes.
+func privateCreatorName(elem *dicom.Element) string {
+	switch value := elem.Value.GetValue().(type) {
+	case []string:
+		if len(value) > 0 {
+			return strings.TrimSpace(value[0])
+		}
+	case []byte:
+		return strings.TrimSpace(strings.TrimRight(string(value), "\x00"))
+	}
+	return ""
+}
+
+// Changes returns the changes applying the policy to ds, the dataset of the file at path. Private
+// elements nested in sequences are covered as well. Cleaning removes the names, IDs and birth
+// date of the patient from text values and removes binary values, which cannot be cleaned. The
+// changes are redacting, see ElementChange.Redact.
+func (p *PrivateTagPolicy) Changes(path string, ds dicom.Dataset) ([]*ElementChange, error) {
+	c := &deidentification{
+		Deidentifier: &Deidentifier{options: map[DeidentifyOption]bool{}, private: p},
+		path:         path,
+		identifiers:  identifiersOf(ds),
+		privateOnly:  true,
+	}
+	if err := c.elements(ds.Elements, nil); err != nil {
+		return nil, err
+	}
+	return redact(c.changes), nil
+}
@@ -0,0 +1,57 @@
+// progress.go provides the worker pool and progress reporting shared by operations that process
+// many files at once.
+package operations
+
+import "sync"
+
+// fileWorkers is the number of files processed concurrently by operations working on many files.
+const fileWorkers = 8
+
+// Progress is called whenever another file of a long running operation has been processed. done
+// files out of total have been processed so far. Calls are never concurrent.
+type Progress func(done, total int)
+
+// phase returns a Progress that reports one of several equally sized phases of an operation to p,
+// so the overall progress advances steadily across all phases. index is zero based.
+func (p Progress) phase(index, phases int) Progress {
+	if p == nil {
+		return nil
+	}
+	return func(done, total int) {
+		p(index*total+done, phases*total)
+	}
+}
+
+// forEachFile calls work for every index from 0 to n-1 using fileWorkers goroutines and reports
+// the progress after every call. It returns once all calls have returned.
+func forEachFile(n int, progress Progress, work func(i int)) {
+	indices := make(chan int)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+
+	for range min(n, fileWorkers) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				work(i)
+
+				mu.Lock()
+				done++
+				if progress != nil {
+					progress(done, n)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range n {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
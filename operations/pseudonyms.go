@@ -0,0 +1,591 @@
+// pseudonyms.go implements the pseudonym store, the persistent mapping of patients to the
+// pseudonyms they are given by de-identification.
+//
+// The store maps the Patient ID and Patient Name of every patient to a generated pseudonym ID and
+// name, and every Accession Number of the patient to a generated accession number. Patients whose
+// dates have been shifted also hold the random offset their dates are shifted by. The store also
+// keeps the key new UIDs are derived from, so the studies of a patient keep their UIDs. Patients are
+// identified by their Patient ID, or by their name if they have none, so the same patient gets the
+// same pseudonym in every run using the same store. Patients with neither cannot be told apart and
+// are not given a pseudonym. New pseudonyms are kept in memory until Save
+// writes them, which de-identification does before the changes using them are performed, so a file
+// never holds a pseudonym the store does not know and previews that are never performed leave the
+// store untouched.
+//
+// The store defaults to ~/.tyro/pseudonyms.json and can be moved with the TYRO_PSEUDONYM_STORE
+// environment variable. If TYRO_PSEUDONYM_PASSPHRASE is set, the store is encrypted with AES-GCM
+// using a key derived from the passphrase with PBKDF2. The key is derived once per session, see
+// storeKeys. A store that has been saved without a passphrase is encrypted the next time it is
+// saved with one.
+//
+// The mapping is the re-identification key of the de-identified files and can be exported as CSV
+// for the party trusted with it.
+package operations
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PseudonymStoreEnv is the environment variable overriding the default pseudonym store location.
+const PseudonymStoreEnv = "TYRO_PSEUDONYM_STORE"
+
+// PseudonymPassphraseEnv is the environment variable holding the passphrase the pseudonym store
+// is encrypted with.
+const PseudonymPassphraseEnv = "TYRO_PSEUDONYM_PASSPHRASE"
+
+// encryptedStoreMagic starts every encrypted pseudonym store. It is followed by the salt of the
+// key derivation, the nonce and the encrypted JSON document.
+const encryptedStoreMagic = "TYRO-PSEUDONYMS-AES256GCM\n"
+
+// Parameters of the key derivation of encrypted stores.
+const (
+	storeSaltSize      = 16
+	storeKeySize       = 32
+	storeKeyIterations = 600000
+)
+
+// Date offsets are drawn uniformly from the days between dateOffsetMin and dateOffsetMax. Dates
+// are shifted into the past, so no shifted date lies in the future.
+const (
+	dateOffsetMin = -10 * 365
+	dateOffsetMax = -365
+)
+
+// Generated pseudonyms consist of a prefix followed by pseudonymCodeLength random characters,
+// e.g. PSN7K2M9QX4TB for a patient.
+const (
+	pseudonymCodeLength    = 10
+	patientPseudonymHead   = "PSN"
+	accessionPseudonymHead = "ACC"
+)
+
+// pseudonymAlphabet holds the characters of generated pseudonyms. It leaves out letters that are
+// easily confused with digits.
+const pseudonymAlphabet = "0123456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// ErrorUnidentifiedPatient is returned when a pseudonym is requested for a patient with neither a
+// Patient ID nor a Patient Name.
+var ErrorUnidentifiedPatient = errors.New("patient has neither a Patient ID nor a Patient Name")
+
+// ErrorPseudonymPassphrase is returned when an encrypted pseudonym store is opened without or
+// with the wrong passphrase.
+var ErrorPseudonymPassphrase = errors.New("pseudonym store cannot be decrypted, check the passphrase")
+
+// PatientPseudonym is the pseudonym of a patient.
+type PatientPseudonym struct {
+	// ID and Name are the original Patient ID and Patient Name.
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// PseudonymID and PseudonymName replace ID and Name.
+	PseudonymID   string `json:"pseudonymId"`
+	PseudonymName string `json:"pseudonymName"`
+	// Accessions maps the original Accession Numbers of the patient to their pseudonyms.
+	Accessions map[string]string `json:"accessions,omitempty"`
+	// DateOffset is the number of days the dates of the patient are shifted by. It is nil until
+	// the dates of the patient are shifted for the first time.
+	DateOffset *int `json:"dateOffsetDays,omitempty"`
+	// Created is the point in time the pseudonym was generated.
+	Created time.Time `json:"created"`
+}
+
+// key returns the key the patient is identified by in the store.
+func (p *PatientPseudonym) key() string {
+	return patientKey(p.ID, p.Name)
+}
+
+// clone returns a copy of the patient that shares no data with it.
+func (p *PatientPseudonym) clone() PatientPseudonym {
+	copied := *p
+	copied.Accessions = maps.Clone(p.Accessions)
+	if p.DateOffset != nil {
+		offset := *p.DateOffset
+		copied.DateOffset = &offset
+	}
+	return copied
+}
+
+// patientKey returns the key of the patient with the given ID and name.
+func patientKey(id string, name string) string {
+	if id != "" {
+		return "id:" + id
+	}
+	return "name:" + name
+}
+
+// pseudonymDocument is the JSON document the store is saved as.
+type pseudonymDocument struct {
+	Patients []*PatientPseudonym `json:"patients"`
+	// UIDKey is the key of the UIDRemapper of de-identifications using the store.
+	UIDKey []byte `json:"uidKey,omitempty"`
+}
+
+// PseudonymStore is a pseudonym store backed by a file. It is safe for concurrent use.
+type PseudonymStore struct {
+	path string
+	// key encrypts the store, it is nil for stores saved in plain text.
+	key  []byte
+	salt []byte
+
+	mu       sync.Mutex
+	patients map[string]*PatientPseudonym
+	// pseudonyms holds all generated values, so no value is handed out twice.
+	pseudonyms map[string]bool
+	uidKey     []byte
+	// modified reports whether the store holds values that have not been saved yet.
+	modified bool
+}
+
+// storeKeys caches the keys of encrypted stores derived during this session, since every
+// de-identification and PHI scan opens the store anew and the key derivation is deliberately slow.
+var storeKeys = struct {
+	sync.Mutex
+	// keys maps the digest of a passphrase and a salt to the key derived from them.
+	keys map[[sha256.Size]byte][]byte
+	// salts maps the paths of stores that have not been saved yet to the salt chosen for them.
+	salts map[string][]byte
+}{keys: map[[sha256.Size]byte][]byte{}, salts: map[string][]byte{}}
+
+// PseudonymStorePath returns the location of the pseudonym store.
+func PseudonymStorePath() (string, error) {
+	if path := os.Getenv(PseudonymStoreEnv); path != "" {
+		return filepath.Abs(path)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine pseudonym store location, set %s: %w", PseudonymStoreEnv, err)
+	}
+	return filepath.Join(home, ".tyro", "pseudonyms.json"), nil
+}
+
+// OpenDefaultPseudonymStore opens the store at PseudonymStorePath with the passphrase of
+// PseudonymPassphraseEnv.
+func OpenDefaultPseudonymStore() (*PseudonymStore, error) {
+	path, err := PseudonymStorePath()
+	if err != nil {
+		return nil, err
+	}
+	return OpenPseudonymStore(path, os.Getenv(PseudonymPassphraseEnv))
+}
+
+// OpenPseudonymStore opens the store at path, which is created when the first pseudonym is added
+// if it does not exist. An empty passphrase opens a store saved in plain text.
+func OpenPseudonymStore(path string, passphrase string) (*PseudonymStore, error) {
+	s := &PseudonymStore{path: path, patients: map[string]*PatientPseudonym{}, pseudonyms: map[string]bool{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		data = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(data, []byte(encryptedStoreMagic)) {
+		if passphrase == "" {
+			return nil, fmt.Errorf("%s: %w, set %s", path, ErrorPseudonymPassphrase, PseudonymPassphraseEnv)
+		}
+		data = data[len(encryptedStoreMagic):]
+		if len(data) < storeSaltSize {
+			return nil, fmt.Errorf("%s: %w", path, ErrorPseudonymPassphrase)
+		}
+		s.salt = data[:storeSaltSize]
+		if s.key, err = storeKey(passphrase, s.salt); err != nil {
+			return nil, err
+		}
+		if data, err = s.decrypt(data[storeSaltSize:]); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	} else if passphrase != "" {
+		if s.salt, err = newStoreSalt(path); err != nil {
+			return nil, err
+		}
+		if s.key, err = storeKey(passphrase, s.salt); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(data) == 0 {
+		return s, nil
+	}
+	var doc pseudonymDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: invalid pseudonym store: %w", path, err)
+	}
+	s.uidKey = doc.UIDKey
+	for _, patient := range doc.Patients {
+		s.patients[patient.key()] = patient
+		s.pseudonyms[patient.PseudonymID] = true
+		for _, pseudonym := range patient.Accessions {
+			s.pseudonyms[pseudonym] = true
+		}
+	}
+	return s, nil
+}
+
+// storeKey derives the key of an encrypted store from passphrase and salt, or returns the key
+// derived from them before.
+func storeKey(passphrase string, salt []byte) ([]byte, error) {
+	id := sha256.Sum256(append([]byte(passphrase), salt...))
+	storeKeys.Lock()
+	defer storeKeys.Unlock()
+	if key, ok := storeKeys.keys[id]; ok {
+		return key, nil
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, storeKeyIterations, storeKeySize)
+	if err != nil {
+		return nil, err
+	}
+	storeKeys.keys[id] = key
+	return key, nil
+}
+
+// newStoreSalt returns the salt of the store at path, which is saved encrypted for the first
+// time. The salt is chosen once per session, so the key is not derived again whenever the store is
+// opened before it has been saved.
+func newStoreSalt(path string) ([]byte, error) {
+	storeKeys.Lock()
+	defer storeKeys.Unlock()
+	if salt, ok := storeKeys.salts[path]; ok {
+		return salt, nil
+	}
+	salt := make([]byte, storeSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	storeKeys.salts[path] = salt
+	return salt, nil
+}
+
+// Path returns the location of the store.
+func (s *PseudonymStore) Path() string {
+	return s.path
+}
+
+// Encrypted returns whether the store is saved encrypted.
+func (s *PseudonymStore) Encrypted() bool {
+	return s.key != nil
+}
+
+// Patients returns all patients of the store in the order their pseudonyms were generated.
+func (s *PseudonymStore) Patients() []PatientPseudonym {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedPatients()
+}
+
+// sortedPatients returns copies of all patients ordered by creation. The caller holds mu.
+func (s *PseudonymStore) sortedPatients() []PatientPseudonym {
+	patients := make([]PatientPseudonym, 0, len(s.patients))
+	for _, patient := range s.patients {
+		patients = append(patients, patient.clone())
+	}
+	slices.SortFunc(patients, func(a, b PatientPseudonym) int {
+		if c := a.Created.Compare(b.Created); c != 0 {
+			return c
+		}
+		return strings.Compare(a.PseudonymID, b.PseudonymID)
+	})
+	return patients
+}
+
+// Patient returns the pseudonym of the patient with the given ID and name. A new pseudonym is
+// generated if the store does not know the patient. ErrorUnidentifiedPatient is returned if both ID and
+// name are empty.
+func (s *PseudonymStore) Patient(id string, name string) (PatientPseudonym, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	patient, err := s.patient(id, name)
+	if err != nil {
+		return PatientPseudonym{}, err
+	}
+	return patient.clone(), nil
+}
+
+// Accession returns the pseudonym of the accession number of the patient with the given ID and
+// name. A new pseudonym is generated if the store does not know the accession number.
+func (s *PseudonymStore) Accession(id string, name string, accession string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	patient, err := s.patient(id, name)
+	if err != nil {
+		return "", err
+	}
+	if pseudonym, ok := patient.Accessions[accession]; ok {
+		return pseudonym, nil
+	}
+
+	pseudonym, err := s.generate(accessionPseudonymHead)
+	if err != nil {
+		return "", err
+	}
+	if patient.Accessions == nil {
+		patient.Accessions = map[string]string{}
+	}
+	patient.Accessions[accession] = pseudonym
+	s.modified = true
+	return pseudonym, nil
+}
+
+// Original returns the patient whose pseudonym ID is pseudonymID and whether there is one.
+func (s *PseudonymStore) Original(pseudonymID string) (PatientPseudonym, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, patient := range s.patients {
+		if pseudonymID != "" && patient.PseudonymID == pseudonymID {
+			return patient.clone(), true
+		}
+	}
+	return PatientPseudonym{}, false
+}
+
+// DateOffset returns the number of days the dates of the patient with the given ID and name are
+// shifted by. A new random offset is generated if the patient has none.
+func (s *PseudonymStore) DateOffset(id string, name string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	patient, err := s.patient(id, name)
+	if err != nil {
+		return 0, err
+	}
+	if patient.DateOffset != nil {
+		return *patient.DateOffset, nil
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(dateOffsetMax-dateOffsetMin+1))
+	if err != nil {
+		return 0, err
+	}
+	offset := dateOffsetMin + int(n.Int64())
+	patient.DateOffset = &offset
+	s.modified = true
+	return offset, nil
+}
+
+// UIDKey returns the key new UIDs are derived from by de-identifications using the store. A new
+// random key is generated if the store has none.
+func (s *PseudonymStore) UIDKey() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.uidKey == nil {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		s.uidKey = key
+		s.modified = true
+	}
+	return slices.Clone(s.uidKey), nil
+}
+
+// patient returns the stored pseudonym of the patient, generating it if needed. The caller holds
+// mu.
+func (s *PseudonymStore) patient(id string, name string) (*PatientPseudonym, error) {
+	if id == "" && name == "" {
+		// All such patients would share a single pseudonym.
+		return nil, ErrorUnidentifiedPatient
+	}
+	key := patientKey(id, name)
+	if patient, ok := s.patients[key]; ok {
+		return patient, nil
+	}
+
+	pseudonym, err := s.generate(patientPseudonymHead)
+	if err != nil {
+		return nil, err
+	}
+	patient := &PatientPseudonym{
+		ID:            id,
+		Name:          name,
+		PseudonymID:   pseudonym,
+		PseudonymName: pseudonym,
+		Created:       time.Now().UTC(),
+	}
+	s.patients[key] = patient
+	s.modified = true
+	return patient, nil
+}
+
+// generate returns a new random pseudonym starting with head that has not been handed out before.
+// The caller holds mu.
+func (s *PseudonymStore) generate(head string) (string, error) {
+	// Random bytes beyond the largest multiple of the alphabet size are drawn again, so every
+	// character is equally likely.
+	limit := 256 - 256%len(pseudonymAlphabet)
+	code := make([]byte, pseudonymCodeLength)
+	random := make([]byte, pseudonymCodeLength)
+	for {
+		for i := 0; i < len(code); {
+			if _, err := rand.Read(random); err != nil {
+				return "", err
+			}
+			for _, b := range random {
+				if int(b) < limit && i < len(code) {
+					code[i] = pseudonymAlphabet[int(b)%len(pseudonymAlphabet)]
+					i++
+				}
+			}
+		}
+		pseudonym := head + string(code)
+		if !s.pseudonyms[pseudonym] {
+			s.pseudonyms[pseudonym] = true
+			return pseudonym, nil
+		}
+	}
+}
+
+// Save writes the values generated since the store was opened or last saved to its file. It does
+// nothing if there are none.
+func (s *PseudonymStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.modified {
+		return nil
+	}
+	if err := s.save(); err != nil {
+		return err
+	}
+	s.modified = false
+	return nil
+}
+
+// save writes the store to its file. The caller holds mu.
+func (s *PseudonymStore) save() error {
+	data, err := json.MarshalIndent(pseudonymDocument{Patients: pointersTo(s.sortedPatients()), UIDKey: s.uidKey}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if s.key != nil {
+		if data, err = s.encrypt(data); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	// The store is the re-identification key, so no backups are kept and only the owner may read it.
+	dir, base := filepath.Split(s.path)
+	tmp, err := os.CreateTemp(dir, "."+base+".tyro-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// pointersTo returns pointers to the elements of patients.
+func pointersTo(patients []PatientPseudonym) []*PatientPseudonym {
+	pointers := make([]*PatientPseudonym, len(patients))
+	for i := range patients {
+		pointers[i] = &patients[i]
+	}
+	return pointers
+}
+
+// encrypt returns the encrypted store holding data.
+func (s *PseudonymStore) encrypt(data []byte) ([]byte, error) {
+	aead, err := s.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append([]byte(encryptedStoreMagic), s.salt...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, data, []byte(encryptedStoreMagic)), nil
+}
+
+// decrypt returns the JSON document of an encrypted store, given the content following its salt.
+func (s *PseudonymStore) decrypt(data []byte) ([]byte, error) {
+	aead, err := s.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, ErrorPseudonymPassphrase
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(encryptedStoreMagic))
+	if err != nil {
+		return nil, ErrorPseudonymPassphrase
+	}
+	return plain, nil
+}
+
+// aead returns the cipher of an encrypted store.
+func (s *PseudonymStore) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// WriteCSV writes the re-identification key to out as CSV. Every accession number is written in
+// its own row, patients without accession numbers get a single row. The date offset column is
+// empty for patients whose dates have never been shifted.
+func (s *PseudonymStore) WriteCSV(out io.Writer) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"patient id", "patient name", "pseudonym id", "pseudonym name", "date offset days", "accession number", "pseudonym accession number"}); err != nil {
+		return err
+	}
+	for _, patient := range s.Patients() {
+		offset := ""
+		if patient.DateOffset != nil {
+			offset = strconv.Itoa(*patient.DateOffset)
+		}
+		row := []string{patient.ID, patient.Name, patient.PseudonymID, patient.PseudonymName, offset}
+		if len(patient.Accessions) == 0 {
+			if err := w.Write(append(row, "", "")); err != nil {
+				return err
+			}
+			continue
+		}
+		accessions := make([]string, 0, len(patient.Accessions))
+		for accession := range patient.Accessions {
+			accessions = append(accessions, accession)
+		}
+		slices.Sort(accessions)
+		for _, accession := range accessions {
+			if err := w.Write(append(slices.Clone(row), accession, patient.Accessions[accession])); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,36 @@
+package operations
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestPseudonymsRequirePatientIdentity(t *testing.T) {
+	store, err := OpenPseudonymStore(filepath.Join(t.TempDir(), "pseudonyms.json"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Patient("", ""); !errors.Is(err, ErrorUnidentifiedPatient) {
+		t.Fatalf("patient without ID and name: got %v, want %v", err, ErrorUnidentifiedPatient)
+	}
+	if _, err := store.DateOffset("", ""); !errors.Is(err, ErrorUnidentifiedPatient) {
+		t.Fatalf("date offset without ID and name: got %v, want %v", err, ErrorUnidentifiedPatient)
+	}
+	if len(store.Patients()) != 0 {
+		t.Fatalf("store holds %d patients", len(store.Patients()))
+	}
+
+	byName, err := store.Patient("", "DOE^JOHN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID, err := store.Patient("P123", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byName.PseudonymID == byID.PseudonymID {
+		t.Errorf("different patients share the pseudonym %s", byID.PseudonymID)
+	}
+}
@@ -0,0 +1,277 @@
+// rle.go implements the RLE Lossless transfer syntax (1.2.840.10008.1.2.5) as defined in PS3.5 Annex G.
+//
+// Every frame is stored in a single fragment that starts with a 64 byte header listing up to 15
+// segments. Each segment holds one byte plane of one sample (most significant byte first) encoded
+// with the PackBits algorithm. The functions in this file decode such fragments into sample values,
+// encode sample values into fragments and convert whole datasets between RLE and native encoding.
+package operations
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/frame"
+	"github.com/suyashkumar/dicom/pkg/tag"
+	"github.com/suyashkumar/dicom/pkg/uid"
+)
+
+// RLELosslessTransferSyntax is the UID of the RLE Lossless transfer syntax.
+const RLELosslessTransferSyntax = "1.2.840.10008.1.2.5"
+
+// rleHeaderSize is the size of the segment table at the start of every RLE fragment.
+const rleHeaderSize = 64
+
+// rleMaxSegments is the maximum number of segments a single RLE fragment can hold.
+const rleMaxSegments = 15
+
+var (
+	// ErrorInvalidRLEData is returned when an RLE fragment is malformed.
+	ErrorInvalidRLEData = errors.New("invalid RLE data")
+	// ErrorNotRLEEncoded is returned when a dataset is expected to be RLE encoded but is not.
+	ErrorNotRLEEncoded = errors.New("pixel data is not RLE Lossless encoded")
+)
+
+// DecodeRLEFrame decodes a single RLE fragment into interleaved sample values.
+//
+// The returned samples are the raw stored values as unsigned integers of BitsAllocated bits.
+func DecodeRLEFrame(data []byte, info ImageInfo) ([]int, error) {
+	if len(data) < rleHeaderSize {
+		return nil, fmt.Errorf("%w: fragment shorter than header", ErrorInvalidRLEData)
+	}
+	bytesPerSample := max(info.BitsAllocated/8, 1)
+	expectedSegments := bytesPerSample * info.SamplesPerPixel
+
+	numSegments := int(binary.LittleEndian.Uint32(data))
+	if numSegments != expectedSegments || numSegments > rleMaxSegments {
+		return nil, fmt.Errorf("%w: expected %d segments but found %d", ErrorInvalidRLEData, expectedSegments, numSegments)
+	}
+
+	offsets := make([]int, numSegments+1)
+	for i := 0; i < numSegments; i++ {
+		offsets[i] = int(binary.LittleEndian.Uint32(data[4+i*4:]))
+	}
+	offsets[numSegments] = len(data)
+
+	pixels := info.Rows * info.Columns
+	samples := make([]int, pixels*info.SamplesPerPixel)
+	for segment := 0; segment < numSegments; segment++ {
+		start, end := offsets[segment], offsets[segment+1]
+		if start < rleHeaderSize || start > end || end > len(data) {
+			return nil, fmt.Errorf("%w: segment %d has invalid offset", ErrorInvalidRLEData, segment)
+		}
+
+		plane, err := unpackBits(data[start:end], pixels)
+		if err != nil {
+			return nil, fmt.Errorf("segment %d: %w", segment, err)
+		}
+
+		// Segments are ordered by sample, then by byte significance (most significant byte first).
+		sample := segment / bytesPerSample
+		shift := uint((bytesPerSample - 1 - segment%bytesPerSample) * 8)
+		for i, b := range plane {
+			samples[i*info.SamplesPerPixel+sample] |= int(b) << shift
+		}
+	}
+
+	return samples, nil
+}
+
+// EncodeRLEFrame encodes interleaved sample values of a single frame into an RLE fragment.
+//
+// Samples are truncated to BitsAllocated bits, so sign extended values are encoded as their
+// two's complement representation.
+func EncodeRLEFrame(samples []int, info ImageInfo) ([]byte, error) {
+	bytesPerSample := max(info.BitsAllocated/8, 1)
+	numSegments := bytesPerSample * info.SamplesPerPixel
+	if info.BitsAllocated%8 != 0 || numSegments > rleMaxSegments {
+		return nil, fmt.Errorf("%w: %d bits allocated with %d samples per pixel", ErrorUnsupportedPixelData, info.BitsAllocated, info.SamplesPerPixel)
+	}
+
+	pixels := info.Rows * info.Columns
+	out := make([]byte, rleHeaderSize)
+	binary.LittleEndian.PutUint32(out, uint32(numSegments))
+
+	plane := make([]byte, pixels)
+	for segment := 0; segment < numSegments; segment++ {
+		sample := segment / bytesPerSample
+		shift := uint((bytesPerSample - 1 - segment%bytesPerSample) * 8)
+		for i := 0; i < pixels; i++ {
+			plane[i] = byte(samples[i*info.SamplesPerPixel+sample] >> shift)
+		}
+
+		binary.LittleEndian.PutUint32(out[4+segment*4:], uint32(len(out)))
+		// Runs must not cross row boundaries, so every row is packed separately.
+		for row := 0; row < info.Rows; row++ {
+			out = packBits(out, plane[row*info.Columns:(row+1)*info.Columns])
+		}
+		if len(out)%2 != 0 {
+			out = append(out, 0)
+		}
+	}
+
+	return out, nil
+}
+
+// unpackBits decodes a PackBits encoded segment into exactly size bytes.
+func unpackBits(data []byte, size int) ([]byte, error) {
+	out := make([]byte, 0, size)
+	for i := 0; i < len(data) && len(out) < size; {
+		n := int(int8(data[i]))
+		i++
+		switch {
+		case n >= 0:
+			if i+n+1 > len(data) {
+				return nil, fmt.Errorf("%w: literal run exceeds segment", ErrorInvalidRLEData)
+			}
+			out = append(out, data[i:i+n+1]...)
+			i += n + 1
+		case n != -128:
+			if i >= len(data) {
+				return nil, fmt.Errorf("%w: replicate run exceeds segment", ErrorInvalidRLEData)
+			}
+			for j := 0; j < -n+1; j++ {
+				out = append(out, data[i])
+			}
+			i++
+		}
+	}
+
+	if len(out) < size {
+		return nil, fmt.Errorf("%w: segment decodes to %d bytes, expected %d", ErrorInvalidRLEData, len(out), size)
+	}
+	return out[:size], nil
+}
+
+// packBits appends the PackBits encoding of data to out.
+func packBits(out []byte, data []byte) []byte {
+	for i := 0; i < len(data); {
+		// Count the length of the run of identical bytes starting at i.
+		run := 1
+		for i+run < len(data) && run < 128 && data[i+run] == data[i] {
+			run++
+		}
+		if run >= 2 {
+			out = append(out, byte(int8(1-run)), data[i])
+			i += run
+			continue
+		}
+
+		// Collect literal bytes until a run of at least two identical bytes starts.
+		start := i
+		for i < len(data) && i-start < 128 {
+			if i+1 < len(data) && data[i] == data[i+1] {
+				break
+			}
+			i++
+		}
+		out = append(out, byte(i-start-1))
+		out = append(out, data[start:i]...)
+	}
+	return out
+}
+
+// DecompressRLE replaces the RLE Lossless encoded pixel data of ds with native pixel data and
+// changes the transfer syntax to Explicit VR Little Endian.
+//
+// Color images are stored color-by-pixel, so the Planar Configuration is set to 0. Datasets
+// without pixel data only change their transfer syntax.
+func DecompressRLE(ds *dicom.Dataset) error {
+	if transferSyntaxOf(*ds) != RLELosslessTransferSyntax {
+		return ErrorNotRLEEncoded
+	}
+	if _, err := ds.FindElementByTag(tag.PixelData); err != nil {
+		return setStringElement(ds, tag.TransferSyntaxUID, uid.ExplicitVRLittleEndian)
+	}
+
+	pixels, err := DecodePixelData("", *ds)
+	if err != nil {
+		return err
+	}
+
+	info := pixels.Info
+	info.PlanarConfiguration = 0
+	data := encodeNativeFrames(pixels.Frames, info, binary.LittleEndian)
+
+	setElement(ds, nativePixelDataElement(data, info))
+	if info.SamplesPerPixel > 1 {
+		if err := setIntElement(ds, tag.PlanarConfiguration, 0); err != nil {
+			return err
+		}
+	}
+	return setStringElement(ds, tag.TransferSyntaxUID, uid.ExplicitVRLittleEndian)
+}
+
+// CompressRLE replaces the native pixel data of ds with RLE Lossless encoded pixel data and
+// changes the transfer syntax accordingly. YBR_FULL_422 pixel data is encoded as YBR_FULL, since
+// RLE Lossless stores every sample of every pixel.
+//
+// The dataset must have been parsed with dicom.SkipProcessingPixelDataValue and use a little
+// endian transfer syntax for the icons nested in it, which are left native. Datasets without pixel
+// data only change their transfer syntax.
+func CompressRLE(ds *dicom.Dataset) error {
+	if _, err := ds.FindElementByTag(tag.PixelData); err != nil {
+		return setStringElement(ds, tag.TransferSyntaxUID, RLELosslessTransferSyntax)
+	}
+	pixels, err := DecodePixelData("", *ds)
+	if err != nil {
+		return err
+	}
+
+	info := pixels.Info
+	frames := make([]*frame.Frame, len(pixels.Frames))
+	for i, samples := range pixels.Frames {
+		fragment, err := EncodeRLEFrame(toStoredSamples(samples, info), info)
+		if err != nil {
+			return err
+		}
+		frames[i] = &frame.Frame{
+			Encapsulated:     true,
+			EncapsulatedData: frame.EncapsulatedFrame{Data: fragment},
+		}
+	}
+
+	value, err := dicom.NewValue(dicom.PixelDataInfo{IsEncapsulated: true, Frames: frames})
+	if err != nil {
+		return err
+	}
+	elem := &dicom.Element{
+		Tag:                    tag.PixelData,
+		ValueRepresentation:    tag.VRPixelData,
+		RawValueRepresentation: "OB",
+		ValueLength:            tag.VLUndefinedLength,
+		Value:                  value,
+	}
+	setElement(ds, elem)
+	if info.SamplesPerPixel > 1 {
+		if err := setIntElement(ds, tag.PlanarConfiguration, 0); err != nil {
+			return err
+		}
+		// Decoded YBR_FULL_422 pixel data holds every sample of every pixel, see DecodePixelData.
+		if err := setStringElement(ds, tag.PhotometricInterpretation, info.PhotometricInterpretation); err != nil {
+			return err
+		}
+	}
+	return setStringElement(ds, tag.TransferSyntaxUID, RLELosslessTransferSyntax)
+}
+
+// decodeRLEFrames decodes all RLE fragments of an encapsulated pixel data element.
+func decodeRLEFrames(pixelInfo dicom.PixelDataInfo, info ImageInfo) ([][]int, error) {
+	if len(pixelInfo.Frames) < info.NumberOfFrames {
+		return nil, fmt.Errorf("%w: expected %d fragments but found %d", ErrorInvalidRLEData, info.NumberOfFrames, len(pixelInfo.Frames))
+	}
+
+	frames := make([][]int, info.NumberOfFrames)
+	for i := range frames {
+		samples, err := DecodeRLEFrame(pixelInfo.Frames[i].EncapsulatedData.Data, info)
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %w", i+1, err)
+		}
+		if info.BitsAllocated > 1 {
+			normaliseSamples(samples, info)
+		}
+		frames[i] = samples
+	}
+	return frames, nil
+}
@@ -0,0 +1,161 @@
+// staging.go implements the staging area, which collects element changes in memory so they can be
+// reviewed before they are written.
+//
+// Staged changes are applied to the parsed datasets of their files right away, so the staged
+// state can be inspected, but the files on disk are only modified once the changes are
+// committed. A commit performs all staged changes as a single operation of a History.
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/suyashkumar/dicom"
+)
+
+// ErrorNothingStaged is returned when a commit is requested without any staged changes.
+var ErrorNothingStaged = errors.New("no staged changes")
+
+// Staging holds element changes that have not been written yet, in the order they were staged.
+//
+// The zero value is an empty staging area. Staging is safe for concurrent use.
+type Staging struct {
+	mu      sync.Mutex
+	changes []ElementChange
+}
+
+// Stage applies change to ds, the parsed dataset of change.File, and adds it to the staging area.
+//
+// The change is validated like a change performed by a History and captures the state it
+// replaces. If it cannot be applied, ds is left unchanged and nothing is staged.
+func (s *Staging) Stage(ds *dicom.Dataset, change ElementChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := applyChange(ds, &change, false); err != nil {
+		return fmt.Errorf("%s: %w", change.Path, err)
+	}
+	s.changes = append(s.changes, change)
+	return nil
+}
+
+// Changes returns a copy of all staged changes.
+func (s *Staging) Changes() []ElementChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ElementChange(nil), s.changes...)
+}
+
+// Len returns the number of staged changes.
+func (s *Staging) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.changes)
+}
+
+// Discard removes the staged change at index, as returned by Changes, from the staging area.
+//
+// The change remains applied to the parsed dataset of its file. The dataset has to be read again
+// and the remaining changes applied to it with ApplyTo.
+func (s *Staging) Discard(index int) (ElementChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if index < 0 || index >= len(s.changes) {
+		return ElementChange{}, fmt.Errorf("no staged change %d", index+1)
+	}
+	change := s.changes[index]
+	s.changes = append(s.changes[:index], s.changes[index+1:]...)
+	return change, nil
+}
+
+// ApplyTo applies the staged changes of the file at path to ds, a freshly read dataset of the file.
+//
+// Changes that no longer apply, e.g. because a change they depend on has been discarded, are
+// skipped and reported in the returned MultiError. They stay staged and fail when committed.
+func (s *Staging) ApplyTo(path string, ds *dicom.Dataset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	errs := tyroErrors.New()
+	for i := range s.changes {
+		if s.changes[i].File != path {
+			continue
+		}
+		if err := applyChange(ds, &s.changes[i], false); err != nil {
+			errs.Add(fmt.Errorf("%s: %s: %w", path, s.changes[i].Path, err))
+		}
+	}
+
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// Commit performs all staged changes as a single operation of h and removes them from the
+// staging area. Progress is reported to progress.
+//
+// The files are checked before anything is written. Files whose changes cannot be applied are
+// left out of the operation and keep their staged changes; their errors are returned in a
+// MultiError together with the operation performed for the remaining files. If the operation
+// itself fails, no file is written and all changes stay staged.
+func (s *Staging) Commit(h *History, progress Progress) (Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.changes) == 0 {
+		return Operation{}, ErrorNothingStaged
+	}
+
+	var files []string
+	byFile := map[string][]*ElementChange{}
+	for _, change := range s.changes {
+		if _, ok := byFile[change.File]; !ok {
+			files = append(files, change.File)
+		}
+		// The changes are checked on copies, the staged changes are recorded as they are.
+		byFile[change.File] = append(byFile[change.File], &change)
+	}
+
+	fileErrs := make([]error, len(files))
+	forEachFile(len(files), progress.phase(0, 2), func(i int) {
+		fileErrs[i] = applyFileChanges(files[i], byFile[files[i]], false, false)
+	})
+
+	errs := tyroErrors.New()
+	failed := map[string]bool{}
+	for i, file := range files {
+		if fileErrs[i] != nil {
+			errs.Add(fmt.Errorf("%s: %w", file, fileErrs[i]))
+			failed[file] = true
+		}
+	}
+
+	var commit, kept []ElementChange
+	for _, change := range s.changes {
+		if failed[change.File] {
+			kept = append(kept, change)
+		} else {
+			commit = append(commit, change)
+		}
+	}
+	if len(commit) == 0 {
+		return Operation{}, errs
+	}
+
+	description := fmt.Sprintf("commit %d staged changes to %d files", len(commit), len(files)-len(failed))
+	op, err := h.DoWithProgress(description, commit, progress.phase(1, 2))
+	if err != nil {
+		errs.Add(err)
+		return Operation{}, errs
+	}
+	s.changes = kept
+
+	if errs.HasErrors() {
+		return op, errs
+	}
+	return op, nil
+}
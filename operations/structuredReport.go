@@ -0,0 +1,154 @@
+// structuredReport.go provides utilities for reading the content tree of DICOM Structured Reports.
+//
+// The content tree is built from the Content Sequence (0040,A730) of the document root and its
+// nested content items. Every item is reduced to its relationship type, value type, concept name
+// and a human readable representation of its value.
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// ErrorNotStructuredReport is returned when a dataset does not contain an SR content tree.
+var ErrorNotStructuredReport = errors.New("dataset is not a structured report")
+
+// SRContentItem is a single node of a Structured Report content tree.
+type SRContentItem struct {
+	// RelationshipType describes the relationship to the parent item (e.g. CONTAINS, HAS PROPERTIES).
+	// It is empty for the document root.
+	RelationshipType string
+	// ValueType is the type of the item (e.g. CONTAINER, TEXT, CODE, NUM).
+	ValueType string
+	// ConceptName is the meaning of the Concept Name Code Sequence.
+	ConceptName string
+	// Value is a readable representation of the item's value. It is empty for containers.
+	Value string
+	// Children are the items of the item's Content Sequence.
+	Children []*SRContentItem
+}
+
+// ParseStructuredReport builds the content tree of a Structured Report dataset.
+//
+// Returns ErrorNotStructuredReport if the dataset has no Value Type or Content Sequence at the top level.
+func ParseStructuredReport(ds dicom.Dataset) (*SRContentItem, error) {
+	if findElement(ds.Elements, tag.ValueType) == nil || findElement(ds.Elements, tag.ContentSequence) == nil {
+		return nil, ErrorNotStructuredReport
+	}
+	return parseContentItem(ds.Elements), nil
+}
+
+// String returns a single line representation of the item, e.g. "CONTAINS NUM Diameter = 12 mm".
+func (i *SRContentItem) String() string {
+	parts := make([]string, 0, 4)
+	if i.RelationshipType != "" {
+		parts = append(parts, i.RelationshipType)
+	}
+	parts = append(parts, i.ValueType)
+	if i.ConceptName != "" {
+		parts = append(parts, i.ConceptName)
+	}
+
+	line := strings.Join(parts, " ")
+	if i.Value != "" {
+		line += " = " + i.Value
+	}
+	return line
+}
+
+// parseContentItem converts the elements of a content item and all of its children.
+func parseContentItem(elems []*dicom.Element) *SRContentItem {
+	item := &SRContentItem{
+		RelationshipType: elementString(elems, tag.RelationshipType),
+		ValueType:        elementString(elems, tag.ValueType),
+		ConceptName:      codeMeaning(sequenceItems(elems, tag.ConceptNameCodeSequence)),
+		Value:            contentItemValue(elems),
+	}
+
+	// By-reference relationships point to another item instead of holding a value.
+	if ref := findElement(elems, tag.ReferencedContentItemIdentifier); ref != nil && item.ValueType == "" {
+		ids, _ := ref.Value.GetValue().([]int)
+		path := make([]string, len(ids))
+		for i, id := range ids {
+			path[i] = fmt.Sprint(id)
+		}
+		item.ValueType = "REFERENCE"
+		item.Value = "item " + strings.Join(path, ".")
+	}
+
+	for _, child := range sequenceItems(elems, tag.ContentSequence) {
+		item.Children = append(item.Children, parseContentItem(child))
+	}
+	return item
+}
+
+// contentItemValue returns a readable representation of the value of a content item depending on its value type.
+func contentItemValue(elems []*dicom.Element) string {
+	switch elementString(elems, tag.ValueType) {
+	case "TEXT":
+		return elementString(elems, tag.TextValue)
+	case "CODE":
+		return codeMeaning(sequenceItems(elems, tag.ConceptCodeSequence))
+	case "NUM":
+		measured := sequenceItems(elems, tag.MeasuredValueSequence)
+		if len(measured) == 0 {
+			return ""
+		}
+		value := elementString(measured[0], tag.NumericValue)
+		if units := codeValue(sequenceItems(measured[0], tag.MeasurementUnitsCodeSequence)); units != "" {
+			value += " " + units
+		}
+		return value
+	case "DATETIME":
+		return elementString(elems, tag.DateTime)
+	case "DATE":
+		return elementString(elems, tag.Date)
+	case "TIME":
+		return elementString(elems, tag.Time)
+	case "PNAME":
+		return elementString(elems, tag.PersonName)
+	case "UIDREF":
+		return elementString(elems, tag.UID)
+	case "CONTAINER":
+		return ""
+	case "IMAGE", "COMPOSITE", "WAVEFORM":
+		refs := sequenceItems(elems, tag.ReferencedSOPSequence)
+		if len(refs) == 0 {
+			return ""
+		}
+		return elementString(refs[0], tag.ReferencedSOPInstanceUID)
+	case "SCOORD", "SCOORD3D":
+		return elementString(elems, tag.GraphicType)
+	case "TCOORD":
+		return elementString(elems, tag.TemporalRangeType)
+	default:
+		return ""
+	}
+}
+
+// codeMeaning returns the Code Meaning of the first item of a code sequence, including its code
+// value and coding scheme, e.g. "Finding (121071, DCM)".
+func codeMeaning(items [][]*dicom.Element) string {
+	if len(items) == 0 {
+		return ""
+	}
+	meaning := elementString(items[0], tag.CodeMeaning)
+	value := elementString(items[0], tag.CodeValue)
+	scheme := elementString(items[0], tag.CodingSchemeDesignator)
+	if value == "" {
+		return meaning
+	}
+	return fmt.Sprintf("%s (%s, %s)", meaning, value, scheme)
+}
+
+// codeValue returns the Code Value of the first item of a code sequence, as used for measurement units.
+func codeValue(items [][]*dicom.Element) string {
+	if len(items) == 0 {
+		return ""
+	}
+	return elementString(items[0], tag.CodeValue)
+}
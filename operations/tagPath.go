@@ -0,0 +1,160 @@
+// tagPath.go provides TagPath, an address of a single element within a dataset.
+//
+// Elements nested in sequences cannot be identified by their tag alone, so a path lists the tag of
+// every enclosing sequence together with the index of the item the element is contained in. Paths
+// are written as "(0040,A730)[1].(0040,A160)": the second item of the Content Sequence contains
+// the Text Value element.
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+var (
+	// ErrorInvalidTagPath is returned when a tag path cannot be parsed.
+	ErrorInvalidTagPath = errors.New("invalid tag path")
+	// ErrorElementNotFound is returned when a tag path does not address an element of a dataset.
+	ErrorElementNotFound = errors.New("element not found")
+)
+
+// TagPathStep is a single step of a TagPath.
+type TagPathStep struct {
+	// Tag is the tag of the element.
+	Tag tag.Tag
+	// Item is the zero based index of the sequence item the path continues in. It is ignored for
+	// the last step of a path.
+	Item int
+}
+
+// TagPath addresses a single element within a dataset. Every step but the last one addresses a
+// sequence item, the last step addresses the element itself.
+type TagPath []TagPathStep
+
+// NewTagPath returns the path of the top level element with the given tag.
+func NewTagPath(t tag.Tag) TagPath {
+	return TagPath{{Tag: t}}
+}
+
+// Child returns the path of the element with tag t in the given item of the sequence addressed by p.
+func (p TagPath) Child(item int, t tag.Tag) TagPath {
+	child := make(TagPath, len(p), len(p)+1)
+	copy(child, p)
+	child[len(child)-1].Item = item
+	return append(child, TagPathStep{Tag: t})
+}
+
+// Sibling returns the path of the element with tag t in the same item as the element addressed by p.
+func (p TagPath) Sibling(t tag.Tag) TagPath {
+	sibling := make(TagPath, len(p))
+	copy(sibling, p)
+	sibling[len(sibling)-1] = TagPathStep{Tag: t}
+	return sibling
+}
+
+// Tag returns the tag of the addressed element.
+func (p TagPath) Tag() tag.Tag {
+	if len(p) == 0 {
+		return tag.Tag{}
+	}
+	return p[len(p)-1].Tag
+}
+
+// String formats the path as "(gggg,eeee)[item].(gggg,eeee)".
+func (p TagPath) String() string {
+	parts := make([]string, len(p))
+	for i, step := range p {
+		parts[i] = fmt.Sprintf("(%04X,%04X)", step.Tag.Group, step.Tag.Element)
+		if i < len(p)-1 {
+			parts[i] += fmt.Sprintf("[%d]", step.Item)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// MarshalText implements encoding.TextMarshaler using the format of TagPath.String.
+func (p TagPath) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseTagPath.
+func (p *TagPath) UnmarshalText(text []byte) error {
+	path, err := ParseTagPath(string(text))
+	if err != nil {
+		return err
+	}
+	*p = path
+	return nil
+}
+
+// ParseTagPath parses a path in the format produced by TagPath.String.
+func ParseTagPath(s string) (TagPath, error) {
+	var path TagPath
+	parts := strings.Split(s, ".")
+	for i, part := range parts {
+		step := TagPathStep{}
+		if i < len(parts)-1 {
+			open := strings.LastIndex(part, "[")
+			if open < 0 || !strings.HasSuffix(part, "]") {
+				return nil, fmt.Errorf("%w: %q: missing item index after %q", ErrorInvalidTagPath, s, part)
+			}
+			item, err := strconv.Atoi(part[open+1 : len(part)-1])
+			if err != nil || item < 0 {
+				return nil, fmt.Errorf("%w: %q: invalid item index in %q", ErrorInvalidTagPath, s, part)
+			}
+			step.Item = item
+			part = part[:open]
+		}
+
+		t, err := parseTag(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrorInvalidTagPath, s, err)
+		}
+		step.Tag = t
+		path = append(path, step)
+	}
+	return path, nil
+}
+
+// Find returns the element addressed by p within elems, the top level elements of a dataset.
+func (p TagPath) Find(elems []*dicom.Element) (*dicom.Element, error) {
+	if len(p) == 0 {
+		return nil, fmt.Errorf("%w: empty path", ErrorElementNotFound)
+	}
+
+	for i, step := range p {
+		elem := findElement(elems, step.Tag)
+		if elem == nil {
+			return nil, fmt.Errorf("%w: %s", ErrorElementNotFound, p)
+		}
+		if i == len(p)-1 {
+			return elem, nil
+		}
+
+		items := itemsOf(elem)
+		if step.Item >= len(items) {
+			return nil, fmt.Errorf("%w: %s", ErrorElementNotFound, p)
+		}
+		elems = items[step.Item]
+	}
+	return nil, fmt.Errorf("%w: %s", ErrorElementNotFound, p)
+}
+
+// parseTag parses a tag written as "(gggg,eeee)", "gggg,eeee" or "ggggeeee".
+func parseTag(s string) (tag.Tag, error) {
+	s = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "("), ")")
+	s = strings.ReplaceAll(s, ",", "")
+	if len(s) != 8 {
+		return tag.Tag{}, fmt.Errorf("malformed tag %q", s)
+	}
+	value, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return tag.Tag{}, fmt.Errorf("malformed tag %q", s)
+	}
+	return tag.Tag{Group: uint16(value >> 16), Element: uint16(value)}, nil
+}
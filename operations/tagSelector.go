@@ -0,0 +1,128 @@
+// tagSelector.go provides TagSelector, a pattern matching a set of tags.
+//
+// Selectors are written as keywords, e.g. "InstitutionName", as tag numbers, e.g. "(0008,0080)",
+// or as tag numbers with wildcard digits, e.g. "(60xx,3000)" for the overlay data of all overlay
+// planes. "*" selects every tag.
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// ErrorInvalidTagSelector is returned when a tag selector cannot be parsed.
+var ErrorInvalidTagSelector = errors.New("invalid tag selector")
+
+// TagSelector matches all tags that equal Tag in the bits set in Mask.
+type TagSelector struct {
+	// Tag is the selected tag, encoded as group << 16 | element.
+	Tag uint32
+	// Mask selects the bits of a tag that have to equal Tag.
+	Mask uint32
+}
+
+// ParseTagSelector parses a single selector.
+func ParseTagSelector(s string) (TagSelector, error) {
+	s = strings.TrimSpace(s)
+	if s == "*" {
+		return TagSelector{}, nil
+	}
+	if info, err := tag.FindByName(s); err == nil {
+		return TagSelector{Tag: uint32(info.Tag.Group)<<16 | uint32(info.Tag.Element), Mask: 0xFFFFFFFF}, nil
+	}
+
+	digits := strings.ReplaceAll(strings.TrimSuffix(strings.TrimPrefix(s, "("), ")"), ",", "")
+	if len(digits) != 8 {
+		return TagSelector{}, fmt.Errorf("%w: %q, expected a keyword, (gggg,eeee) or *", ErrorInvalidTagSelector, s)
+	}
+
+	var selector TagSelector
+	for _, digit := range digits {
+		selector.Tag <<= 4
+		selector.Mask <<= 4
+		if digit == 'x' || digit == 'X' {
+			continue
+		}
+		value, err := strconv.ParseUint(string(digit), 16, 4)
+		if err != nil {
+			return TagSelector{}, fmt.Errorf("%w: %q, %q is neither a hex digit nor x", ErrorInvalidTagSelector, s, digit)
+		}
+		selector.Tag |= uint32(value)
+		selector.Mask |= 0xF
+	}
+	return selector, nil
+}
+
+// ParseTagSelectors parses a comma separated list of selectors. Commas within parentheses belong to
+// tag numbers, e.g. "(0008,0080),StationName".
+func ParseTagSelectors(spec string) ([]TagSelector, error) {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range spec {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, spec[start:])
+
+	var selectors []TagSelector
+	for _, s := range parts {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		selector, err := ParseTagSelector(s)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+	}
+	if len(selectors) == 0 {
+		return nil, fmt.Errorf("%w: no tags selected", ErrorInvalidTagSelector)
+	}
+	return selectors, nil
+}
+
+// Matches reports whether t is selected.
+func (s TagSelector) Matches(t tag.Tag) bool {
+	return (uint32(t.Group)<<16|uint32(t.Element))&s.Mask == s.Tag&s.Mask
+}
+
+// String formats the selector as "(gggg,eeee)" with an x for every wildcard digit.
+func (s TagSelector) String() string {
+	if s.Mask == 0 {
+		return "*"
+	}
+
+	digits := make([]byte, 8)
+	for i := range digits {
+		shift := uint(28 - 4*i)
+		if (s.Mask>>shift)&0xF == 0 {
+			digits[i] = 'x'
+		} else {
+			digits[i] = "0123456789ABCDEF"[(s.Tag>>shift)&0xF]
+		}
+	}
+	return fmt.Sprintf("(%s,%s)", digits[:4], digits[4:])
+}
+
+// matchesAny reports whether t is selected by any of selectors.
+func matchesAny(selectors []TagSelector, t tag.Tag) bool {
+	for _, selector := range selectors {
+		if selector.Matches(t) {
+			return true
+		}
+	}
+	return false
+}
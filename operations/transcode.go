@@ -0,0 +1,247 @@
+// transcode.go implements the conversion of files between the native transfer syntaxes, i.e.
+// Implicit VR Little Endian, Explicit VR Little Endian, Explicit VR Big Endian and Deflated
+// Explicit VR Little Endian, and RLE Lossless.
+//
+// The DICOM library encodes elements using the transfer syntax stored in the File Meta
+// Information, so most of the conversion happens when the dataset is written. Native pixel data is
+// kept as raw bytes and byte swapped here when the byte order changes, OW elements are held as
+// little endian words and swapped when written, see encodeDataset. RLE Lossless pixel data is
+// decoded to Explicit VR Little Endian first and native pixel data is encoded from it, see
+// DecompressRLE and CompressRLE. Other encapsulated (compressed) pixel data cannot be transcoded.
+package operations
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+	"github.com/suyashkumar/dicom/pkg/uid"
+)
+
+// ErrorUnsupportedTransferSyntax is returned when a file is transcoded from or to a transfer
+// syntax other than the native ones.
+var ErrorUnsupportedTransferSyntax = errors.New("unsupported transfer syntax")
+
+// transferSyntaxNames maps the names accepted by ParseTransferSyntax to their UIDs.
+var transferSyntaxNames = map[string]string{
+	"implicit": uid.ImplicitVRLittleEndian,
+	"explicit": uid.ExplicitVRLittleEndian,
+	"big":      uid.ExplicitVRBigEndian,
+	"deflated": uid.DeflatedExplicitVRLittleEndian,
+	"rle":      RLELosslessTransferSyntax,
+}
+
+// ParseTransferSyntax parses the target of a transcode, given as "implicit", "explicit", "big",
+// "deflated" or "rle" or as one of the corresponding UIDs.
+func ParseTransferSyntax(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if ts, ok := transferSyntaxNames[strings.ToLower(name)]; ok {
+		return ts, nil
+	}
+	for _, ts := range transferSyntaxNames {
+		if name == ts {
+			return ts, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q, expected implicit, explicit, big, deflated or rle", ErrorUnsupportedTransferSyntax, name)
+}
+
+// TransferSyntaxName returns the name of the transfer syntax ts, e.g. "Explicit VR Big Endian",
+// or ts itself if it is unknown.
+func TransferSyntaxName(ts string) string {
+	if info, err := uid.Lookup(ts); err == nil {
+		return info.Name
+	}
+	return ts
+}
+
+// TranscodeChange returns the change converting ds, the dataset of the file at path, to the
+// transfer syntax target, or nil if the file already uses it.
+func TranscodeChange(path string, ds dicom.Dataset, target string) (*ElementChange, error) {
+	source := transferSyntaxOf(ds)
+	if source == target {
+		return nil, nil
+	}
+	if err := checkTranscode(ds, source, target); err != nil {
+		return nil, err
+	}
+	return &ElementChange{Kind: ChangeTranscode, File: path, Path: NewTagPath(tag.TransferSyntaxUID), Old: source, New: target}, nil
+}
+
+// Transcode converts the DICOM file at path to the transfer syntax target and saves it. Files that
+// already use target are left untouched and false is returned.
+//
+// The previous version of the file is kept as a backup, see ReplaceFile.
+func Transcode(path string, target string) (bool, error) {
+	ds, err := parseFile(path, dicom.SkipPixelData())
+	if err != nil {
+		return false, err
+	}
+	change, err := TranscodeChange(path, ds, target)
+	if err != nil || change == nil {
+		return false, err
+	}
+	return true, applyFileChanges(path, []*ElementChange{change}, false, true)
+}
+
+// checkTranscode returns an error if ds cannot be converted from source to target.
+func checkTranscode(ds dicom.Dataset, source string, target string) error {
+	for _, ts := range []string{source, target} {
+		if !isNativeTransferSyntax(ts) && ts != RLELosslessTransferSyntax {
+			return fmt.Errorf("%w: %s is neither a native transfer syntax nor RLE Lossless", ErrorUnsupportedTransferSyntax, TransferSyntaxName(ts))
+		}
+	}
+	elem, err := ds.FindElementByTag(tag.PixelData)
+	if err != nil {
+		return nil
+	}
+	if dicom.MustGetPixelDataInfo(elem.Value).IsEncapsulated && source != RLELosslessTransferSyntax {
+		return fmt.Errorf("%w: pixel data is encapsulated", ErrorUnsupportedPixelData)
+	}
+	if target == RLELosslessTransferSyntax {
+		info, err := ReadImageInfo(ds)
+		if err != nil {
+			return err
+		}
+		if info.BitsAllocated%8 != 0 || max(info.BitsAllocated/8, 1)*info.SamplesPerPixel > rleMaxSegments {
+			return fmt.Errorf("%w: %d bits allocated with %d samples per pixel cannot be RLE encoded", ErrorUnsupportedPixelData, info.BitsAllocated, info.SamplesPerPixel)
+		}
+	}
+	return nil
+}
+
+// isNativeTransferSyntax reports whether ts stores pixel data uncompressed. The deflated transfer
+// syntax is included, since only the encoded data set is compressed.
+func isNativeTransferSyntax(ts string) bool {
+	for _, native := range uid.StandardTransferSyntaxes {
+		if ts == native {
+			return true
+		}
+	}
+	return false
+}
+
+// transcodeDataset converts ds to the transfer syntax target and returns the transfer syntax it
+// was encoded in before.
+//
+// Pixel data is only converted if ds has been parsed with dicom.SkipProcessingPixelDataValue.
+// Conversions from and to RLE Lossless go through Explicit VR Little Endian. Datasets parsed
+// without their pixel data, e.g. to stage the change, only get the attributes the conversion
+// changes, and the pixel data is converted when the file is written.
+func transcodeDataset(ds *dicom.Dataset, target string) (string, error) {
+	source := transferSyntaxOf(*ds)
+	if source == target {
+		return source, nil
+	}
+	if err := checkTranscode(*ds, source, target); err != nil {
+		return source, err
+	}
+	if elem, err := ds.FindElementByTag(tag.PixelData); err == nil && dicom.MustGetPixelDataInfo(elem.Value).IntentionallySkipped {
+		return source, transcodeAttributes(ds, target)
+	}
+
+	if source == RLELosslessTransferSyntax {
+		if err := DecompressRLE(ds); err != nil {
+			return source, err
+		}
+	}
+	native := target
+	if target == RLELosslessTransferSyntax {
+		native = uid.ExplicitVRLittleEndian
+	}
+	if err := transcodeNative(ds, native); err != nil {
+		return source, err
+	}
+	if target == RLELosslessTransferSyntax {
+		return source, CompressRLE(ds)
+	}
+	return source, nil
+}
+
+// transcodeAttributes changes the attributes of ds, whose pixel data has not been read, the way
+// converting it to target does.
+func transcodeAttributes(ds *dicom.Dataset, target string) error {
+	source := transferSyntaxOf(*ds)
+	if info, err := ReadImageInfo(*ds); err == nil && info.SamplesPerPixel > 1 && (source == RLELosslessTransferSyntax || target == RLELosslessTransferSyntax) {
+		// See DecompressRLE and CompressRLE.
+		if err := setIntElement(ds, tag.PlanarConfiguration, 0); err != nil {
+			return err
+		}
+		if target == RLELosslessTransferSyntax && isSubsampled(info) {
+			if err := setStringElement(ds, tag.PhotometricInterpretation, "YBR_FULL"); err != nil {
+				return err
+			}
+		}
+	}
+	return setStringElement(ds, tag.TransferSyntaxUID, target)
+}
+
+// transcodeNative converts ds from one native transfer syntax to another.
+func transcodeNative(ds *dicom.Dataset, target string) error {
+	from, to := byteOrderOf(*ds), binary.ByteOrder(binary.LittleEndian)
+	if target == uid.ExplicitVRBigEndian {
+		to = binary.BigEndian
+	}
+	if from != to {
+		if err := swapRawValues(ds.Elements, 0); err != nil {
+			return err
+		}
+	}
+	if target == uid.DeflatedExplicitVRLittleEndian {
+		return SetDeflated(ds)
+	}
+	return setStringElement(ds, tag.TransferSyntaxUID, target)
+}
+
+// swapRawValues swaps the byte order of the native pixel data in elems, including the pixel data
+// of icons nested in sequences, in units of its Bits Allocated. bitsAllocated is used for pixel
+// data whose dataset lacks Bits Allocated.
+func swapRawValues(elems []*dicom.Element, bitsAllocated int) error {
+	if bits, err := intAttribute(dicom.Dataset{Elements: elems}, tag.BitsAllocated); err == nil {
+		bitsAllocated = bits
+	}
+	for _, elem := range elems {
+		switch {
+		case elem.Value == nil:
+		case elem.Value.ValueType() == dicom.Sequences:
+			for _, item := range itemsOf(elem) {
+				if err := swapRawValues(item, bitsAllocated); err != nil {
+					return err
+				}
+			}
+		case elem.Tag == tag.PixelData:
+			info := dicom.MustGetPixelDataInfo(elem.Value)
+			if !info.IntentionallyUnprocessed {
+				continue
+			}
+			if bitsAllocated <= 8 {
+				// Byte samples are not affected by the byte order, unless they are stored as words.
+				elem.RawValueRepresentation = "OB"
+				continue
+			}
+			info.UnprocessedValueData = swapBytes(info.UnprocessedValueData, bitsAllocated/8)
+			value, err := dicom.NewValue(info)
+			if err != nil {
+				return err
+			}
+			elem.Value = value
+		}
+	}
+	return nil
+}
+
+// swapBytes returns a copy of data with the byte order of every unit of size bytes reversed.
+// Trailing bytes that do not form a complete unit are copied as they are.
+func swapBytes(data []byte, size int) []byte {
+	swapped := append([]byte(nil), data...)
+	for start := 0; start+size <= len(swapped); start += size {
+		unit := swapped[start : start+size]
+		for i, j := 0, size-1; i < j; i, j = i+1, j-1 {
+			unit[i], unit[j] = unit[j], unit[i]
+		}
+	}
+	return swapped
+}
@@ -0,0 +1,89 @@
+package operations
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/uid"
+)
+
+func TestTranscodeKeepsPixelData(t *testing.T) {
+	path, _ := writeTestFile(t, uid.ExplicitVRLittleEndian)
+	targets := []string{
+		RLELosslessTransferSyntax,
+		uid.ExplicitVRBigEndian,
+		RLELosslessTransferSyntax,
+		uid.DeflatedExplicitVRLittleEndian,
+		uid.ImplicitVRLittleEndian,
+	}
+	for _, target := range targets {
+		changed, err := Transcode(path, target)
+		if err != nil {
+			t.Fatalf("%s: %v", TransferSyntaxName(target), err)
+		}
+		if !changed {
+			t.Fatalf("%s: file was not changed", TransferSyntaxName(target))
+		}
+
+		ds, err := parseFile(path, dicom.SkipProcessingPixelDataValue())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ts := transferSyntaxOf(ds); ts != target {
+			t.Fatalf("transfer syntax is %s instead of %s", TransferSyntaxName(ts), TransferSyntaxName(target))
+		}
+		pixels, err := DecodePixelData(path, ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(pixels.Frames[0], []int{0, 1000, 2000, 4095}) {
+			t.Errorf("%s: pixel data is %v", TransferSyntaxName(target), pixels.Frames[0])
+		}
+	}
+}
+
+func TestStageTranscodeWithoutPixelData(t *testing.T) {
+	path, _ := writeTestFile(t, uid.ExplicitVRLittleEndian)
+	t.Setenv(JournalDirEnv, filepath.Join(t.TempDir(), "journals"))
+	history, err := OpenHistory(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []string{RLELosslessTransferSyntax, uid.ExplicitVRLittleEndian} {
+		// The file tree parses its datasets without pixel data.
+		ds, err := parseFile(path, dicom.SkipPixelData())
+		if err != nil {
+			t.Fatal(err)
+		}
+		change, err := TranscodeChange(path, ds, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var staging Staging
+		if err := staging.Stage(&ds, *change); err != nil {
+			t.Fatalf("%s: %v", TransferSyntaxName(target), err)
+		}
+		if ts := transferSyntaxOf(ds); ts != target {
+			t.Fatalf("staged transfer syntax is %s instead of %s", TransferSyntaxName(ts), TransferSyntaxName(target))
+		}
+		if _, err := staging.Commit(history, nil); err != nil {
+			t.Fatalf("%s: %v", TransferSyntaxName(target), err)
+		}
+
+		ds, err = parseFile(path, dicom.SkipProcessingPixelDataValue())
+		if err != nil {
+			t.Fatal(err)
+		}
+		pixels, err := DecodePixelData(path, ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(pixels.Frames[0], []int{0, 1000, 2000, 4095}) {
+			t.Errorf("%s: pixel data is %v", TransferSyntaxName(target), pixels.Frames[0])
+		}
+	}
+}
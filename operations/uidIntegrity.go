@@ -0,0 +1,351 @@
+// uidIntegrity.go implements the detection and repair of UIDs reused by unrelated data.
+//
+// Archives reject or silently merge data whose UIDs collide, e.g. when a modality restarts its UID
+// counter or a study is copied and edited without new UIDs. The check covers the hierarchy of
+// patients, studies, series and instances:
+//
+//   - a Study Instance UID shared by files of different patients,
+//   - a Series Instance UID shared by files of different studies,
+//   - a SOP Instance UID shared by files of different content.
+//
+// Files are compared by their parsed datasets and a digest of their pixel data. Two files with the
+// same SOP Instance UID are copies of the same instance if all their attributes apart from the File
+// Meta Information are equal and their pixel data holds the same samples, so duplicates that merely
+// exist twice are not reported. The pixel data is only read from files sharing their SOP Instance
+// UID. Files lacking the Patient ID or Study Instance UID the files of a study or series are told
+// apart by are not considered conflicting.
+//
+// The fix keeps the UID for the largest group of files sharing it and gives every other group a UID
+// of its own, created below UIDRoot. The UID is replaced wherever it occurs within the files of the
+// group, including the File Meta Information and references nested in sequences. Files moved to a
+// new study get new Series Instance UIDs as well, so their series do not span two studies.
+// References to the UID from files outside the group are ambiguous and are left unchanged.
+package operations
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"slices"
+	"strings"
+
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// UIDLevel is the level of the DICOM information model a UID identifies.
+type UIDLevel string
+
+const (
+	// UIDLevelStudy identifies studies by their Study Instance UID.
+	UIDLevelStudy UIDLevel = "study"
+	// UIDLevelSeries identifies series by their Series Instance UID.
+	UIDLevelSeries UIDLevel = "series"
+	// UIDLevelInstance identifies instances by their SOP Instance UID.
+	UIDLevelInstance UIDLevel = "instance"
+)
+
+// uidLevels are the levels in the order of the hierarchy.
+var uidLevels = []UIDLevel{UIDLevelStudy, UIDLevelSeries, UIDLevelInstance}
+
+// UIDConflict is a UID shared by files that do not belong together.
+type UIDConflict struct {
+	// Level is the level the UID identifies.
+	Level UIDLevel
+	// UID is the shared UID.
+	UID string
+	// Groups partition the files sharing the UID into files that belong together, e.g. the files
+	// of the same patient for a study. The first group keeps the UID when the conflict is fixed.
+	Groups []UIDConflictGroup
+}
+
+// UIDConflictGroup is a set of files sharing a UID that belong together.
+type UIDConflictGroup struct {
+	// Owner describes what the files have in common, e.g. `patient "P1"`.
+	Owner string
+	// Files are the paths of the files.
+	Files []string
+}
+
+// UIDReport is the result of checking files for conflicting UIDs.
+type UIDReport struct {
+	// Files is the number of checked files.
+	Files int
+	// Conflicts are the conflicts in the order of the levels and their UIDs.
+	Conflicts []UIDConflict
+}
+
+// Len returns the number of conflicts at level.
+func (r UIDReport) Len(level UIDLevel) int {
+	n := 0
+	for _, conflict := range r.Conflicts {
+		if conflict.Level == level {
+			n++
+		}
+	}
+	return n
+}
+
+// uidIdentity holds the UIDs of a file and what they are told apart by.
+type uidIdentity struct {
+	path string
+	// uids and owners hold the UID of the file and the owner of the UID for every level.
+	uids   map[UIDLevel]string
+	owners map[UIDLevel]string
+	// digest is the content digest of the file, see contentDigest. It is only computed for files
+	// sharing their SOP Instance UID, whose instance owner shows its start.
+	digest string
+}
+
+// CheckUIDs checks the parsed datasets of files for conflicting UIDs. Files sharing their SOP
+// Instance UID are compared by content concurrently, the progress of which is reported to progress.
+func CheckUIDs(files []*ParsedDicomFile, progress Progress) UIDReport {
+	identities := make([]uidIdentity, len(files))
+	shared := map[string]int{}
+	for i, file := range files {
+		identities[i] = identify(file.Path, file.Dataset)
+		if uid := identities[i].uids[UIDLevelInstance]; uid != "" {
+			shared[uid]++
+		}
+	}
+
+	// Reading the pixel data is slow, so files with a SOP Instance UID of their own are skipped.
+	var compared []int
+	for i, id := range identities {
+		if shared[id.uids[UIDLevelInstance]] > 1 {
+			compared = append(compared, i)
+		}
+	}
+	forEachFile(len(compared), progress, func(j int) {
+		id := &identities[compared[j]]
+		digest := contentDigest(id.path, files[compared[j]].Dataset.Elements)
+		id.digest = string(digest)
+		id.owners[UIDLevelInstance] = fmt.Sprintf("content %x", digest[:4])
+	})
+
+	report := UIDReport{Files: len(files)}
+	for _, level := range uidLevels {
+		report.Conflicts = append(report.Conflicts, conflicts(level, identities)...)
+	}
+	return report
+}
+
+// identify returns the UIDs of ds, the dataset of the file at path. The owner of the SOP Instance
+// UID is left to CheckUIDs.
+func identify(path string, ds dicom.Dataset) uidIdentity {
+	elems := ds.Elements
+	id := uidIdentity{
+		path: path,
+		uids: map[UIDLevel]string{
+			UIDLevelStudy:    elementString(elems, tag.StudyInstanceUID),
+			UIDLevelSeries:   elementString(elems, tag.SeriesInstanceUID),
+			UIDLevelInstance: elementString(elems, tag.SOPInstanceUID),
+		},
+		owners: map[UIDLevel]string{},
+	}
+	if patient := elementString(elems, tag.PatientID); patient != "" {
+		id.owners[UIDLevelStudy] = fmt.Sprintf("patient %q", patient)
+	}
+	if study := id.uids[UIDLevelStudy]; study != "" {
+		id.owners[UIDLevelSeries] = "study " + study
+	}
+	return id
+}
+
+// conflicts returns the UIDs of level shared by files of more than one owner, ordered by UID.
+func conflicts(level UIDLevel, identities []uidIdentity) []UIDConflict {
+	groups := map[string][]UIDConflictGroup{}
+	// indices maps a UID and the key of an owner to the index of its group.
+	indices := map[[2]string]int{}
+	for _, id := range identities {
+		uid, owner := id.uids[level], id.owners[level]
+		if uid == "" || owner == "" {
+			continue
+		}
+		key := owner
+		if level == UIDLevelInstance {
+			// The owner only shows the start of the digest, files are grouped by all of it.
+			key = id.digest
+		}
+		i, ok := indices[[2]string{uid, key}]
+		if !ok {
+			groups[uid] = append(groups[uid], UIDConflictGroup{Owner: owner})
+			i = len(groups[uid]) - 1
+			indices[[2]string{uid, key}] = i
+		}
+		groups[uid][i].Files = append(groups[uid][i].Files, id.path)
+	}
+
+	var result []UIDConflict
+	for uid, owners := range groups {
+		if len(owners) < 2 {
+			continue
+		}
+		// The largest group keeps the UID, ties are decided by the order of the files.
+		slices.SortStableFunc(owners, func(a, b UIDConflictGroup) int { return cmp.Compare(len(b.Files), len(a.Files)) })
+		result = append(result, UIDConflict{Level: level, UID: uid, Groups: owners})
+	}
+	slices.SortFunc(result, func(a, b UIDConflict) int { return cmp.Compare(a.UID, b.UID) })
+	return result
+}
+
+// contentDigest returns a hash of elems, the elements of the file at path, apart from the File Meta
+// Information, including the elements nested in sequences and the pixel data of the file.
+func contentDigest(path string, elems []*dicom.Element) []byte {
+	h := sha256.New()
+	digestElements(h, elems, true)
+	if err := digestPixelData(h, path); err != nil {
+		// The error is part of the digest, so a file whose pixel data is unreadable is never taken
+		// for a copy of a readable one.
+		fmt.Fprintf(h, "pixel data: %v", err)
+	}
+	return h.Sum(nil)
+}
+
+// digestPixelData writes the pixel data of the file at path to h. The datasets of the checked
+// files are parsed without their pixel data, so it is read here, one file at a time.
+//
+// Native pixel data is written in little endian and RLE Lossless pixel data is decoded first, so a
+// file and its transcoded copy match. Other encapsulated pixel data is written fragment by fragment.
+func digestPixelData(h hash.Hash, path string) error {
+	ds, err := parseFile(path, dicom.SkipProcessingPixelDataValue())
+	if err != nil {
+		return err
+	}
+	if transferSyntaxOf(ds) == RLELosslessTransferSyntax {
+		if err := DecompressRLE(&ds); err != nil {
+			return err
+		}
+	}
+	elem, err := ds.FindElementByTag(tag.PixelData)
+	if err != nil {
+		return nil
+	}
+
+	pixelInfo := dicom.MustGetPixelDataInfo(elem.Value)
+	h.Write([]byte{0xfd})
+	if pixelInfo.IsEncapsulated {
+		for _, frame := range pixelInfo.Frames {
+			binary.Write(h, binary.LittleEndian, uint32(len(frame.EncapsulatedData.Data)))
+			h.Write(frame.EncapsulatedData.Data)
+		}
+		return nil
+	}
+	data := pixelInfo.UnprocessedValueData
+	if bits, err := intAttribute(ds, tag.BitsAllocated); err == nil && bits > 8 && byteOrderOf(ds) == binary.BigEndian {
+		data = swapBytes(data, bits/8)
+	}
+	binary.Write(h, binary.LittleEndian, uint32(len(data)))
+	h.Write(data)
+	return nil
+}
+
+// digestElements writes the tags and values of elems to h. VRs are left out, so copies in Implicit
+// VR transfer syntaxes match. topLevel reports whether elems are the elements of a dataset rather
+// than of a sequence item.
+func digestElements(h hash.Hash, elems []*dicom.Element, topLevel bool) {
+	for _, elem := range elems {
+		if topLevel && (elem.Tag.Group == tag.MetadataGroup || elem.Tag == tag.PixelData) {
+			continue
+		}
+		binary.Write(h, binary.LittleEndian, [2]uint16{elem.Tag.Group, elem.Tag.Element})
+		if elem.Value != nil && elem.Value.ValueType() == dicom.Sequences {
+			for _, item := range itemsOf(elem) {
+				h.Write([]byte{0xfe})
+				digestElements(h, item, false)
+			}
+			h.Write([]byte{0xff})
+			continue
+		}
+		value := digestValue(elem)
+		binary.Write(h, binary.LittleEndian, uint32(len(value)))
+		h.Write(value)
+	}
+}
+
+// digestValue returns the value of elem without padding. Text is compared with binary values as
+// bytes, since elements unknown to the library are read as text or bytes depending on the transfer
+// syntax.
+func digestValue(elem *dicom.Element) []byte {
+	if elem.Value == nil {
+		return nil
+	}
+	if data, ok := elem.Value.GetValue().([]byte); ok {
+		return bytes.TrimRight(data, " \x00")
+	}
+	return []byte(strings.TrimRight(ElementText(elem), " \x00"))
+}
+
+// FixChanges returns the changes giving every group of files but the first of each conflict a UID
+// of its own, created below UIDRoot. Files moved to a new study get new Series Instance UIDs as
+// well. files must hold the parsed datasets of the files of the report. The changes are ordered by
+// file.
+func (r UIDReport) FixChanges(files []*ParsedDicomFile) ([]*ElementChange, error) {
+	root, err := UIDRoot()
+	if err != nil {
+		return nil, err
+	}
+	remapper, err := NewUIDRemapper(root, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	datasets := map[string]dicom.Dataset{}
+	for _, file := range files {
+		datasets[file.Path] = file.Dataset
+	}
+	replacements := map[string]map[string]string{}
+	replace := func(path, uid, replacement string) {
+		if replacements[path] == nil {
+			replacements[path] = map[string]string{}
+		}
+		replacements[path][uid] = replacement
+	}
+	// Conflicts are fixed from the bottom of the hierarchy up, so the new series of a study
+	// prevail over the fix of a series conflict between the study and the rest of its patient.
+	for _, conflict := range slices.Backward(r.Conflicts) {
+		for i, group := range conflict.Groups[1:] {
+			// The level and the group are part of the hashed value, so every group gets a
+			// different UID, even if the UID is used at several levels.
+			key := fmt.Sprintf("%s/%s/%d", conflict.Level, conflict.UID, i)
+			for _, path := range group.Files {
+				replace(path, conflict.UID, remapper.UID(key))
+				if conflict.Level != UIDLevelStudy {
+					continue
+				}
+				// The series of a new study are new series as well.
+				if series := elementString(datasets[path].Elements, tag.SeriesInstanceUID); series != "" {
+					replace(path, series, remapper.UID(key+"/"+series))
+				}
+			}
+		}
+	}
+
+	var changes []*ElementChange
+	errs := tyroErrors.New()
+	for _, file := range files {
+		fileReplacements, ok := replacements[file.Path]
+		if !ok {
+			continue
+		}
+		fileChanges, err := uidChanges(file.Path, file.Dataset, func(uid string) string {
+			if replacement, ok := fileReplacements[uid]; ok {
+				return replacement
+			}
+			return uid
+		})
+		if err != nil {
+			errs.Add(fmt.Errorf("%s: %w", file.Path, err))
+			continue
+		}
+		changes = append(changes, fileChanges...)
+	}
+	if errs.HasErrors() {
+		return changes, errs
+	}
+	return changes, nil
+}
@@ -0,0 +1,63 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/uid"
+)
+
+// parsedTestFile writes data to name in dir and parses it the way the file tree does.
+func parsedTestFile(t *testing.T, dir, name string, data []byte) *ParsedDicomFile {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := parseFile(path, dicom.SkipPixelData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &ParsedDicomFile{Path: path, Dataset: ds}
+}
+
+func TestCheckUIDsComparesPixelData(t *testing.T) {
+	original, data := writeTestFile(t, uid.ExplicitVRLittleEndian)
+	dir := filepath.Dir(original)
+	files := []*ParsedDicomFile{parsedTestFile(t, dir, "original.dcm", data)}
+
+	// Copies in other transfer syntaxes hold the same samples.
+	for _, target := range []string{uid.ExplicitVRBigEndian, RLELosslessTransferSyntax} {
+		if _, err := Transcode(original, target); err != nil {
+			t.Fatal(err)
+		}
+		copied, err := os.ReadFile(original)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, parsedTestFile(t, dir, TransferSyntaxName(target)+".dcm", copied))
+	}
+	if report := CheckUIDs(files, nil); report.Len(UIDLevelInstance) != 0 {
+		t.Fatalf("transcoded copies reported as conflicts: %+v", report.Conflicts)
+	}
+
+	// The last sample is stored in the last two bytes of the file.
+	changed := append([]byte(nil), data...)
+	changed[len(changed)-1] ^= 0x01
+	files = append(files, parsedTestFile(t, dir, "changed.dcm", changed))
+	report := CheckUIDs(files, nil)
+	if report.Len(UIDLevelInstance) != 1 {
+		t.Fatalf("%d instance conflicts instead of 1", report.Len(UIDLevelInstance))
+	}
+	changes, err := report.FixChanges(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range changes {
+		if change.File != files[len(files)-1].Path {
+			t.Errorf("%s changed instead of the file with other pixel data", change.File)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// uidRemapper.go implements the deterministic replacement of UIDs.
+//
+// A UIDRemapper derives every new UID from a keyed hash of the original UID, so the same original
+// UID is replaced by the same new UID in every file of an operation and references between the
+// files, e.g. a Referenced SOP Instance UID in a Source Image Sequence or an RT plan referencing a
+// structure set, keep pointing at the right instances. Without the key the new UIDs cannot be
+// traced back to the original ones. A remapper sharing the key of an earlier operation reproduces
+// its UIDs.
+//
+// New UIDs are created below the root 2.25, which turns the hash into a UUID-derived UID, or below
+// the organisation root configured with the TYRO_UID_ROOT environment variable.
+//
+// UIDs of the DICOM standard, which start with 1.2.840.10008, are never replaced, and neither are
+// the values of attributes identifying classes, transfer syntaxes or coding resources rather than
+// instances, e.g. the SOP Class UID.
+package operations
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// UIDRootEnv is the environment variable holding the organisation root new UIDs are created below.
+const UIDRootEnv = "TYRO_UID_ROOT"
+
+// defaultUIDRoot is the root of UIDs derived from a UUID, see PS3.5 B.2.
+const defaultUIDRoot = "2.25"
+
+// standardUIDRoot is the root of the UIDs defined by the DICOM standard.
+const standardUIDRoot = "1.2.840.10008."
+
+// maxUIDLength is the maximum length of a UID.
+const maxUIDLength = 64
+
+// maxUIDRootLength is the maximum length of an organisation root. It leaves at least 19 digits,
+// i.e. more than 63 bits of the hash, to the part of the UID derived from the original.
+const maxUIDRootLength = maxUIDLength - 1 - 19
+
+// uidDigits is the number of decimal digits of the 128 bit number UIDs are derived from.
+const uidDigits = 39
+
+// ErrorInvalidUIDRoot is returned when the configured UID root is not a valid UID prefix.
+var ErrorInvalidUIDRoot = errors.New("invalid UID root")
+
+// uidRootPattern matches valid UIDs and UID roots: numeric components without leading zeros.
+var uidRootPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)(\.(0|[1-9][0-9]*))*$`)
+
+// unmappedUIDs are the UI attributes whose values identify classes, transfer syntaxes, coding
+// resources or their creators rather than instances. They are not replaced by RemapChanges or by
+// the UI attributes de-identification replaces beyond the action table.
+var unmappedUIDs = map[tag.Tag]bool{
+	tag.AffectedSOPClassUID:               true,
+	tag.RequestedSOPClassUID:              true,
+	tag.MediaStorageSOPClassUID:           true,
+	tag.TransferSyntaxUID:                 true,
+	tag.ImplementationClassUID:            true,
+	tag.PrivateInformationCreatorUID:      true,
+	tag.ReferencedSOPClassUIDInFile:       true,
+	tag.ReferencedTransferSyntaxUIDInFile: true,
+	tag.InstanceCreatorUID:                true,
+	tag.SOPClassUID:                       true,
+	tag.RelatedGeneralSOPClassUID:         true,
+	tag.OriginalSpecializedSOPClassUID:    true,
+	tag.SOPClassesInStudy:                 true,
+	tag.CodingSchemeUID:                   true,
+	tag.ContextGroupExtensionCreatorUID:   true,
+	{Group: 0x0008, Element: 0x0118}:      true, // MappingResourceUID
+	tag.ReferencedSOPClassUID:             true,
+	tag.SOPClassesSupported:               true,
+	tag.MACCalculationTransferSyntaxUID:   true,
+	tag.EncryptedContentTransferSyntaxUID: true,
+	tag.RetrieveLocationUID:               true,
+}
+
+// UIDRemapper replaces UIDs with UIDs derived from them. It is safe for concurrent use.
+type UIDRemapper struct {
+	root string
+	// key is mixed into the hash new UIDs are derived from.
+	key []byte
+}
+
+// UIDRoot returns the root new UIDs are created below, the value of UIDRootEnv or 2.25.
+func UIDRoot() (string, error) {
+	root := strings.TrimSuffix(strings.TrimSpace(os.Getenv(UIDRootEnv)), ".")
+	if root == "" {
+		return defaultUIDRoot, nil
+	}
+	if !uidRootPattern.MatchString(root) || len(root) > maxUIDRootLength {
+		return "", fmt.Errorf("%w %q in %s, expected a UID of at most %d characters", ErrorInvalidUIDRoot, root, UIDRootEnv, maxUIDRootLength)
+	}
+	return root, nil
+}
+
+// NewUIDRemapper creates a UIDRemapper creating UIDs below root. A nil key creates a remapper with
+// a random key, whose UIDs differ from those of every other remapper.
+func NewUIDRemapper(root string, key []byte) (*UIDRemapper, error) {
+	if !uidRootPattern.MatchString(root) || len(root) > maxUIDRootLength {
+		return nil, fmt.Errorf("%w %q", ErrorInvalidUIDRoot, root)
+	}
+	if key == nil {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	return &UIDRemapper{root: root, key: key}, nil
+}
+
+// Root returns the root new UIDs are created below.
+func (r *UIDRemapper) Root() string {
+	return r.root
+}
+
+// UID returns the UID replacing original. UIDs of the DICOM standard are returned unchanged.
+func (r *UIDRemapper) UID(original string) string {
+	if strings.HasPrefix(original, standardUIDRoot) {
+		return original
+	}
+	hash := sha256.Sum256(append(append([]byte(nil), r.key...), original...))
+	n := new(big.Int).SetBytes(hash[:16])
+	if digits := maxUIDLength - len(r.root) - 1; digits < uidDigits {
+		n.Mod(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil))
+	}
+	return r.root + "." + n.String()
+}
+
+// Remap returns the value of a UI element with each of its UIDs replaced using UID.
+func (r *UIDRemapper) Remap(value string) string {
+	return replaceUIDs(value, r.UID)
+}
+
+// replaceUIDs returns the value of a UI element with each of its UIDs replaced by replace.
+func replaceUIDs(value string, replace func(uid string) string) string {
+	uids := strings.Split(value, "\\")
+	for i, uid := range uids {
+		if uid = strings.TrimRight(uid, " \x00"); uid != "" {
+			uids[i] = replace(uid)
+		}
+	}
+	return strings.Join(uids, "\\")
+}
+
+// remapsElement returns whether elem holds UIDs of instances that are replaced.
+func remapsElement(elem *dicom.Element) bool {
+	return elem.RawValueRepresentation == "UI" && !unmappedUIDs[elem.Tag] && IsEditable(elem)
+}
+
+// RemapChanges returns the changes replacing the UIDs of ds, the dataset of the file at path. All
+// UI elements are replaced, including those of the File Meta Information and those nested in
+// sequences, except for the values of unmappedUIDs. The changes are redacting, see
+// ElementChange.Redact.
+func (r *UIDRemapper) RemapChanges(path string, ds dicom.Dataset) ([]*ElementChange, error) {
+	changes, err := uidChanges(path, ds, r.UID)
+	return redact(changes), err
+}
+
+// uidChanges returns the changes replacing each UID of the UI elements of ds, the dataset of the
+// file at path, by replace. The values of unmappedUIDs are kept.
+func uidChanges(path string, ds dicom.Dataset, replace func(uid string) string) ([]*ElementChange, error) {
+	var changes []*ElementChange
+	var remap func(elems []*dicom.Element, child func(t tag.Tag) TagPath) error
+	remap = func(elems []*dicom.Element, child func(t tag.Tag) TagPath) error {
+		for _, elem := range elems {
+			elemPath := child(elem.Tag)
+			if elem.Value != nil && elem.Value.ValueType() == dicom.Sequences {
+				for i, item := range itemsOf(elem) {
+					if err := remap(item, func(t tag.Tag) TagPath { return elemPath.Child(i, t) }); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if !remapsElement(elem) {
+				continue
+			}
+			old := ElementText(elem)
+			value := replaceUIDs(old, replace)
+			if value == old {
+				continue
+			}
+			if _, err := ParseElementText(elem, value); err != nil {
+				return fmt.Errorf("%s: %w", elemPath, err)
+			}
+			changes = append(changes, &ElementChange{Kind: ChangeSet, File: path, Path: elemPath, Old: old, New: value})
+		}
+		return nil
+	}
+	if err := remap(ds.Elements, NewTagPath); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,248 @@
+// valueValidation.go validates element values against the rules of their Value Representation.
+//
+// The rules follow PS3.5 section 6.2: every VR restricts the length and the characters of a single
+// value and some VRs, such as DA, TM or UI, require a specific format. In addition the number of
+// values of an element has to match the Value Multiplicity defined in the data dictionary.
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+var (
+	// ErrorInvalidValue is returned when a value does not conform to its Value Representation.
+	ErrorInvalidValue = errors.New("invalid value")
+	// ErrorInvalidValueMultiplicity is returned when the number of values of an element does not
+	// match the Value Multiplicity defined in the data dictionary.
+	ErrorInvalidValueMultiplicity = errors.New("invalid value multiplicity")
+)
+
+// vrMaxLength is the maximum length in characters of a single value of the string VRs.
+var vrMaxLength = map[string]int{
+	"AE": 16,
+	"AS": 4,
+	"CS": 16,
+	"DA": 8,
+	"DS": 16,
+	"DT": 26,
+	"IS": 12,
+	"LO": 64,
+	"LT": 10240,
+	"SH": 16,
+	"ST": 1024,
+	"TM": 16,
+	"UI": 64,
+}
+
+// vrFormat describes the required format of the values of a VR.
+type vrFormat struct {
+	pattern     *regexp.Regexp
+	description string
+}
+
+// vrFormats are the formats of the VRs that restrict their values beyond length and characters.
+var vrFormats = map[string]vrFormat{
+	"AS": {regexp.MustCompile(`^\d{3}[DWMY]$`), "nnnD, nnnW, nnnM or nnnY"},
+	"CS": {regexp.MustCompile(`^[A-Z0-9 _]*$`), "upper case letters, digits, space and underscore"},
+	"DA": {regexp.MustCompile(`^\d{8}$`), "YYYYMMDD"},
+	"DS": {regexp.MustCompile(`^ *[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)? *$`), "a decimal number"},
+	"DT": {regexp.MustCompile(`^\d{4}(\d{2}(\d{2}(\d{2}(\d{2}(\d{2}(\.\d{1,6})?)?)?)?)?)?([+-]\d{4})? *$`), "YYYYMMDDHHMMSS.FFFFFF&ZZXX"},
+	"IS": {regexp.MustCompile(`^ *[+-]?\d+ *$`), "an integer"},
+	"TM": {regexp.MustCompile(`^\d{2}(\d{2}(\d{2}(\.\d{1,6})?)?)? *$`), "HHMMSS.FFFFFF"},
+	"UI": {regexp.MustCompile(`^(0|[1-9]\d*)(\.(0|[1-9]\d*))*$`), "digits separated by periods without leading zeros"},
+}
+
+// singleValueVRs are the VRs whose values may contain backslashes and therefore always have a
+// Value Multiplicity of 1.
+var singleValueVRs = map[string]bool{
+	"LT": true,
+	"ST": true,
+	"UT": true,
+	"UR": true,
+}
+
+// ValidateValue checks a single value against the rules of the given VR.
+//
+// Values of VRs stored as binary numbers (US, SS, UL, SL, FL, FD) must be parseable and within the
+// range of the binary type. VRs without value restrictions, such as OB or UN, are always valid.
+func ValidateValue(vr string, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	switch vr {
+	case "US":
+		return validateInteger(value, 0, math.MaxUint16)
+	case "SS":
+		return validateInteger(value, math.MinInt16, math.MaxInt16)
+	case "UL":
+		return validateInteger(value, 0, math.MaxUint32)
+	case "SL":
+		return validateInteger(value, math.MinInt32, math.MaxInt32)
+	case "FL":
+		return validateFloat(value, 32)
+	case "FD":
+		return validateFloat(value, 64)
+	}
+
+	if maxLength, ok := vrMaxLength[vr]; ok && utf8.RuneCountInString(value) > maxLength {
+		return fmt.Errorf("%w: %s values are limited to %d characters", ErrorInvalidValue, vr, maxLength)
+	}
+	if err := validateCharacters(vr, value); err != nil {
+		return err
+	}
+	if format, ok := vrFormats[vr]; ok && !format.pattern.MatchString(value) {
+		return fmt.Errorf("%w: %q is not a valid %s value, expected %s", ErrorInvalidValue, value, vr, format.description)
+	}
+
+	switch vr {
+	case "DA":
+		if _, err := time.Parse("20060102", value); err != nil {
+			return fmt.Errorf("%w: %q is not a valid date", ErrorInvalidValue, value)
+		}
+	case "TM":
+		return validateTime(strings.TrimSpace(value))
+	case "IS":
+		return validateInteger(strings.TrimSpace(value), math.MinInt32, math.MaxInt32)
+	case "PN":
+		return validatePersonName(value)
+	}
+	return nil
+}
+
+// ValidateValueMultiplicity checks that n values are allowed for the element with tag t.
+//
+// Tags missing from the data dictionary, such as private tags, accept any number of values.
+// Zero values are always allowed since they represent an empty element.
+func ValidateValueMultiplicity(t tag.Tag, n int) error {
+	info, err := tag.Find(t)
+	if err != nil || n == 0 {
+		return nil
+	}
+	if !vmAllows(info.VM, n) {
+		return fmt.Errorf("%w: %s allows %s value(s) but %d were given", ErrorInvalidValueMultiplicity, info.Name, info.VM, n)
+	}
+	return nil
+}
+
+// SplitValues splits the textual representation of an element value into its single values.
+//
+// Values are separated by backslashes, except for VRs that allow backslashes within their only value.
+func SplitValues(vr string, input string) []string {
+	if input == "" {
+		return nil
+	}
+	if singleValueVRs[vr] {
+		return []string{input}
+	}
+	return strings.Split(input, "\\")
+}
+
+// validateCharacters checks that value only contains characters allowed for the given VR.
+//
+// Control characters are only allowed as line breaks in the text VRs and as ESC, which introduces
+// character set extensions. Backslashes separate values and are therefore not part of a value.
+func validateCharacters(vr string, value string) error {
+	text := vr == "LT" || vr == "ST" || vr == "UT"
+	for _, r := range value {
+		switch {
+		case r == '\\' && !text:
+			return fmt.Errorf("%w: %s values must not contain a backslash", ErrorInvalidValue, vr)
+		case r == 0x1b:
+		case text && (r == '\n' || r == '\r' || r == '\t' || r == '\f'):
+		case unicode.IsControl(r):
+			return fmt.Errorf("%w: %s values must not contain control characters", ErrorInvalidValue, vr)
+		}
+	}
+	return nil
+}
+
+// validateInteger checks that value is an integer within [minimum, maximum].
+func validateInteger(value string, minimum, maximum int64) error {
+	v, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %q is not an integer", ErrorInvalidValue, value)
+	}
+	if v < minimum || v > maximum {
+		return fmt.Errorf("%w: %d is not within [%d, %d]", ErrorInvalidValue, v, minimum, maximum)
+	}
+	return nil
+}
+
+// validateFloat checks that value is a floating point number representable with the given bit size.
+func validateFloat(value string, bitSize int) error {
+	if _, err := strconv.ParseFloat(strings.TrimSpace(value), bitSize); err != nil {
+		return fmt.Errorf("%w: %q is not a %d bit floating point number", ErrorInvalidValue, value, bitSize)
+	}
+	return nil
+}
+
+// validateTime checks the hour, minute and second components of a TM value.
+//
+// A second value of 60 is accepted to allow for leap seconds.
+func validateTime(value string) error {
+	limits := []int{23, 59, 60}
+	for i, limit := range limits {
+		if len(value) < 2*i+2 {
+			break
+		}
+		component, _ := strconv.Atoi(value[2*i : 2*i+2])
+		if component > limit {
+			return fmt.Errorf("%w: %q is not a valid time", ErrorInvalidValue, value)
+		}
+	}
+	return nil
+}
+
+// validatePersonName checks that a PN value has at most three component groups of at most five
+// components each, with every group limited to 64 characters.
+func validatePersonName(value string) error {
+	groups := strings.Split(value, "=")
+	if len(groups) > 3 {
+		return fmt.Errorf("%w: %q has more than three component groups", ErrorInvalidValue, value)
+	}
+	for _, group := range groups {
+		if utf8.RuneCountInString(group) > 64 {
+			return fmt.Errorf("%w: PN component groups are limited to 64 characters", ErrorInvalidValue)
+		}
+		if strings.Count(group, "^") > 4 {
+			return fmt.Errorf("%w: %q has more than five name components", ErrorInvalidValue, group)
+		}
+	}
+	return nil
+}
+
+// vmAllows reports whether n values satisfy a Value Multiplicity such as "1", "1-3", "1-n" or "2-2n".
+func vmAllows(vm string, n int) bool {
+	minimum, maximum, found := strings.Cut(vm, "-")
+	low, err := strconv.Atoi(minimum)
+	if err != nil {
+		return true
+	}
+	if !found {
+		return n == low
+	}
+	if n < low {
+		return false
+	}
+
+	if step, ok := strings.CutSuffix(maximum, "n"); ok {
+		if step == "" {
+			return true
+		}
+		multiple, err := strconv.Atoi(step)
+		return err != nil || n%multiple == 0
+	}
+	high, err := strconv.Atoi(maximum)
+	return err != nil || n <= high
+}
@@ -0,0 +1,144 @@
+// verify.go implements the verification of saved files.
+//
+// After a dataset has been saved, the file is read again through saveParseUntilEOF, the same way
+// tyro reads files, and its dataset is compared to the one that was written element by element.
+// If the file no longer parses or any element differs, the file is rolled back to the backup taken
+// when it was replaced, or to a temporary copy for files that are not backed up, so a save never leaves a file that cannot be read back as intended.
+//
+// Elements are compared by their encoding produced by the DICOM library in the transfer syntax of
+// the file. Values that are held differently in memory but are written identically, e.g. an
+// element added with its data dictionary VR that is read back from an Implicit VR file, are equal.
+package operations
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/suyashkumar/dicom"
+)
+
+// ErrorVerificationFailed is returned when a saved file cannot be read back or does not contain
+// the dataset that was written. The file has been rolled back to its previous version.
+var ErrorVerificationFailed = errors.New("saved file does not match the written dataset")
+
+// saveVerified replaces the file at path with the content produced by write, which encodes ds,
+// and verifies the saved file using verifySaved. opts are the options ds has been parsed with.
+//
+// If the verification fails, the previous version of the file is restored and an error wrapping
+// ErrorVerificationFailed is returned. If backup is false, the previous version is not backed up
+// but kept in a temporary file next to the file until it has been verified.
+func saveVerified(path string, ds dicom.Dataset, backup bool, write func(out io.Writer) error, opts ...dicom.ParseOption) error {
+	if !backup {
+		return saveVerifiedWithoutBackup(path, ds, write, opts...)
+	}
+	previous, err := replaceFile(path, true, write)
+	if err != nil {
+		return err
+	}
+
+	verifyErr := verifySaved(path, ds, opts...)
+	if verifyErr == nil {
+		return nil
+	}
+	if previous == nil {
+		return fmt.Errorf("%s: %w, no previous version to roll back to", path, verifyErr)
+	}
+	// Restoring backs up the faulty file as well, so it can still be inspected.
+	if err := RestoreBackup(*previous); err != nil {
+		return fmt.Errorf("%s: %w, rolling back failed: %v", path, verifyErr, err)
+	}
+	return fmt.Errorf("%s: %w, the previous version has been restored", path, verifyErr)
+}
+
+// saveVerifiedWithoutBackup is saveVerified for files whose previous version must not be kept.
+func saveVerifiedWithoutBackup(path string, ds dicom.Dataset, write func(out io.Writer) error, opts ...dicom.ParseOption) error {
+	previous, err := keepPrevious(path)
+	if err != nil {
+		return err
+	}
+	// Removing the previous version fails once it has been renamed back, which is fine.
+	defer os.Remove(previous)
+
+	if _, err := replaceFile(path, false, write); err != nil {
+		return err
+	}
+	verifyErr := verifySaved(path, ds, opts...)
+	if verifyErr == nil {
+		return nil
+	}
+	if err := os.Rename(previous, path); err != nil {
+		return fmt.Errorf("%s: %w, rolling back failed: %v", path, verifyErr, err)
+	}
+	syncDir(filepath.Dir(path))
+	return fmt.Errorf("%s: %w, the previous version has been restored", path, verifyErr)
+}
+
+// verifySaved reads the file at path using saveParseUntilEOF with opts and compares its dataset
+// to ds, the dataset that has been written to it.
+func verifySaved(path string, ds dicom.Dataset, opts ...dicom.ParseOption) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorVerificationFailed, err)
+	}
+	defer file.Close()
+
+	saved, err := saveParseUntilEOF(file, opts...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorVerificationFailed, err)
+	}
+	return compareDatasets(ds, saved)
+}
+
+// compareDatasets compares the top level elements of want and got, including the File Meta
+// Information, and returns an error naming the first element that is missing, unexpected or
+// encoded differently.
+func compareDatasets(want dicom.Dataset, got dicom.Dataset) error {
+	wantElems, err := encodeTopLevel(want)
+	if err != nil {
+		return err
+	}
+	gotElems, err := encodeTopLevel(got)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorVerificationFailed, err)
+	}
+
+	gotByTag := elementsByTag(gotElems)
+	for _, elem := range wantElems {
+		saved, ok := gotByTag[elem.tag]
+		if !ok {
+			return fmt.Errorf("%w: %s is missing", ErrorVerificationFailed, tagName(elem.tag))
+		}
+		if !bytes.Equal(saved.data, elem.data) {
+			return fmt.Errorf("%w: %s differs", ErrorVerificationFailed, tagName(elem.tag))
+		}
+	}
+
+	wantByTag := elementsByTag(wantElems)
+	for _, elem := range gotElems {
+		if _, ok := wantByTag[elem.tag]; !ok {
+			return fmt.Errorf("%w: unexpected element %s", ErrorVerificationFailed, tagName(elem.tag))
+		}
+	}
+	if len(gotElems) != len(wantElems) {
+		return fmt.Errorf("%w: %d elements were written but %d were read", ErrorVerificationFailed, len(wantElems), len(gotElems))
+	}
+	return nil
+}
+
+// encodeTopLevel encodes ds using the DICOM library and returns its top level File Meta
+// Information and data set elements.
+func encodeTopLevel(ds dicom.Dataset) ([]rawElement, error) {
+	encoded := &bytes.Buffer{}
+	if err := encodeDataset(encoded, ds); err != nil {
+		return nil, err
+	}
+	file, err := splitEncodedFile(encoded.Bytes(), false)
+	if err != nil {
+		return nil, err
+	}
+	return append(file.meta, file.elements...), nil
+}
@@ -0,0 +1,95 @@
+// writer.go provides the functions used to encode parsed datasets back into DICOM files.
+package operations
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/suyashkumar/dicom"
+)
+
+// writeOptions are passed to the DICOM library for every dataset written by tyro.
+//
+// VR verification is skipped because files in the wild frequently use VRs that differ from the
+// data dictionary, and those elements have to be written back as they were read.
+var writeOptions = []dicom.WriteOption{dicom.SkipVRVerification()}
+
+// WriteDataset encodes ds as a complete DICOM file including preamble and File Meta Information.
+//
+// The data set is encoded using the transfer syntax stored in the File Meta Information. Deflated
+// Explicit VR Little Endian data sets are compressed after encoding.
+func WriteDataset(out io.Writer, ds dicom.Dataset) error {
+	if !IsDeflated(ds) {
+		return encodeDataset(out, ds)
+	}
+
+	encoded := &bytes.Buffer{}
+	if err := encodeDataset(encoded, ds); err != nil {
+		return err
+	}
+	return writeDeflated(out, encoded.Bytes())
+}
+
+// encodeDataset encodes ds as a complete DICOM file using the DICOM library, without deflating it.
+//
+// The library reads the OW values of Big Endian data sets as little endian words, but writes OW
+// values as they are held. They are therefore swapped to big endian while ds is written, so OW
+// elements are written the way they have been read.
+func encodeDataset(out io.Writer, ds dicom.Dataset) error {
+	if byteOrderOf(ds) == binary.BigEndian {
+		restore, err := swapOtherWords(ds.Elements)
+		defer restore()
+		if err != nil {
+			return err
+		}
+	}
+	return dicom.Write(out, ds, writeOptions...)
+}
+
+// swapOtherWords replaces the values of all OW elements in elems, including the ones nested in
+// sequences, with byte swapped copies. The returned function restores the original values.
+func swapOtherWords(elems []*dicom.Element) (func(), error) {
+	var swapped []*dicom.Element
+	var originals []dicom.Value
+	restore := func() {
+		for i, elem := range swapped {
+			elem.Value = originals[i]
+		}
+	}
+
+	var swap func(elems []*dicom.Element) error
+	swap = func(elems []*dicom.Element) error {
+		for _, elem := range elems {
+			switch {
+			case elem.Value == nil:
+			case elem.Value.ValueType() == dicom.Sequences:
+				for _, item := range itemsOf(elem) {
+					if err := swap(item); err != nil {
+						return err
+					}
+				}
+			case elem.RawValueRepresentation == "OW" && elem.Value.ValueType() == dicom.Bytes:
+				value, err := dicom.NewValue(swapBytes(elem.Value.GetValue().([]byte), 2))
+				if err != nil {
+					return err
+				}
+				swapped, originals = append(swapped, elem), append(originals, elem.Value)
+				elem.Value = value
+			}
+		}
+		return nil
+	}
+	return restore, swap(elems)
+}
+
+// SaveDataset writes ds to the file at path, replacing its previous content.
+//
+// The file is replaced atomically and its previous version is kept as a backup, see ReplaceFile.
+// The saved file is read back and rolled back to the backup if it does not contain ds, see
+// saveVerified. opts are the options ds has been parsed with.
+func SaveDataset(path string, ds dicom.Dataset, opts ...dicom.ParseOption) error {
+	return saveVerified(path, ds, true, func(out io.Writer) error {
+		return WriteDataset(out, ds)
+	}, opts...)
+}
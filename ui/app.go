@@ -1,16 +1,56 @@
 package ui
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/streimelstefan/tyro/operations"
 	"github.com/streimelstefan/tyro/ui/expandableTree"
 	"github.com/streimelstefan/tyro/ui/statusbar"
 )
 
+// FileSelectedMsg is sent whenever the selection in the file tree changes.
+type FileSelectedMsg struct {
+	// File is the selected DICOM file. It is nil if a directory is selected.
+	File *operations.ParsedDicomFile
+}
+
+// exportDir is the directory, relative to the working directory, that frames are exported to from the UI.
+const exportDir = "tyro-export"
+
+// historyAppliedMsg is sent once an operation has been undone, redone, replayed or rolled back.
+type historyAppliedMsg struct {
+	// verb describes what has been done to the operation, e.g. "undid".
+	verb string
+	op   operations.Operation
+	err  error
+}
+
+// pane identifies the content displayed in the right-hand pane.
+type pane int
+
+const (
+	// paneTags shows the tag tree of the selected file. It is the default content of the right-hand pane.
+	paneTags pane = iota
+	// panePreview shows the image preview of the selected file.
+	panePreview
+	// paneDocument shows the SR content tree or encapsulated document of the selected file.
+	paneDocument
+	// paneBackups lists the backups of the selected file.
+	paneBackups
+	// paneBatch applies a batch edit to the files beneath the selected file tree node.
+	paneBatch
+	// paneStaging lists the staged changes for review.
+	paneStaging
+)
+
 // App represents the main application state
 type App struct {
 	statusBar *statusbar.Model
@@ -23,22 +63,71 @@ type App struct {
 	fileTree         *expandableTree.Model
 	fileTreeViewPort viewport.Model
 
+	tags     *tagTreeModel
+	preview  *previewModel
+	document *documentModel
+	backups  *backupsModel
+	batch    *batchEditModel
+	stage    *stagingModel
+
+	rightPane      pane
+	rightPaneFocus bool
+
+	selectedFile *operations.ParsedDicomFile
+	// files holds all discovered files by path.
+	files map[string]*operations.ParsedDicomFile
+
+	history *operations.History
+	// staging collects edits in memory while it is enabled.
+	staging *stagingArea
+	// quitWarned reports whether the user has been warned that quitting discards staged changes.
+	quitWarned bool
+	// historyErr is the error that occurred while opening the journal of the root directory.
+	historyErr error
+
 	debug *debugModel
 }
 
 // NewApp creates a new application instance
+//
+// The edit history of folder is restored from its journal. If the journal cannot be opened, the
+// history is kept in memory only.
 func NewApp(folder string) App {
+	history, err := operations.OpenHistory(folder)
+	if err != nil {
+		history = &operations.History{}
+	}
+	staging := &stagingArea{Staging: &operations.Staging{}}
+	files := map[string]*operations.ParsedDicomFile{}
+
 	return App{
-		statusBar: statusbar.New(folder),
-		discovery: NewDiscoveryModel(folder, 100*time.Millisecond),
-		fileTree:  expandableTree.New(),
-		debug:     NewDebugModel(),
+		statusBar:  statusbar.New(folder),
+		discovery:  NewDiscoveryModel(folder, 100*time.Millisecond),
+		fileTree:   expandableTree.New(),
+		tags:       NewTagTreeModel(history, staging),
+		preview:    NewPreviewModel(),
+		document:   NewDocumentModel(),
+		backups:    NewBackupsModel(),
+		batch:      NewBatchEditModel(history, staging, files),
+		stage:      NewStagingModel(staging, history, files),
+		files:      files,
+		history:    history,
+		staging:    staging,
+		historyErr: err,
+		debug:      NewDebugModel(),
 	}
 }
 
 // Init is called when the program starts
 func (m App) Init() tea.Cmd {
-	return m.discovery.Init()
+	cmds := []tea.Cmd{m.discovery.Init()}
+	if m.historyErr != nil {
+		cmds = append(cmds, statusbar.Error(fmt.Errorf("undo history will not survive a restart: %w", m.historyErr)))
+	}
+	if pending := m.history.Pending(); pending != nil {
+		cmds = append(cmds, statusbar.Error(pendingError(pending)))
+	}
+	return tea.Batch(cmds...)
 }
 
 // Update handles messages and user input
@@ -52,16 +141,102 @@ func (m App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.fileTreeViewPort.Width = msg.Width
-		m.fileTreeViewPort.Height = msg.Height
+		m.layout()
 	case tea.KeyMsg:
+		if (m.tags.Editing() || m.batch.Editing()) && msg.String() != "ctrl+c" {
+			// Inputs receive all keys, so they must not trigger any global action.
+			break
+		}
+		if pending := m.history.Pending(); pending != nil {
+			// An interrupted operation has to be resolved before the files can be worked with.
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			case "R":
+				cmds = append(cmds, m.recover(true))
+			case "X":
+				cmds = append(cmds, m.recover(false))
+			default:
+				cmds = append(cmds, statusbar.Error(pendingError(pending)))
+			}
+			break
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
+			if n := m.staging.Len(); n > 0 && !m.quitWarned {
+				m.quitWarned = true
+				cmds = append(cmds, statusbar.Error(fmt.Errorf("%d staged changes have not been committed, press %s again to discard them", n, msg.String())))
+				break
+			}
 			return m, tea.Quit
+		case "S":
+			cmds = append(cmds, m.toggleStaging())
+		case "s":
+			cmds = append(cmds, m.showRightPane(paneStaging))
+		case "u":
+			cmds = append(cmds, m.undo())
+		case "ctrl+r":
+			cmds = append(cmds, m.redo())
+		case "p":
+			cmds = append(cmds, m.showRightPane(panePreview))
+		case "d":
+			cmds = append(cmds, m.showRightPane(paneDocument))
+		case "b":
+			cmds = append(cmds, m.showRightPane(paneBackups))
+		case "B":
+			// The key must not reach the input of the batch edit pane it opens.
+			return m, m.startBatchEdit(batchModeEdit)
+		case "F":
+			return m, m.startBatchEdit(batchModeFind)
+		case "P":
+			return m, m.startBatchEdit(batchModeScan)
+		case "U":
+			return m, m.startBatchEdit(batchModeUIDs)
+		case "T":
+			cmd := m.startBatchEdit(batchModeEdit)
+			m.batch.SetInput("transcode ")
+			return m, cmd
+		case "tab":
+			m.setRightPaneFocus(!m.rightPaneFocus && m.rightPane != panePreview)
+		case "e":
+			cmds = append(cmds, m.exportSelected())
+		case "x":
+			cmds = append(cmds, m.extractSelected())
+		default:
+			// Keys for the focused tag tree, backup list and batch edit are handled by the panes themselves.
+			switch {
+			case !m.rightPaneFocus:
+				m.fileTree, cmd = m.fileTree.Update(msg)
+			case m.rightPane == paneDocument:
+				m.document.pane, cmd = m.document.pane.Update(msg)
+			}
+			cmds = append(cmds, cmd)
 		}
+		m.refreshFileTree()
 	case CollectedDICOMFiles:
 		m.addNewFilesToTrees(msg)
-		m.fileTreeViewPort.SetContent(m.fileTree.View())
+		cmds = append(cmds, m.fileTree.SelectFirst())
+		m.refreshFileTree()
+	case expandableTree.SelectionChangedMsg:
+		if msg.Tree == m.fileTree {
+			cmds = append(cmds, m.fileSelected(msg.Model))
+		}
+	case FileSelectedMsg:
+		m.selectedFile = msg.File
+	case backupRestoredMsg:
+		cmds = append(cmds, m.backupRestored(msg))
+	case historyAppliedMsg:
+		cmds = append(cmds, m.historyApplied(msg))
+	case batchAppliedMsg:
+		cmds = append(cmds, m.historyApplied(historyAppliedMsg{verb: "applied", op: msg.op, err: msg.err}))
+	case stagingCommittedMsg:
+		cmds = append(cmds, m.stagingCommitted(msg))
+	case stagingChangedMsg:
+		m.quitWarned = false
+	case batchClosedMsg:
+		if m.rightPane == paneBatch {
+			cmds = append(cmds, m.showRightPane(paneBatch))
+		}
 	}
 
 	m.statusBar, cmd = m.statusBar.Update(msg)
@@ -70,22 +245,334 @@ func (m App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.discovery, cmd = m.discovery.Update(msg)
 	cmds = append(cmds, cmd)
 
-	m.fileTreeViewPort, cmd = m.fileTreeViewPort.Update(msg)
+	if _, ok := msg.(tea.KeyMsg); !ok {
+		m.fileTreeViewPort, cmd = m.fileTreeViewPort.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	m.tags, cmd = m.tags.Update(msg)
+	cmds = append(cmds, cmd)
+
+	m.preview, cmd = m.preview.Update(msg)
+	cmds = append(cmds, cmd)
+
+	m.document, cmd = m.document.Update(msg)
+	cmds = append(cmds, cmd)
+
+	m.backups, cmd = m.backups.Update(msg)
+	cmds = append(cmds, cmd)
+
+	m.batch, cmd = m.batch.Update(msg)
+	cmds = append(cmds, cmd)
+
+	m.stage, cmd = m.stage.Update(msg)
 	cmds = append(cmds, cmd)
 
 	m.debug, cmd = m.debug.Update(msg)
 	cmds = append(cmds, cmd)
 
+	switch msg.(type) {
+	case elementSavedMsg, historyAppliedMsg, batchAppliedMsg, stagingCommittedMsg, stagingChangedMsg, backupRestoredMsg:
+		// Reloaded files may have changed their File Meta Information markers.
+		m.refreshFileTree()
+	}
+
 	return m, tea.Batch(cmds...)
 }
 
 // View renders the UI
 func (m App) View() string {
-	return m.fileTreeViewPort.View()
+	var right string
+	switch m.rightPane {
+	case paneTags:
+		right = m.tags.View()
+	case panePreview:
+		right = m.preview.View()
+	case paneDocument:
+		right = m.document.View()
+	case paneBackups:
+		right = m.backups.View()
+	case paneBatch:
+		right = m.batch.View()
+	case paneStaging:
+		right = m.stage.View()
+	}
+	panes := lipgloss.JoinHorizontal(lipgloss.Top, m.fileTreeViewPort.View(), right)
+	return lipgloss.JoinVertical(lipgloss.Left, panes, m.statusBar.View())
+}
+
+// showRightPane toggles the given content in the right-hand pane. Showing a pane that is already
+// visible switches back to the tag tree.
+func (m *App) showRightPane(p pane) tea.Cmd {
+	if m.rightPane == p {
+		p = paneTags
+	}
+	m.rightPane = p
+	m.setRightPaneFocus(false)
+	m.layout()
+	return m.preview.SetVisible(p == panePreview)
+}
+
+// setRightPaneFocus moves the keyboard focus to the right-hand pane or back to the file tree.
+func (m *App) setRightPaneFocus(focused bool) {
+	m.rightPaneFocus = focused
+	m.tags.SetFocused(focused && m.rightPane == paneTags)
+	m.backups.SetFocused(focused && m.rightPane == paneBackups)
+	m.batch.SetFocused(focused && m.rightPane == paneBatch)
+	m.stage.SetFocused(focused && m.rightPane == paneStaging)
+}
+
+// layout distributes the available terminal space between the panes.
+func (m *App) layout() {
+	treeWidth := m.width / 3
+	if m.rightPane == panePreview {
+		treeWidth = m.width / 2
+	}
+	paneHeight := max(0, m.height-1)
+	m.fileTreeViewPort.Width = treeWidth
+	m.fileTreeViewPort.Height = paneHeight
+	m.tags.SetSize(m.width-treeWidth, paneHeight)
+	m.preview.SetSize(m.width-treeWidth, paneHeight)
+	m.document.SetSize(m.width-treeWidth, paneHeight)
+	m.backups.SetSize(m.width-treeWidth, paneHeight)
+	m.batch.SetSize(m.width-treeWidth, paneHeight)
+	m.stage.SetSize(m.width-treeWidth, paneHeight)
+}
+
+// refreshFileTree re-renders the file tree and scrolls the viewport so the selection stays visible.
+func (m *App) refreshFileTree() {
+	m.fileTreeViewPort.SetContent(m.fileTree.View())
+
+	line := m.fileTree.SelectedLine()
+	if line < 0 {
+		return
+	}
+	if line < m.fileTreeViewPort.YOffset {
+		m.fileTreeViewPort.SetYOffset(line)
+	} else if line >= m.fileTreeViewPort.YOffset+m.fileTreeViewPort.Height {
+		m.fileTreeViewPort.SetYOffset(line - m.fileTreeViewPort.Height + 1)
+	}
+}
+
+// fileSelected returns a command announcing the file represented by the given tree item model.
+func (m App) fileSelected(model tea.Model) tea.Cmd {
+	item, _ := model.(FileTreeItemModel)
+	return func() tea.Msg {
+		return FileSelectedMsg{File: item.File}
+	}
+}
+
+// exportSelected returns a command exporting the selected file to 8-bit PNGs in exportDir.
+//
+// If the preview shows the selected file, only the displayed frame is exported using the window
+// of the preview. Otherwise all frames are exported using the window stored in the dataset.
+func (m App) exportSelected() tea.Cmd {
+	if m.selectedFile == nil {
+		return statusbar.Error(fmt.Errorf("no file selected"))
+	}
+
+	opts := operations.ExportOptions{
+		Format:      operations.ExportPNG8,
+		OutputDir:   exportDir,
+		ApplyWindow: true,
+	}
+	pixels := m.preview.pixels
+	if m.rightPane == panePreview && pixels != nil && pixels.Path == m.selectedFile.Path {
+		window := m.preview.window
+		opts.Window = &window
+		opts.Frames = []int{m.preview.frame}
+	} else {
+		pixels = nil
+	}
+
+	path := m.selectedFile.Path
+	return func() tea.Msg {
+		if pixels == nil {
+			var err error
+			if pixels, err = operations.LoadPixelData(path); err != nil {
+				return statusbar.MessageMsg{Text: fmt.Sprintf("export failed: %v", err), IsError: true}
+			}
+		}
+		written, err := operations.ExportFrames(pixels, opts)
+		if err != nil {
+			return statusbar.MessageMsg{Text: fmt.Sprintf("export failed: %v", err), IsError: true}
+		}
+		return statusbar.MessageMsg{Text: fmt.Sprintf("exported %d file(s) to %s", len(written), exportDir)}
+	}
+}
+
+// extractSelected returns a command writing the encapsulated document of the selected file to exportDir.
+func (m App) extractSelected() tea.Cmd {
+	if m.selectedFile == nil {
+		return statusbar.Error(fmt.Errorf("no file selected"))
+	}
+
+	file := m.selectedFile
+	return func() tea.Msg {
+		out, err := operations.ExtractEncapsulatedDocument(file, exportDir)
+		if err != nil {
+			return statusbar.MessageMsg{Text: fmt.Sprintf("extraction failed: %v", err), IsError: true}
+		}
+		return statusbar.MessageMsg{Text: "extracted document to " + out}
+	}
+}
+
+// backupRestored re-reads a file after one of its backups has been restored and reports the result.
+//
+// If the file is selected, a FileSelectedMsg is returned so all panes show its restored content.
+func (m App) backupRestored(msg backupRestoredMsg) tea.Cmd {
+	if msg.err != nil {
+		return statusbar.Error(fmt.Errorf("restoring %s failed: %w", msg.backup.Original, msg.err))
+	}
+	if err := m.staging.reload(msg.file); err != nil {
+		return statusbar.Error(fmt.Errorf("restored %s but could not read it: %w", msg.file.Path, err))
+	}
+
+	cmds := []tea.Cmd{
+		statusbar.Message(fmt.Sprintf("restored %s from %s", msg.file.Path, msg.backup.Time.Local().Format("2006-01-02 15:04:05"))),
+	}
+	if msg.file == m.selectedFile {
+		cmds = append(cmds, func() tea.Msg { return FileSelectedMsg{File: msg.file} })
+	}
+	return tea.Batch(cmds...)
+}
+
+// startBatchEdit opens the batch edit pane for all files beneath the selected file tree node and
+// moves the keyboard focus to it in the given mode. Searches and UID checks cover all discovered
+// files instead.
+func (m *App) startBatchEdit(mode batchMode) tea.Cmd {
+	node := m.fileTree.Selected()
+	if node == nil || mode == batchModeFind || mode == batchModeUIDs {
+		node = m.fileTree.ExpandableTree.Root
+	}
+
+	root, paths := nodePath(m.discovery.rootDir, node), filesBelow(node)
+	switch mode {
+	case batchModeFind:
+		m.batch.StartFind(root, paths)
+	case batchModeScan:
+		m.batch.StartScan(root, paths)
+	case batchModeUIDs:
+		m.batch.StartUIDCheck(root, paths)
+	default:
+		m.batch.Start(root, paths)
+	}
+
+	cmd := m.preview.SetVisible(false)
+	m.rightPane = paneBatch
+	m.setRightPaneFocus(true)
+	m.layout()
+	return cmd
+}
+
+// toggleStaging switches between staging edits and writing them immediately.
+func (m *App) toggleStaging() tea.Cmd {
+	m.staging.enabled = !m.staging.enabled
+	m.stage.Load()
+	if m.staging.enabled {
+		return statusbar.Message("staging on: edits are kept in memory until they are committed from the staging pane (s)")
+	}
+	return statusbar.Message(fmt.Sprintf("staging off: edits are written immediately, %d staged changes remain", m.staging.Len()))
+}
+
+// stagingCommitted re-reads the files modified by a commit of the staging area and reports the
+// result. Files that failed keep their staged changes.
+func (m App) stagingCommitted(msg stagingCommittedMsg) tea.Cmd {
+	var cmds []tea.Cmd
+	if len(msg.op.Changes) > 0 {
+		cmds = append(cmds, m.historyApplied(historyAppliedMsg{verb: "performed", op: msg.op}))
+	}
+	if msg.err != nil {
+		failed := 1
+		var multi *tyroErrors.MultiError
+		if errors.As(msg.err, &multi) {
+			failed = len(multi.Errors())
+		}
+		cmds = append(cmds, statusbar.Error(fmt.Errorf("commit: %d errors, see the staging pane (s)", failed)))
+	}
+	return tea.Batch(cmds...)
+}
+
+// nodePath returns the path of the file or directory represented by a file tree node. The
+// identifiers of the nodes are the parts of the path relative to the root directory.
+func nodePath(root string, n *expandableTree.Node) string {
+	var parts []string
+	for ; n != nil && n.Identifier != ""; n = n.Parent() {
+		parts = append([]string{n.Identifier}, parts...)
+	}
+	return filepath.Join(append([]string{root}, parts...)...)
+}
+
+// undo returns a command reverting the most recent operation of the edit history.
+func (m App) undo() tea.Cmd {
+	history := m.history
+	return func() tea.Msg {
+		op, err := history.Undo()
+		return historyAppliedMsg{verb: "undid", op: op, err: err}
+	}
+}
+
+// redo returns a command performing the most recently undone operation again.
+func (m App) redo() tea.Cmd {
+	history := m.history
+	return func() tea.Msg {
+		op, err := history.Redo()
+		return historyAppliedMsg{verb: "redid", op: op, err: err}
+	}
+}
+
+// recover returns a command replaying or rolling back the interrupted operation of the journal.
+func (m App) recover(replay bool) tea.Cmd {
+	history := m.history
+	verb := "rolled back"
+	if replay {
+		verb = "replayed"
+	}
+	return func() tea.Msg {
+		pending, err := history.Recover(replay)
+		return historyAppliedMsg{verb: verb, op: pending.Operation, err: err}
+	}
+}
+
+// historyApplied re-reads the files modified by an undone, redone or recovered operation and
+// reports the result.
+//
+// If the selected file has been modified, a FileSelectedMsg is returned so all panes show its
+// new content.
+func (m App) historyApplied(msg historyAppliedMsg) tea.Cmd {
+	if msg.err != nil {
+		return statusbar.Error(msg.err)
+	}
+
+	cmds := []tea.Cmd{statusbar.Message(fmt.Sprintf("%s %q", msg.verb, msg.op.Description))}
+	for _, path := range msg.op.Files() {
+		file, ok := m.files[path]
+		if !ok {
+			continue
+		}
+		if err := m.staging.reload(file); err != nil {
+			cmds = append(cmds, statusbar.Error(fmt.Errorf("%s %q but could not read %s: %w", msg.verb, msg.op.Description, path, err)))
+			continue
+		}
+		if file == m.selectedFile {
+			cmds = append(cmds, func() tea.Msg { return FileSelectedMsg{File: file} })
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// pendingError describes an interrupted operation and how to resolve it.
+func pendingError(pending *operations.PendingAction) error {
+	if pending.Operation.Irreversible() {
+		return fmt.Errorf("%s of %q was interrupted: press R to replay, it removes identifying information and cannot be rolled back", pending.Action, pending.Operation.Description)
+	}
+	return fmt.Errorf("%s of %q was interrupted: press R to replay or X to roll back", pending.Action, pending.Operation.Description)
 }
 
 func (m App) addNewFilesToTrees(files CollectedDICOMFiles) {
 	for _, file := range files {
+		m.files[file.Path] = file
+
 		rel, err := filepath.Rel(m.discovery.rootDir, file.Path)
 		if err != nil {
 			continue
@@ -94,10 +581,14 @@ func (m App) addNewFilesToTrees(files CollectedDICOMFiles) {
 		parts := strings.Split(rel, string(filepath.Separator))
 
 		currentNode := m.fileTree.ExpandableTree.Root
-		for _, part := range parts {
+		for i, part := range parts {
 			tmpChild := currentNode.GetChild(part)
 			if tmpChild == nil {
-				tmpChild = m.fileTree.ExpandableTree.AddNode(currentNode, part, NewFileTreeItemModel(part))
+				item := NewFileTreeItemModel(part)
+				if i == len(parts)-1 {
+					item = NewFileTreeFileItemModel(part, file)
+				}
+				tmpChild = m.fileTree.ExpandableTree.AddNode(currentNode, part, item)
 			}
 			currentNode = tmpChild
 		}
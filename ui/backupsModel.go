@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/streimelstefan/tyro/operations"
+)
+
+// backupRestoredMsg is sent once a backup has been restored.
+type backupRestoredMsg struct {
+	file   *operations.ParsedDicomFile
+	backup operations.Backup
+	err    error
+}
+
+// backupItemModel is a tree item representing a single backup of a file.
+type backupItemModel struct {
+	Backup operations.Backup
+}
+
+func (m backupItemModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m backupItemModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return m, nil
+}
+
+func (m backupItemModel) View() string {
+	return fmt.Sprintf("%s  %d bytes", m.Backup.Time.Local().Format("2006-01-02 15:04:05"), m.Backup.Size)
+}
+
+// backupsModel lists the backups of the selected file, newest first.
+//
+// While the pane has the keyboard focus, enter restores the selected backup. The version replaced
+// by the restore is backed up itself, so restoring can be reverted.
+type backupsModel struct {
+	pane *treePaneModel
+	file *operations.ParsedDicomFile
+
+	focused bool
+}
+
+// NewBackupsModel creates an empty backup pane.
+func NewBackupsModel() *backupsModel {
+	m := &backupsModel{
+		pane: newTreePaneModel(),
+	}
+	m.pane.Reset("No file selected")
+	return m
+}
+
+func (m *backupsModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update reloads the backup list whenever the file selection changes or a file has been written
+// and handles the keys of the list while the pane has the focus.
+func (m *backupsModel) Update(msg tea.Msg) (*backupsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case FileSelectedMsg:
+		m.file = msg.File
+		m.Load()
+	case elementSavedMsg, backupRestoredMsg:
+		m.Load()
+	case tea.KeyMsg:
+		if !m.focused {
+			return m, nil
+		}
+		if selected := m.pane.tree.Selected(); selected != nil && msg.String() == "enter" {
+			if item, ok := selected.Model.(backupItemModel); ok {
+				return m, m.restore(item.Backup)
+			}
+		}
+		var cmd tea.Cmd
+		m.pane, cmd = m.pane.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m *backupsModel) View() string {
+	return m.pane.View()
+}
+
+// SetSize sets the number of terminal columns and rows available to the pane.
+func (m *backupsModel) SetSize(width, height int) {
+	m.pane.SetSize(width, height)
+}
+
+// SetFocused sets whether the pane receives key messages.
+func (m *backupsModel) SetFocused(focused bool) {
+	m.focused = focused
+}
+
+// Load fills the list with the backups of the selected file.
+func (m *backupsModel) Load() {
+	if m.file == nil {
+		m.pane.Reset("No file selected")
+		return
+	}
+
+	backups, err := operations.ListBackups(m.file.Path)
+	if err != nil {
+		m.pane.Reset(fmt.Sprintf("Cannot list backups: %v", err))
+		return
+	}
+
+	m.pane.Reset("No backups of " + m.file.Path)
+	tree := m.pane.tree.ExpandableTree
+	for _, backup := range backups {
+		tree.AddNode(tree.Root, backup.Path, backupItemModel{Backup: backup})
+	}
+	m.pane.tree.SelectFirst()
+	m.pane.Refresh()
+}
+
+// restore returns a command restoring backup over the selected file.
+func (m *backupsModel) restore(backup operations.Backup) tea.Cmd {
+	file := m.file
+	return func() tea.Msg {
+		err := operations.RestoreBackup(backup)
+		return backupRestoredMsg{file: file, backup: backup, err: err}
+	}
+}
@@ -0,0 +1,791 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/streimelstefan/tyro/operations"
+	defaults "github.com/streimelstefan/tyro/ui/defaults"
+	"github.com/streimelstefan/tyro/ui/expandableTree"
+)
+
+// batchState is the stage of a batch edit.
+type batchState int
+
+const (
+	// batchInput waits for the specification of the edit.
+	batchInput batchState = iota
+	// batchPreviewing reads the files to compute the changes of the edit.
+	batchPreviewing
+	// batchPreviewed shows the computed changes and waits for them to be confirmed.
+	batchPreviewed
+	// batchApplying writes the changes to the files.
+	batchApplying
+)
+
+// batchUpdates delivers the progress and the result of a step of a batch edit.
+type batchUpdates <-chan tea.Msg
+
+// batchProgressMsg reports the progress of previewing or applying a batch edit.
+type batchProgressMsg struct {
+	updates batchUpdates
+	done    int
+	total   int
+}
+
+// batchMode is the kind of modification entered in the batch edit pane.
+type batchMode int
+
+const (
+	// batchModeEdit applies a batch edit to the files beneath a file tree node.
+	batchModeEdit batchMode = iota
+	// batchModeFind searches and replaces values in all parsed files.
+	batchModeFind
+	// batchModeScan scans the files beneath a file tree node for residual PHI.
+	batchModeScan
+	// batchModeUIDs checks all parsed files for conflicting UIDs and fixes them.
+	batchModeUIDs
+)
+
+// batchPreview is the previewed outcome of a batch edit or a search.
+type batchPreview struct {
+	// description summarizes the modification and becomes the description of its operation.
+	description string
+	// edit is the previewed batch edit, which is committed before its changes are applied. It is
+	// nil for other modes.
+	edit operations.BatchEdit
+	// files is the number of examined files.
+	files int
+	// changes are the changes to perform.
+	changes []operations.ElementChange
+	// hits are the matches of a search. They are nil for batch edits.
+	hits []operations.Hit
+	// phi are the hits of a PHI scan. They are nil for batch edits and searches.
+	phi []operations.PHIHit
+	// warning is the warning of a PHI scan, if any.
+	warning string
+	// uids is the report of a UID check, whose fix are the changes. It is nil for other modes.
+	uids *operations.UIDReport
+	// errors holds an error for every file or hit that cannot be changed.
+	errors []error
+}
+
+// batchPreviewedMsg is sent once the changes of a batch edit have been computed.
+type batchPreviewedMsg struct {
+	updates batchUpdates
+	preview batchPreview
+}
+
+// batchAppliedMsg is sent once the changes of a batch edit have been written.
+type batchAppliedMsg struct {
+	updates batchUpdates
+	op      operations.Operation
+	err     error
+}
+
+// batchClosedMsg is sent when the batch edit pane is closed.
+type batchClosedMsg struct{}
+
+// batchEditModel applies a single tag change to all DICOM files beneath a node of the file tree.
+//
+// The edit is entered as "set <tag> <value>", "clear <tag>", "delete <tag>" or
+// "replace <tag> /pattern/replacement/". Enter reads all files and previews which files change and
+// how; a second enter writes the changes as a single, undoable operation. Both steps process the
+// files concurrently and report their progress below the input. Esc returns from the preview to
+// the input and closes the pane from the input.
+//
+// In find mode, the pane searches the parsed datasets of all files instead. The search is entered
+// as "<tags> /pattern/replacement/" and lists every hit, including those nested in sequences,
+// before the replacements are applied the same way.
+//
+// In scan mode, the pane reads the files and reports residual PHI grouped by file. The input
+// optionally takes an additional pattern as "<name> <regular expression>". A scan changes nothing.
+//
+// In UID mode, the pane checks the parsed datasets of all files for UIDs shared by unrelated
+// files and lists the conflicts by level, UID and group of files. Enter applies the fix, giving
+// the offending groups UIDs of their own.
+type batchEditModel struct {
+	pane *treePaneModel
+
+	// history performs the edits, so they can be undone.
+	history *operations.History
+	// staging collects the edits instead while it is enabled.
+	staging *stagingArea
+	// files holds all discovered files by path. Staged edits are applied to their datasets.
+	files map[string]*operations.ParsedDicomFile
+
+	// root is the directory or file the edit applies to and paths are the DICOM files beneath it.
+	root  string
+	paths []string
+
+	mode    batchMode
+	state   batchState
+	preview batchPreview
+	// done and total are the progress of the running step.
+	done  int
+	total int
+	// updates delivers the progress and the result of the running step. Updates of steps started
+	// before are received but ignored.
+	updates batchUpdates
+
+	focused  bool
+	input    textinput.Model
+	inputErr error
+
+	width  int
+	height int
+
+	errorStyle lipgloss.Style
+	helpStyle  lipgloss.Style
+}
+
+// NewBatchEditModel creates a batch edit pane performing edits through history, or staging them
+// on the parsed files in staging while it is enabled.
+func NewBatchEditModel(history *operations.History, staging *stagingArea, files map[string]*operations.ParsedDicomFile) *batchEditModel {
+	m := &batchEditModel{
+		pane:    newTreePaneModel(),
+		history: history,
+		staging: staging,
+		files:   files,
+		input:   textinput.New(),
+		errorStyle: lipgloss.NewStyle().
+			Foreground(defaults.ErrorColor),
+		helpStyle: lipgloss.NewStyle().
+			Foreground(defaults.AccentColor),
+	}
+	m.input.Prompt = "batch edit: "
+	m.input.Placeholder = "set AccessionNumber A123"
+	m.pane.Reset("No files selected")
+	return m
+}
+
+func (m *batchEditModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles the keys of the pane while it has the focus and the progress and results of
+// running steps.
+func (m *batchEditModel) Update(msg tea.Msg) (*batchEditModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case batchProgressMsg:
+		if msg.updates == m.updates {
+			m.done, m.total = msg.done, msg.total
+		}
+		return m, waitForUpdate(msg.updates)
+	case batchPreviewedMsg:
+		if msg.updates == m.updates {
+			m.previewed(msg.preview)
+		}
+	case batchAppliedMsg:
+		if msg.updates == m.updates {
+			m.applied(msg)
+		}
+	case tea.KeyMsg:
+		if !m.focused {
+			return m, nil
+		}
+		switch m.state {
+		case batchInput:
+			return m, m.updateInput(msg)
+		case batchPreviewed:
+			switch msg.String() {
+			case "enter":
+				return m, m.apply()
+			case "esc":
+				m.setState(batchInput)
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.pane, cmd = m.pane.Update(msg)
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+func (m *batchEditModel) View() string {
+	status := m.helpStyle.Render("set|clear|delete|replace <tag> [value|/pattern/replacement/], transcode implicit|explicit|big|deflated|rle, fix-meta, deidentify [options], remap-uids, strip-private [all], blank <WxH+X+Y ...|preset>  enter: preview  esc: close")
+	if m.mode == batchModeFind {
+		status = m.helpStyle.Render("<tags,...> /pattern/[replacement/]  enter: search  esc: close")
+	}
+	if m.mode == batchModeScan {
+		status = m.helpStyle.Render("[<name> <regular expression>]  enter: scan  esc: close")
+	}
+	if m.mode == batchModeUIDs {
+		status = m.helpStyle.Render("enter: check  esc: close")
+	}
+	switch {
+	case m.inputErr != nil:
+		status = m.errorStyle.Render(m.inputErr.Error())
+	case m.state == batchPreviewing:
+		status = fmt.Sprintf("reading files %s", progressBar(m.done, m.total, m.width))
+	case m.state == batchApplying:
+		status = fmt.Sprintf("writing files %s", progressBar(m.done, m.total, m.width))
+	case m.state == batchPreviewed && len(m.preview.changes) > 0 && m.staging.enabled:
+		status = m.helpStyle.Render(fmt.Sprintf("enter: stage %d changes  esc: edit", len(m.preview.changes)))
+	case m.state == batchPreviewed && len(m.preview.changes) > 0:
+		status = m.helpStyle.Render(fmt.Sprintf("enter: apply %d changes  esc: edit", len(m.preview.changes)))
+	case m.state == batchPreviewed:
+		status = m.helpStyle.Render("esc: edit")
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.pane.View(),
+		m.input.View(),
+		lipgloss.NewStyle().MaxWidth(max(1, m.width)).Render(status),
+	)
+}
+
+// SetSize sets the number of terminal columns and rows available to the pane.
+func (m *batchEditModel) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.pane.SetSize(width, max(0, height-editorHeight))
+	m.input.Width = max(1, width-lipgloss.Width(m.input.Prompt)-1)
+}
+
+// SetFocused sets whether the pane receives key messages.
+func (m *batchEditModel) SetFocused(focused bool) {
+	m.focused = focused
+	if focused && m.state == batchInput {
+		m.input.Focus()
+	} else {
+		m.input.Blur()
+	}
+}
+
+// Editing reports whether the edit is being entered. All key messages are handled by the input
+// while it has the focus.
+func (m *batchEditModel) Editing() bool {
+	return m.focused && m.state == batchInput
+}
+
+// Start prepares a new batch edit of the DICOM files at paths, which are located beneath root.
+// A step that is still running is not interrupted, but its result is discarded.
+func (m *batchEditModel) Start(root string, paths []string) {
+	m.start(batchModeEdit, "batch edit: ", "set AccessionNumber A123", root, paths)
+	m.pane.Reset(fmt.Sprintf("%d DICOM files beneath %s", len(paths), root))
+}
+
+// SetInput replaces the entered edit with spec, e.g. to suggest an action.
+func (m *batchEditModel) SetInput(spec string) {
+	m.input.SetValue(spec)
+	m.input.CursorEnd()
+}
+
+// StartFind prepares a new search of the DICOM files at paths, which are located beneath root.
+func (m *batchEditModel) StartFind(root string, paths []string) {
+	m.start(batchModeFind, "find: ", "InstitutionName,StationName /HOSP_A/Hospital A/", root, paths)
+	m.pane.Reset(fmt.Sprintf("Search %d DICOM files beneath %s, including sequences", len(paths), root))
+}
+
+// StartScan prepares a new PHI scan of the DICOM files at paths, which are located beneath root.
+func (m *batchEditModel) StartScan(root string, paths []string) {
+	m.start(batchModeScan, "scan for PHI: ", "ward Station [0-9]+ (optional)", root, paths)
+	m.pane.Reset(fmt.Sprintf("Scan %d DICOM files beneath %s for residual PHI", len(paths), root))
+}
+
+// StartUIDCheck prepares a new check of the DICOM files at paths, which are located beneath root,
+// for conflicting UIDs.
+func (m *batchEditModel) StartUIDCheck(root string, paths []string) {
+	m.start(batchModeUIDs, "check UIDs: ", "press enter", root, paths)
+	m.pane.Reset(fmt.Sprintf("Check %d DICOM files beneath %s for study, series and instance UIDs shared by unrelated files", len(paths), root))
+}
+
+// start resets the pane for a new modification of the given mode.
+func (m *batchEditModel) start(mode batchMode, prompt string, placeholder string, root string, paths []string) {
+	if m.mode != mode {
+		m.input.SetValue("")
+	}
+	m.mode = mode
+	m.input.Prompt = prompt
+	m.input.Placeholder = placeholder
+	m.root = root
+	m.paths = paths
+	m.updates = nil
+	m.setState(batchInput)
+	m.SetSize(m.width, m.height)
+}
+
+// setState switches to the given stage of the edit.
+func (m *batchEditModel) setState(state batchState) {
+	m.state = state
+	m.inputErr = nil
+	m.SetFocused(m.focused)
+}
+
+// updateInput handles a key message while the edit is being entered.
+func (m *batchEditModel) updateInput(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		return func() tea.Msg { return batchClosedMsg{} }
+	case "enter":
+		if len(m.paths) == 0 {
+			m.inputErr = fmt.Errorf("no DICOM files beneath %s", m.root)
+			return nil
+		}
+		switch m.mode {
+		case batchModeFind:
+			return m.find()
+		case batchModeScan:
+			return m.scan()
+		case batchModeUIDs:
+			return m.checkUIDs()
+		}
+
+		edit, err := operations.ParseBatchEdit(m.input.Value())
+		if err != nil {
+			m.inputErr = err
+			return nil
+		}
+		paths := m.paths
+		m.setState(batchPreviewing)
+		return m.run(func(updates batchUpdates, progress operations.Progress) tea.Msg {
+			result := operations.PreviewBatchEdit(paths, edit, progress)
+			return batchPreviewedMsg{updates: updates, preview: batchPreview{
+				description: edit.String(),
+				edit:        edit,
+				files:       result.Files,
+				changes:     result.Changes,
+				errors:      result.Errors.Errors(),
+			}}
+		})
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.inputErr = nil
+	return cmd
+}
+
+// find returns a command searching the parsed datasets of the files for the entered search.
+func (m *batchEditModel) find() tea.Cmd {
+	search, err := operations.ParseFindReplace(m.input.Value())
+	if err != nil {
+		m.inputErr = err
+		return nil
+	}
+
+	var files []*operations.ParsedDicomFile
+	for _, path := range m.paths {
+		if file, ok := m.files[path]; ok {
+			files = append(files, file)
+		}
+	}
+	m.setState(batchPreviewing)
+	return m.run(func(updates batchUpdates, progress operations.Progress) tea.Msg {
+		hits := operations.FindInFiles(files, search, progress)
+		preview := batchPreview{
+			description: search.String(),
+			files:       len(files),
+			changes:     operations.HitChanges(hits),
+			hits:        hits,
+		}
+		if hits == nil {
+			preview.hits = []operations.Hit{}
+		}
+		for _, hit := range hits {
+			if hit.Err != nil {
+				preview.errors = append(preview.errors, hit.Err)
+			}
+		}
+		return batchPreviewedMsg{updates: updates, preview: preview}
+	})
+}
+
+// scan returns a command scanning the files for PHI with the configured patterns and the entered
+// pattern, if any.
+func (m *batchEditModel) scan() tea.Cmd {
+	var patterns []operations.PHIPattern
+	if spec := strings.TrimSpace(m.input.Value()); spec != "" {
+		pattern, err := operations.ParsePHIPattern(spec)
+		if err != nil {
+			m.inputErr = err
+			return nil
+		}
+		patterns = append(patterns, pattern)
+	}
+	scanner, err := operations.NewDefaultPHIScanner(patterns...)
+	if err != nil {
+		m.inputErr = err
+		return nil
+	}
+
+	paths := m.paths
+	m.setState(batchPreviewing)
+	return m.run(func(updates batchUpdates, progress operations.Progress) tea.Msg {
+		report := scanner.ScanFiles(paths, progress)
+		preview := batchPreview{
+			description: "PHI scan",
+			files:       report.Files,
+			phi:         report.Hits,
+			warning:     report.Warning,
+			errors:      report.Errors.Errors(),
+		}
+		if report.Hits == nil {
+			preview.phi = []operations.PHIHit{}
+		}
+		return batchPreviewedMsg{updates: updates, preview: preview}
+	})
+}
+
+// checkUIDs returns a command checking the parsed datasets of the files for conflicting UIDs and
+// computing the changes fixing them.
+func (m *batchEditModel) checkUIDs() tea.Cmd {
+	var files []*operations.ParsedDicomFile
+	for _, path := range m.paths {
+		if file, ok := m.files[path]; ok {
+			files = append(files, file)
+		}
+	}
+	m.setState(batchPreviewing)
+	return m.run(func(updates batchUpdates, progress operations.Progress) tea.Msg {
+		report := operations.CheckUIDs(files, progress)
+		preview := batchPreview{
+			description: "regenerate conflicting UIDs",
+			files:       report.Files,
+			uids:        &report,
+		}
+		changes, err := report.FixChanges(files)
+		for _, change := range changes {
+			preview.changes = append(preview.changes, *change)
+		}
+		if err != nil {
+			preview.errors = []error{err}
+			var multi *tyroErrors.MultiError
+			if errors.As(err, &multi) {
+				preview.errors = multi.Errors()
+			}
+		}
+		return batchPreviewedMsg{updates: updates, preview: preview}
+	})
+}
+
+// apply returns a command writing the previewed changes as a single operation of the history.
+// While staging is enabled, the changes are staged instead.
+func (m *batchEditModel) apply() tea.Cmd {
+	if len(m.preview.changes) == 0 {
+		return nil
+	}
+	if m.staging.enabled {
+		return m.stage()
+	}
+
+	history, preview := m.history, m.preview
+	m.setState(batchApplying)
+	return m.run(func(updates batchUpdates, progress operations.Progress) tea.Msg {
+		if err := operations.CommitBatchEdit(preview.edit); err != nil {
+			return batchAppliedMsg{updates: updates, err: err}
+		}
+		op, err := history.DoWithProgress(preview.description, preview.changes, progress)
+		return batchAppliedMsg{updates: updates, op: op, err: err}
+	})
+}
+
+// stage applies the previewed changes to the parsed datasets of their files and adds them to the
+// staging area.
+func (m *batchEditModel) stage() tea.Cmd {
+	var staged []string
+	errs := tyroErrors.New()
+	changes := m.preview.changes
+	if err := operations.CommitBatchEdit(m.preview.edit); err != nil {
+		errs.Add(err)
+		changes = nil
+	}
+	for _, change := range changes {
+		file, ok := m.files[change.File]
+		if !ok {
+			errs.Add(fmt.Errorf("%s: file has not been discovered", change.File))
+			continue
+		}
+		if err := m.staging.Stage(&file.Dataset, change); err != nil {
+			errs.Add(fmt.Errorf("%s: %w", change.File, err))
+			continue
+		}
+		staged = append(staged, change.File)
+	}
+
+	m.setState(batchInput)
+	m.pane.Reset("")
+	tree := m.pane.tree.ExpandableTree
+	tree.AddNode(tree.Root, "result", textItemModel{
+		text: fmt.Sprintf("%s: staged %d changes, press s to review them", m.preview.description, len(staged)),
+	})
+	m.addErrors(errs.Errors())
+	m.pane.tree.SelectFirst()
+	m.pane.Refresh()
+
+	return func() tea.Msg { return stagingChangedMsg{files: staged} }
+}
+
+// run starts step in a goroutine and returns a command delivering its progress and result.
+func (m *batchEditModel) run(step func(updates batchUpdates, progress operations.Progress) tea.Msg) tea.Cmd {
+	updates := make(chan tea.Msg, 1)
+	m.updates = updates
+	m.done, m.total = 0, len(m.paths)
+
+	go func() {
+		result := step(updates, func(done, total int) {
+			// Progress is dropped while the UI has not caught up, the next update supersedes it.
+			select {
+			case updates <- batchProgressMsg{updates: updates, done: done, total: total}:
+			default:
+			}
+		})
+		updates <- result
+		close(updates)
+	}()
+	return waitForUpdate(updates)
+}
+
+// waitForUpdate returns a command receiving the next update of a step.
+func waitForUpdate(updates batchUpdates) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// previewed lists the changes of the previewed edit, one node per affected file, followed by the
+// files that cannot be edited. The hits of a search are listed grouped by file instead.
+func (m *batchEditModel) previewed(preview batchPreview) {
+	m.updates = nil
+	m.preview = preview
+	m.setState(batchPreviewed)
+
+	m.pane.Reset("")
+	switch {
+	case preview.phi != nil:
+		m.addPHIHits(preview)
+	case preview.uids != nil:
+		m.addUIDConflicts(preview)
+	case preview.hits != nil:
+		m.addHits(preview)
+	default:
+		m.addChanges(preview)
+	}
+	m.addErrors(preview.errors)
+	m.pane.tree.SelectFirst()
+	m.pane.Refresh()
+}
+
+// addChanges adds a node per affected file. Files with more than one change, e.g. de-identified
+// files, list their changes below their node.
+func (m *batchEditModel) addChanges(preview batchPreview) {
+	var files [][]operations.ElementChange
+	for i, change := range preview.changes {
+		if i == 0 || change.File != preview.changes[i-1].File {
+			files = append(files, nil)
+		}
+		files[len(files)-1] = append(files[len(files)-1], change)
+	}
+
+	tree := m.pane.tree.ExpandableTree
+	summary := fmt.Sprintf("%s: %d of %d files change", preview.description, len(files), preview.files)
+	node := tree.AddNode(tree.Root, "changes", textItemModel{text: summary})
+	for _, changes := range files {
+		file := changes[0].File
+		if len(changes) == 1 {
+			tree.AddNode(node, file, textItemModel{text: m.relative(file) + ": " + describeChange(changes[0])})
+			continue
+		}
+		fileNode := tree.AddNode(node, file, textItemModel{text: fmt.Sprintf("%s: %d changes", m.relative(file), len(changes))})
+		for i, change := range changes {
+			text := describeChange(change)
+			if change.Kind == operations.ChangeSet {
+				// Only set changes do not name the element themselves.
+				text = fmt.Sprintf("%s %s", change.Path, text)
+			}
+			tree.AddNode(fileNode, fmt.Sprint(i), textItemModel{text: text})
+		}
+	}
+}
+
+// addHits adds a node per file listing the hits of a search within the file.
+func (m *batchEditModel) addHits(preview batchPreview) {
+	files := 0
+	for i, hit := range preview.hits {
+		if i == 0 || hit.File != preview.hits[i-1].File {
+			files++
+		}
+	}
+
+	tree := m.pane.tree.ExpandableTree
+	summary := fmt.Sprintf("%s: %d hits in %d of %d files", preview.description, len(preview.hits), files, preview.files)
+	tree.AddNode(tree.Root, "summary", textItemModel{text: summary})
+
+	var node *expandableTree.Node
+	for i, hit := range preview.hits {
+		if i == 0 || hit.File != preview.hits[i-1].File {
+			node = tree.AddNode(tree.Root, hit.File, textItemModel{text: m.relative(hit.File)})
+		}
+		text := fmt.Sprintf("%s %q", hit.Path, hit.Old)
+		if hit.New != hit.Old {
+			text = fmt.Sprintf("%s %q → %q", hit.Path, hit.Old, hit.New)
+		}
+		if hit.Err != nil {
+			text += " (invalid)"
+		}
+		tree.AddNode(node, fmt.Sprint(i), textItemModel{text: text})
+	}
+}
+
+// addPHIHits adds a node per file listing the PHI found within the file.
+func (m *batchEditModel) addPHIHits(preview batchPreview) {
+	files := 0
+	for i, hit := range preview.phi {
+		if i == 0 || hit.File != preview.phi[i-1].File {
+			files++
+		}
+	}
+
+	tree := m.pane.tree.ExpandableTree
+	summary := fmt.Sprintf("%s: %d hits in %d of %d files", preview.description, len(preview.phi), files, preview.files)
+	if len(preview.phi) == 0 {
+		summary = fmt.Sprintf("%s: no PHI found in %d files", preview.description, preview.files)
+	}
+	tree.AddNode(tree.Root, "summary", textItemModel{text: summary})
+	if preview.warning != "" {
+		tree.AddNode(tree.Root, "warning", textItemModel{text: "warning: " + preview.warning})
+	}
+
+	var node *expandableTree.Node
+	for i, hit := range preview.phi {
+		if i == 0 || hit.File != preview.phi[i-1].File {
+			node = tree.AddNode(tree.Root, hit.File, textItemModel{text: m.relative(hit.File)})
+		}
+		text := fmt.Sprintf("%s [%s] %q in %q", hit.Path, hit.Kind, hit.Match, hit.Value)
+		tree.AddNode(node, fmt.Sprint(i), textItemModel{text: text})
+	}
+}
+
+// addUIDConflicts adds a node per level listing its conflicting UIDs, the groups of files sharing
+// each UID and their files, followed by the changes fixing them.
+func (m *batchEditModel) addUIDConflicts(preview batchPreview) {
+	report := preview.uids
+	tree := m.pane.tree.ExpandableTree
+	if len(report.Conflicts) == 0 {
+		tree.AddNode(tree.Root, "summary", textItemModel{text: fmt.Sprintf("no conflicting UIDs in %d files", report.Files)})
+		return
+	}
+
+	levels := map[operations.UIDLevel]*expandableTree.Node{}
+	for i, conflict := range report.Conflicts {
+		level, ok := levels[conflict.Level]
+		if !ok {
+			text := fmt.Sprintf("%d conflicting %s UIDs", report.Len(conflict.Level), conflict.Level)
+			level = tree.AddNode(tree.Root, string(conflict.Level), textItemModel{text: text})
+			levels[conflict.Level] = level
+		}
+		text := fmt.Sprintf("%s shared by %d groups", conflict.UID, len(conflict.Groups))
+		node := tree.AddNode(level, fmt.Sprint(i), textItemModel{text: text})
+		for j, group := range conflict.Groups {
+			text := fmt.Sprintf("%s: %d files", group.Owner, len(group.Files))
+			if j == 0 {
+				text += ", keeps the UID"
+			}
+			groupNode := tree.AddNode(node, fmt.Sprint(j), textItemModel{text: text})
+			for _, path := range group.Files {
+				tree.AddNode(groupNode, path, textItemModel{text: m.relative(path)})
+			}
+		}
+	}
+	if len(preview.changes) > 0 {
+		m.addChanges(preview)
+	}
+}
+
+// applied shows the result of writing the changes and returns to the input.
+func (m *batchEditModel) applied(msg batchAppliedMsg) {
+	m.updates = nil
+	m.setState(batchInput)
+
+	if msg.err == nil {
+		m.pane.Reset(fmt.Sprintf("%s: %d files written, press u to undo", m.preview.description, len(msg.op.Files())))
+		return
+	}
+
+	m.pane.Reset("")
+	tree := m.pane.tree.ExpandableTree
+	tree.AddNode(tree.Root, "result", textItemModel{text: fmt.Sprintf("%s: no files written", m.preview.description)})
+	errs := []error{msg.err}
+	var multi *tyroErrors.MultiError
+	if errors.As(msg.err, &multi) {
+		errs = multi.Errors()
+	}
+	m.addErrors(errs)
+	m.pane.tree.SelectFirst()
+	m.pane.Refresh()
+}
+
+// addErrors adds a node listing errs to the tree, if there are any.
+func (m *batchEditModel) addErrors(errs []error) {
+	if len(errs) == 0 {
+		return
+	}
+	tree := m.pane.tree.ExpandableTree
+	node := tree.AddNode(tree.Root, "errors", textItemModel{text: fmt.Sprintf("%d errors", len(errs))})
+	for i, err := range errs {
+		tree.AddNode(node, fmt.Sprint(i), textItemModel{text: strings.TrimPrefix(err.Error(), m.root+string(filepath.Separator))})
+	}
+}
+
+// relative returns path relative to the root of the edit.
+func (m *batchEditModel) relative(path string) string {
+	if rel, err := filepath.Rel(m.root, path); err == nil && rel != "." {
+		return rel
+	}
+	return filepath.Base(path)
+}
+
+// describeChange summarizes the effect of change on the value of its element.
+func describeChange(change operations.ElementChange) string {
+	switch change.Kind {
+	case operations.ChangeSet:
+		return fmt.Sprintf("%q → %q", change.Old, change.New)
+	case operations.ChangeAdd:
+		return fmt.Sprintf("add %s %q", change.Path, change.New)
+	case operations.ChangeRemove:
+		return fmt.Sprintf("remove %s", change.Path)
+	case operations.ChangeFileMeta:
+		return "regenerate file meta information"
+	case operations.ChangeTranscode:
+		return fmt.Sprintf("transcode %s → %s", operations.TransferSyntaxName(change.Old), operations.TransferSyntaxName(change.New))
+	case operations.ChangeBlank:
+		return fmt.Sprintf("blank pixel regions %s", change.New)
+	}
+	return fmt.Sprintf("%s %s", change.Kind, change.Path)
+}
+
+// progressBar renders done out of total as a bar fitting into width columns followed by the counts.
+func progressBar(done, total, width int) string {
+	counts := fmt.Sprintf(" %d/%d", done, total)
+	barWidth := max(0, min(40, width-lipgloss.Width("writing files ")-len(counts)-2))
+	filled := 0
+	if total > 0 {
+		filled = barWidth * done / total
+	}
+	return "[" + strings.Repeat("█", filled) + strings.Repeat(" ", barWidth-filled) + "]" + counts
+}
+
+// filesBelow returns the paths of all DICOM files represented by n and its descendants in the
+// file tree.
+func filesBelow(n *expandableTree.Node) []string {
+	var paths []string
+	if item, ok := n.Model.(FileTreeItemModel); ok && item.File != nil {
+		paths = append(paths, item.File.Path)
+	}
+	for _, child := range n.Children {
+		paths = append(paths, filesBelow(child)...)
+	}
+	return paths
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/streimelstefan/tyro/operations"
+	"github.com/streimelstefan/tyro/ui/expandableTree"
+)
+
+// textItemModel is a tree item that displays a fixed line of text.
+type textItemModel struct {
+	text string
+}
+
+func (m textItemModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m textItemModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return m, nil
+}
+
+func (m textItemModel) View() string {
+	return m.text
+}
+
+// documentModel displays the document content of the selected file: the content tree of a
+// Structured Report or a summary of an encapsulated document.
+type documentModel struct {
+	pane *treePaneModel
+}
+
+// NewDocumentModel creates an empty document pane.
+func NewDocumentModel() *documentModel {
+	return &documentModel{
+		pane: newTreePaneModel(),
+	}
+}
+
+func (m *documentModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update rebuilds the document tree whenever the file selection changes.
+func (m *documentModel) Update(msg tea.Msg) (*documentModel, tea.Cmd) {
+	if msg, ok := msg.(FileSelectedMsg); ok {
+		m.load(msg.File)
+	}
+	return m, nil
+}
+
+func (m *documentModel) View() string {
+	return m.pane.View()
+}
+
+// SetSize sets the number of terminal columns and rows available to the pane.
+func (m *documentModel) SetSize(width, height int) {
+	m.pane.SetSize(width, height)
+}
+
+// load fills the tree with the document content of file.
+func (m *documentModel) load(file *operations.ParsedDicomFile) {
+	if file == nil {
+		m.pane.Reset("No file selected")
+		return
+	}
+
+	if doc, err := operations.GetEncapsulatedDocument(file.Dataset); err == nil {
+		m.pane.Reset("")
+		tree := m.pane.tree.ExpandableTree
+		root := tree.AddNode(tree.Root, "document", textItemModel{text: "Encapsulated document"})
+		tree.AddNode(root, "title", textItemModel{text: "Title: " + doc.Title})
+		tree.AddNode(root, "mime", textItemModel{text: "MIME type: " + doc.MIMEType})
+		tree.AddNode(root, "size", textItemModel{text: fmt.Sprintf("Size: %d bytes", len(doc.Data))})
+		tree.AddNode(root, "hint", textItemModel{text: "Press x to extract it to " + exportDir})
+		m.pane.tree.SelectFirst()
+		m.pane.Refresh()
+		return
+	}
+
+	report, err := operations.ParseStructuredReport(file.Dataset)
+	if err != nil {
+		m.pane.Reset("Neither a structured report nor an encapsulated document")
+		return
+	}
+
+	m.pane.Reset("")
+	m.addContentItem(m.pane.tree.ExpandableTree.Root, report, "1")
+	m.pane.tree.SelectFirst()
+	m.pane.Refresh()
+}
+
+// addContentItem adds an SR content item and all of its children below parent.
+//
+// path is the position of the item in the content tree (e.g. 1.2.3), as used by by-reference relationships.
+func (m *documentModel) addContentItem(parent *expandableTree.Node, item *operations.SRContentItem, path string) {
+	node := m.pane.tree.ExpandableTree.AddNode(parent, path, textItemModel{text: item.String()})
+	for i, child := range item.Children {
+		m.addContentItem(node, child, fmt.Sprintf("%s.%d", path, i+1))
+	}
+}
@@ -23,6 +23,9 @@ type ExpandableTree struct {
 	Root *node
 }
 
+// Node is the exported name of a tree node, allowing other packages to build trees recursively.
+type Node = node
+
 type node struct {
 	Identifier string
 	Model      tea.Model
@@ -35,6 +38,7 @@ type node struct {
 
 	isRoot bool
 	level  int
+	parent *node
 }
 
 func NewExpandableTree() *ExpandableTree {
@@ -52,20 +56,21 @@ func NewExpandableTree() *ExpandableTree {
 	}
 }
 
-func newNode(identifier string, model tea.Model, level int) *node {
+func newNode(identifier string, model tea.Model, parent *node) *node {
 	return &node{
 		Model:      model,
 		Children:   make([]*node, 0),
 		IsExpanded: true,
 		IsSelected: false,
 		isRoot:     false,
-		level:      level,
+		level:      parent.level + 1,
+		parent:     parent,
 		Identifier: identifier,
 	}
 }
 
 func (e *ExpandableTree) AddNode(parent *node, identifier string, model tea.Model) *node {
-	newNode := newNode(identifier, model, parent.level+1)
+	newNode := newNode(identifier, model, parent)
 	parent.Children = append(parent.Children, newNode)
 	return newNode
 }
@@ -86,3 +91,37 @@ func (n node) GetChild(identifier string) *node {
 	}
 	return nil
 }
+
+// VisibleNodes returns all nodes that are currently rendered, in render order.
+//
+// The root node is never part of the result. Children of collapsed and filtered out nodes are skipped.
+func (e *ExpandableTree) VisibleNodes() []*node {
+	nodes := make([]*node, 0)
+	var collect func(n *node)
+	collect = func(n *node) {
+		for _, child := range n.Children {
+			if child.IsFilteredOut {
+				continue
+			}
+			nodes = append(nodes, child)
+			if child.IsExpanded {
+				collect(child)
+			}
+		}
+	}
+	collect(e.Root)
+	return nodes
+}
+
+// Parent returns the parent of the node or nil if the node is the root or a top level node.
+func (n node) Parent() *node {
+	if n.parent == nil || n.parent.isRoot {
+		return nil
+	}
+	return n.parent
+}
+
+// Level returns the depth of the node in the tree, starting with 0 for top level nodes.
+func (n node) Level() int {
+	return n.level
+}
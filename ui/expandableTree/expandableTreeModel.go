@@ -4,8 +4,20 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	defaults "github.com/streimelstefan/tyro/ui/defaults"
 )
 
+// SelectionChangedMsg is emitted by a Model whenever its selected node changes.
+type SelectionChangedMsg struct {
+	// Tree is the Model whose selection changed.
+	Tree *Model
+	// Identifier is the identifier of the newly selected node.
+	Identifier string
+	// Model is the model of the newly selected node.
+	Model tea.Model
+}
+
 type Model struct {
 	ExpandableTree *ExpandableTree
 
@@ -14,6 +26,10 @@ type Model struct {
 	Branch    string
 	Collapsed string
 	BranchEnd string
+
+	SelectedStyle lipgloss.Style
+
+	selected *node
 }
 
 func New() *Model {
@@ -24,6 +40,9 @@ func New() *Model {
 		Expanded:       "",
 		Collapsed:      "+ ",
 		BranchEnd:      "└─ ",
+		SelectedStyle: lipgloss.NewStyle().
+			Foreground(defaults.BackgroundColor).
+			Background(defaults.AccentColor),
 	}
 }
 
@@ -31,10 +50,131 @@ func (m *Model) Init() tea.Cmd {
 	return nil
 }
 
+// Update handles the navigation keys of the tree.
+//
+// up/k and down/j move the selection, left/h collapses the selected node or jumps to its parent,
+// right/l expands the selected node and enter/space toggles it. A SelectionChangedMsg is emitted
+// whenever the selected node changes.
 func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	previous := m.selected
+	switch keyMsg.String() {
+	case "up", "k":
+		m.moveSelection(-1)
+	case "down", "j":
+		m.moveSelection(1)
+	case "pgup":
+		m.moveSelection(-10)
+	case "pgdown":
+		m.moveSelection(10)
+	case "home", "g":
+		m.moveSelection(-len(m.ExpandableTree.VisibleNodes()))
+	case "end", "G":
+		m.moveSelection(len(m.ExpandableTree.VisibleNodes()))
+	case "left", "h":
+		if m.selected == nil {
+			break
+		}
+		if m.selected.IsExpanded && m.selected.HasChildren() {
+			m.selected.IsExpanded = false
+		} else if parent := m.selected.Parent(); parent != nil {
+			m.Select(parent)
+		}
+	case "right", "l":
+		if m.selected != nil && m.selected.HasChildren() {
+			m.selected.IsExpanded = true
+		}
+	case "enter", " ":
+		if m.selected != nil && m.selected.HasChildren() {
+			m.selected.IsExpanded = !m.selected.IsExpanded
+		}
+	}
+
+	if previous != m.selected {
+		return m, m.selectionChanged
+	}
 	return m, nil
 }
 
+// Selected returns the currently selected node or nil if no node is selected.
+func (m *Model) Selected() *node {
+	return m.selected
+}
+
+// Select marks the given node as the selected node of the tree. Passing nil clears the selection.
+func (m *Model) Select(n *node) {
+	if m.selected != nil {
+		m.selected.IsSelected = false
+	}
+	m.selected = n
+	if n != nil {
+		n.IsSelected = true
+	}
+}
+
+// SelectFirst selects the first visible node if no node is selected yet.
+//
+// It returns a command emitting a SelectionChangedMsg if the selection changed.
+func (m *Model) SelectFirst() tea.Cmd {
+	if m.selected != nil {
+		return nil
+	}
+	nodes := m.ExpandableTree.VisibleNodes()
+	if len(nodes) == 0 {
+		return nil
+	}
+	m.Select(nodes[0])
+	return m.selectionChanged
+}
+
+// Clear removes all nodes from the tree and resets the selection.
+func (m *Model) Clear() {
+	m.ExpandableTree = NewExpandableTree()
+	m.selected = nil
+}
+
+// SelectedLine returns the line index of the selected node in the rendered view or -1 if no
+// visible node is selected.
+func (m *Model) SelectedLine() int {
+	for i, n := range m.ExpandableTree.VisibleNodes() {
+		if n == m.selected {
+			return i
+		}
+	}
+	return -1
+}
+
+// moveSelection moves the selection by delta visible nodes, clamping at both ends.
+func (m *Model) moveSelection(delta int) {
+	nodes := m.ExpandableTree.VisibleNodes()
+	if len(nodes) == 0 {
+		return
+	}
+
+	index := m.SelectedLine()
+	if index < 0 {
+		m.Select(nodes[0])
+		return
+	}
+
+	index = max(0, min(len(nodes)-1, index+delta))
+	m.Select(nodes[index])
+}
+
+// selectionChanged is a tea.Cmd that reports the current selection.
+func (m *Model) selectionChanged() tea.Msg {
+	msg := SelectionChangedMsg{Tree: m}
+	if m.selected != nil {
+		msg.Identifier = m.selected.Identifier
+		msg.Model = m.selected.Model
+	}
+	return msg
+}
+
 func (m Model) View() string {
 	b := strings.Builder{}
 
@@ -71,8 +211,16 @@ func (m Model) renderTreeNode(node *node, isLast bool, b *strings.Builder) {
 		}
 	}
 
-	b.WriteString(node.Model.View())
-	b.WriteRune('\n')
+	if node.isRoot {
+		b.WriteString(node.Model.View())
+	} else {
+		if node.IsSelected {
+			b.WriteString(m.SelectedStyle.Render(node.Model.View()))
+		} else {
+			b.WriteString(node.Model.View())
+		}
+		b.WriteRune('\n')
+	}
 
 	if node.HasChildren() && node.IsExpanded {
 		for i, child := range node.Children {
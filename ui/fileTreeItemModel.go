@@ -1,9 +1,15 @@
 package ui
 
-import tea "github.com/charmbracelet/bubbletea"
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/streimelstefan/tyro/operations"
+)
 
 type FileTreeItemModel struct {
 	Part string
+
+	// File is the parsed DICOM file represented by this item. It is nil for directories.
+	File *operations.ParsedDicomFile
 }
 
 func NewFileTreeItemModel(part string) FileTreeItemModel {
@@ -12,6 +18,14 @@ func NewFileTreeItemModel(part string) FileTreeItemModel {
 	}
 }
 
+// NewFileTreeFileItemModel creates a tree item representing a parsed DICOM file.
+func NewFileTreeFileItemModel(part string, file *operations.ParsedDicomFile) FileTreeItemModel {
+	return FileTreeItemModel{
+		Part: part,
+		File: file,
+	}
+}
+
 func (m FileTreeItemModel) Init() tea.Cmd {
 	return nil
 }
@@ -20,6 +34,18 @@ func (m FileTreeItemModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// View renders the name of the item. Files with inconsistent File Meta Information and files at
+// risk of burned-in annotations are marked.
 func (m FileTreeItemModel) View() string {
-	return m.Part
+	text := m.Part
+	if m.File == nil {
+		return text
+	}
+	if m.File.MetaIssues != nil {
+		text += " ⚠"
+	}
+	if operations.BurnedInAnnotationRisk(m.File.Dataset) != "" {
+		text += " ▣"
+	}
+	return text
 }
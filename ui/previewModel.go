@@ -0,0 +1,259 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/streimelstefan/tyro/operations"
+	defaults "github.com/streimelstefan/tyro/ui/defaults"
+)
+
+// previewLoadedMsg is sent once the pixel data of a file has been loaded for the preview.
+type previewLoadedMsg struct {
+	// generation is the generation of the preview the load was started in.
+	generation int
+	pixels     *operations.PixelData
+	err        error
+}
+
+// previewModel renders the selected frame of the selected file using Unicode half-block characters.
+//
+// Each terminal cell displays two vertically stacked pixels: the upper one as foreground color of
+// the "▀" character and the lower one as background color. Truecolor escape sequences are used if
+// the terminal supports them, otherwise colors are approximated with the xterm 256-color palette.
+type previewModel struct {
+	visible bool
+
+	path   string
+	pixels *operations.PixelData
+	frame  int
+	window operations.Window
+	err    error
+	// loading reports whether the pixel data of the current generation is being loaded.
+	loading bool
+	// generation is advanced whenever the selection changes, so loads of a previous selection
+	// are told apart from the load of the current one.
+	generation int
+
+	width  int
+	height int
+
+	profile     termenv.Profile
+	headerStyle lipgloss.Style
+	errorStyle  lipgloss.Style
+}
+
+// NewPreviewModel creates a hidden preview using the color profile of the current terminal.
+func NewPreviewModel() *previewModel {
+	return &previewModel{
+		profile: termenv.EnvColorProfile(),
+		headerStyle: lipgloss.NewStyle().
+			Foreground(defaults.TextColor).
+			Background(defaults.InfoColor),
+		errorStyle: lipgloss.NewStyle().
+			Foreground(defaults.ErrorColor),
+	}
+}
+
+func (m *previewModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles file selection changes, loaded pixel data and the preview keys.
+//
+// [ and ] step through the frames, - and = narrow or widen the window, , and . lower or raise
+// the window center and 0 resets the window to the one stored in the dataset.
+func (m *previewModel) Update(msg tea.Msg) (*previewModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case FileSelectedMsg:
+		path := ""
+		if msg.File != nil {
+			path = msg.File.Path
+		}
+		if path == m.path {
+			return m, nil
+		}
+		// A directory clears the preview.
+		m.path = path
+		m.pixels = nil
+		m.err = nil
+		m.loading = false
+		m.generation++
+		if m.visible && m.path != "" {
+			return m, m.load()
+		}
+	case previewLoadedMsg:
+		if msg.generation != m.generation {
+			return m, nil
+		}
+		m.loading = false
+		m.pixels = msg.pixels
+		m.err = msg.err
+		m.frame = 0
+		if m.pixels != nil {
+			m.window = m.pixels.DefaultWindow(0)
+		}
+	case tea.KeyMsg:
+		if !m.visible || m.pixels == nil {
+			return m, nil
+		}
+		step := math.Max(1, m.window.Width/20)
+		switch msg.String() {
+		case "[":
+			m.frame = max(0, m.frame-1)
+		case "]":
+			m.frame = min(len(m.pixels.Frames)-1, m.frame+1)
+		case "-":
+			m.window.Width = math.Max(1, m.window.Width-step)
+		case "=", "+":
+			m.window.Width += step
+		case ",":
+			m.window.Center -= step
+		case ".":
+			m.window.Center += step
+		case "0":
+			m.window = m.pixels.DefaultWindow(m.frame)
+		}
+	}
+	return m, nil
+}
+
+// SetVisible shows or hides the preview. When the preview becomes visible the pixel data of the
+// selected file is loaded if it has not been loaded yet.
+func (m *previewModel) SetVisible(visible bool) tea.Cmd {
+	m.visible = visible
+	if m.visible && m.path != "" && m.pixels == nil && !m.loading {
+		return m.load()
+	}
+	return nil
+}
+
+// SetSize sets the number of terminal columns and rows available to the preview.
+func (m *previewModel) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+func (m *previewModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	switch {
+	case m.path == "":
+		return "No file selected"
+	case m.loading:
+		return "Loading pixel data..."
+	case m.err != nil:
+		return m.errorStyle.Render(fmt.Sprintf("Cannot preview %s: %v", m.path, m.err))
+	case m.pixels == nil:
+		return ""
+	}
+
+	header := fmt.Sprintf("frame %d/%d  WC %.1f  WW %.1f", m.frame+1, len(m.pixels.Frames), m.window.Center, m.window.Width)
+	if !m.pixels.Info.IsMonochrome() {
+		header = fmt.Sprintf("frame %d/%d  %s", m.frame+1, len(m.pixels.Frames), m.pixels.Info.PhotometricInterpretation)
+	}
+
+	img, err := m.pixels.RenderFrame(m.frame, m.window)
+	if err != nil {
+		return m.errorStyle.Render(err.Error())
+	}
+
+	return m.headerStyle.Width(m.width).Render(header) + "\n" + renderHalfBlocks(img, m.width, m.height-1, m.profile)
+}
+
+// load returns a command that loads the pixel data of the selected file in the background.
+func (m *previewModel) load() tea.Cmd {
+	m.loading = true
+	path, generation := m.path, m.generation
+	return func() tea.Msg {
+		pixels, err := operations.LoadPixelData(path)
+		return previewLoadedMsg{generation: generation, pixels: pixels, err: err}
+	}
+}
+
+// renderHalfBlocks renders an image into at most cols x rows terminal cells, preserving its aspect ratio.
+func renderHalfBlocks(img image.Image, cols, rows int, profile termenv.Profile) string {
+	bounds := img.Bounds()
+	if cols <= 0 || rows <= 0 || bounds.Empty() {
+		return ""
+	}
+
+	scale := math.Min(float64(cols)/float64(bounds.Dx()), float64(rows*2)/float64(bounds.Dy()))
+	outWidth := max(1, int(float64(bounds.Dx())*scale))
+	outHeight := max(2, int(float64(bounds.Dy())*scale))
+
+	at := func(x, y int) color.RGBA {
+		if y >= outHeight {
+			return color.RGBA{}
+		}
+		srcX := bounds.Min.X + x*bounds.Dx()/outWidth
+		srcY := bounds.Min.Y + y*bounds.Dy()/outHeight
+		r, g, b, _ := img.At(srcX, srcY).RGBA()
+		return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 255}
+	}
+
+	b := strings.Builder{}
+	for y := 0; y < outHeight; y += 2 {
+		for x := 0; x < outWidth; x++ {
+			b.WriteString(cellEscape(at(x, y), at(x, y+1), profile))
+			b.WriteString("▀")
+		}
+		b.WriteString("\x1b[0m")
+		if y+2 < outHeight {
+			b.WriteRune('\n')
+		}
+	}
+	return b.String()
+}
+
+// cellEscape returns the escape sequence setting the foreground to top and the background to bottom.
+func cellEscape(top, bottom color.RGBA, profile termenv.Profile) string {
+	if profile == termenv.TrueColor {
+		return fmt.Sprintf("\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm", top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+	}
+	return fmt.Sprintf("\x1b[38;5;%dm\x1b[48;5;%dm", ansi256(top), ansi256(bottom))
+}
+
+// ansi256 returns the index of the xterm 256-color palette entry closest to c.
+//
+// Both the 6x6x6 color cube and the 24 step grayscale ramp are considered.
+func ansi256(c color.RGBA) int {
+	cubeLevels := [6]int{0, 95, 135, 175, 215, 255}
+	toCube := func(v uint8) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return (int(v) - 35) / 40
+	}
+
+	r, g, b := toCube(c.R), toCube(c.G), toCube(c.B)
+	cubeIndex := 16 + 36*r + 6*g + b
+	cubeDistance := colorDistance(c, cubeLevels[r], cubeLevels[g], cubeLevels[b])
+
+	average := (int(c.R) + int(c.G) + int(c.B)) / 3
+	grayStep := max(0, min(23, (average-3)/10))
+	grayLevel := 8 + 10*grayStep
+	grayDistance := colorDistance(c, grayLevel, grayLevel, grayLevel)
+
+	if grayDistance < cubeDistance {
+		return 232 + grayStep
+	}
+	return cubeIndex
+}
+
+// colorDistance returns the squared euclidean distance between c and the given color components.
+func colorDistance(c color.RGBA, r, g, b int) int {
+	dr, dg, db := int(c.R)-r, int(c.G)-g, int(c.B)-b
+	return dr*dr + dg*dg + db*db
+}
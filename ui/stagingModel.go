@@ -0,0 +1,273 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/streimelstefan/tyro/operations"
+	"github.com/streimelstefan/tyro/ui/statusbar"
+)
+
+// stagingArea is the staging area shared by all panes that modify files.
+type stagingArea struct {
+	*operations.Staging
+
+	// enabled reports whether edits are staged instead of being written immediately.
+	enabled bool
+}
+
+// reload reads file again and applies its staged changes, so the parsed dataset reflects the
+// staged state of the file.
+func (s *stagingArea) reload(file *operations.ParsedDicomFile) error {
+	if err := file.Reload(); err != nil {
+		return err
+	}
+	return s.ApplyTo(file.Path, &file.Dataset)
+}
+
+// stagingChangedMsg is sent whenever changes have been staged or discarded.
+type stagingChangedMsg struct {
+	// files are the paths of the files whose parsed datasets have been replaced. Panes showing
+	// one of them have to be rebuilt.
+	files []string
+}
+
+// stagingCommittedMsg is sent once the staged changes have been committed.
+type stagingCommittedMsg struct {
+	op  operations.Operation
+	err error
+}
+
+// stagedChangeItemModel is a tree item representing a single staged change.
+type stagedChangeItemModel struct {
+	// Index is the position of the change within the staging area.
+	Index  int
+	Change operations.ElementChange
+}
+
+func (m stagedChangeItemModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m stagedChangeItemModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return m, nil
+}
+
+func (m stagedChangeItemModel) View() string {
+	if m.Change.Kind == operations.ChangeSet {
+		return fmt.Sprintf("%s %s", m.Change.Path, describeChange(m.Change))
+	}
+	return describeChange(m.Change)
+}
+
+// stagedFileItemModel is a tree item grouping the staged changes of a single file.
+type stagedFileItemModel struct {
+	Path    string
+	Changes int
+}
+
+func (m stagedFileItemModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m stagedFileItemModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return m, nil
+}
+
+func (m stagedFileItemModel) View() string {
+	return fmt.Sprintf("%s (%d changes)", m.Path, m.Changes)
+}
+
+// stagingModel lists the staged changes grouped by file for review.
+//
+// While the pane has the keyboard focus, delete discards the selected change or all changes of
+// the selected file and c commits all staged changes as a single operation.
+type stagingModel struct {
+	pane *treePaneModel
+
+	staging *stagingArea
+	history *operations.History
+	// files holds all discovered files by path.
+	files map[string]*operations.ParsedDicomFile
+
+	focused    bool
+	committing bool
+	// result summarizes the last commit. It is shown until the staged changes are modified.
+	result *stagingCommittedMsg
+}
+
+// NewStagingModel creates the review pane of staging, committing through history. files are the
+// parsed files the changes have been staged on.
+func NewStagingModel(staging *stagingArea, history *operations.History, files map[string]*operations.ParsedDicomFile) *stagingModel {
+	m := &stagingModel{
+		pane:    newTreePaneModel(),
+		staging: staging,
+		history: history,
+		files:   files,
+	}
+	m.Load()
+	return m
+}
+
+func (m *stagingModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update rebuilds the list whenever the staged changes change and handles the keys of the list
+// while the pane has the focus.
+func (m *stagingModel) Update(msg tea.Msg) (*stagingModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case stagingChangedMsg:
+		m.result = nil
+		m.Load()
+	case stagingCommittedMsg:
+		m.committing = false
+		m.result = &msg
+		m.Load()
+	case tea.KeyMsg:
+		if !m.focused {
+			return m, nil
+		}
+		switch msg.String() {
+		case "delete":
+			return m, m.discard()
+		case "c":
+			return m, m.commit()
+		}
+		var cmd tea.Cmd
+		m.pane, cmd = m.pane.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m *stagingModel) View() string {
+	return m.pane.View()
+}
+
+// SetSize sets the number of terminal columns and rows available to the pane.
+func (m *stagingModel) SetSize(width, height int) {
+	m.pane.SetSize(width, height)
+}
+
+// SetFocused sets whether the pane receives key messages.
+func (m *stagingModel) SetFocused(focused bool) {
+	m.focused = focused
+}
+
+// Load fills the list with the staged changes, preceded by the result of the last commit.
+func (m *stagingModel) Load() {
+	mode := "Staging is off, press S to stage edits instead of writing them"
+	if m.staging.enabled {
+		mode = "Staging is on, press S to write edits immediately"
+	}
+	m.pane.Reset("No staged changes. " + mode)
+	tree := m.pane.tree.ExpandableTree
+
+	if m.result != nil {
+		summary := "Commit failed"
+		if len(m.result.op.Changes) > 0 {
+			summary = fmt.Sprintf("Committed %d changes to %d files, press u to undo", len(m.result.op.Changes), len(m.result.op.Files()))
+		}
+		node := tree.AddNode(tree.Root, "result", textItemModel{text: summary})
+		errs := []error{m.result.err}
+		var multi *tyroErrors.MultiError
+		if errors.As(m.result.err, &multi) {
+			errs = multi.Errors()
+		}
+		for i, err := range errs {
+			if err != nil {
+				tree.AddNode(node, fmt.Sprint(i), textItemModel{text: err.Error()})
+			}
+		}
+	}
+
+	changes := m.staging.Changes()
+	if len(changes) > 0 {
+		tree.AddNode(tree.Root, "mode", textItemModel{text: mode + ", c to commit"})
+	}
+	var fileNodes []string
+	counts := map[string]int{}
+	for _, change := range changes {
+		if counts[change.File] == 0 {
+			fileNodes = append(fileNodes, change.File)
+		}
+		counts[change.File]++
+	}
+	for _, path := range fileNodes {
+		node := tree.AddNode(tree.Root, path, stagedFileItemModel{Path: path, Changes: counts[path]})
+		for i, change := range changes {
+			if change.File == path {
+				tree.AddNode(node, fmt.Sprint(i), stagedChangeItemModel{Index: i, Change: change})
+			}
+		}
+	}
+
+	m.pane.tree.SelectFirst()
+	m.pane.Refresh()
+}
+
+// discard removes the selected change, or all changes of the selected file, from the staging
+// area and reads the file again, so its parsed dataset only reflects the remaining changes.
+func (m *stagingModel) discard() tea.Cmd {
+	selected := m.pane.tree.Selected()
+	if selected == nil {
+		return nil
+	}
+
+	var path string
+	var indices []int
+	switch item := selected.Model.(type) {
+	case stagedChangeItemModel:
+		path, indices = item.Change.File, []int{item.Index}
+	case stagedFileItemModel:
+		path = item.Path
+		for _, child := range selected.Children {
+			if change, ok := child.Model.(stagedChangeItemModel); ok {
+				indices = append(indices, change.Index)
+			}
+		}
+	default:
+		return nil
+	}
+
+	// Later changes are discarded first, so the indices of earlier ones remain valid.
+	for i := len(indices) - 1; i >= 0; i-- {
+		if _, err := m.staging.Discard(indices[i]); err != nil {
+			return statusbar.Error(err)
+		}
+	}
+
+	cmds := []tea.Cmd{
+		statusbar.Message(fmt.Sprintf("discarded %d staged changes of %s", len(indices), path)),
+		func() tea.Msg { return stagingChangedMsg{files: []string{path}} },
+	}
+	if file, ok := m.files[path]; ok {
+		if err := m.staging.reload(file); err != nil {
+			cmds = append(cmds, statusbar.Error(fmt.Errorf("discarded changes but %s does not reflect the remaining ones: %w", path, err)))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// commit returns a command committing all staged changes.
+func (m *stagingModel) commit() tea.Cmd {
+	if m.committing {
+		return nil
+	}
+	if m.staging.Len() == 0 {
+		return statusbar.Error(operations.ErrorNothingStaged)
+	}
+
+	m.committing = true
+	staging, history := m.staging, m.history
+	return tea.Batch(
+		statusbar.Message(fmt.Sprintf("committing %d staged changes", staging.Len())),
+		func() tea.Msg {
+			op, err := staging.Commit(history, nil)
+			return stagingCommittedMsg{op: op, err: err}
+		},
+	)
+}
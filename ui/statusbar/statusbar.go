@@ -6,16 +6,29 @@ import (
 	defaults "github.com/streimelstefan/tyro/ui/defaults"
 )
 
+// MessageMsg sets the message displayed next to the folder in the status bar.
+type MessageMsg struct {
+	// Text is the message to display. An empty text clears the message.
+	Text string
+	// IsError renders the message using the error style.
+	IsError bool
+}
+
 type Model struct {
 	Folder string
 
 	Style *StatusBarStyle
 
 	width int
+
+	message string
+	isError bool
 }
 
 type StatusBarStyle struct {
-	FolderStyle lipgloss.Style
+	FolderStyle  lipgloss.Style
+	MessageStyle lipgloss.Style
+	ErrorStyle   lipgloss.Style
 }
 
 func New(folder string) *Model {
@@ -26,18 +39,58 @@ func New(folder string) *Model {
 				Foreground(defaults.TextColor).
 				Background(defaults.AccentColor).
 				Padding(0, 0, 0, 0),
+			MessageStyle: lipgloss.NewStyle().
+				Foreground(defaults.TextColor).
+				Background(defaults.BackgroundColor).
+				Padding(0, 1),
+			ErrorStyle: lipgloss.NewStyle().
+				Foreground(defaults.TextColor).
+				Background(defaults.ErrorColor).
+				Padding(0, 1),
 		},
 	}
 }
 
+// Message returns a command that displays text in the status bar.
+func Message(text string) tea.Cmd {
+	return func() tea.Msg {
+		return MessageMsg{Text: text}
+	}
+}
+
+// Error returns a command that displays err in the status bar using the error style.
+func Error(err error) tea.Cmd {
+	return func() tea.Msg {
+		return MessageMsg{Text: err.Error(), IsError: true}
+	}
+}
+
 func (m *Model) Init() tea.Cmd {
 	return nil
 }
 
 func (m *Model) View() string {
-	return ""
+	folder := m.Style.FolderStyle.Render(m.Folder)
+
+	messageStyle := m.Style.MessageStyle
+	if m.isError {
+		messageStyle = m.Style.ErrorStyle
+	}
+	messageWidth := max(0, m.width-lipgloss.Width(folder))
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, folder, messageStyle.
+		Width(messageWidth).
+		MaxHeight(1).
+		Render(m.message))
 }
 
 func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case MessageMsg:
+		m.message = msg.Text
+		m.isError = msg.IsError
+	}
 	return m, nil
 }
@@ -0,0 +1,720 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	tyroErrors "github.com/streimelstefan/tyro/errors"
+	"github.com/streimelstefan/tyro/operations"
+	defaults "github.com/streimelstefan/tyro/ui/defaults"
+	"github.com/streimelstefan/tyro/ui/expandableTree"
+	"github.com/streimelstefan/tyro/ui/statusbar"
+	"github.com/suyashkumar/dicom"
+	"github.com/suyashkumar/dicom/pkg/tag"
+)
+
+// maxTagValueLength is the number of characters of a value displayed in the tag tree before it is truncated.
+const maxTagValueLength = 64
+
+// editorHeight is the number of rows below the tag tree used by the value editor.
+const editorHeight = 2
+
+// maxSuggestions is the number of keyword completions displayed below the tag input.
+const maxSuggestions = 5
+
+// editorMode is the state of the editor below the tag tree.
+type editorMode int
+
+const (
+	// editorClosed means no editor is open.
+	editorClosed editorMode = iota
+	// editorValue edits the value of an existing or a newly added element.
+	editorValue
+	// editorTag asks for the tag and VR of an element to add.
+	editorTag
+)
+
+// elementSavedMsg is sent once a change to a dataset has been written to disk.
+type elementSavedMsg struct {
+	file   *operations.ParsedDicomFile
+	change operations.ElementChange
+	err    error
+}
+
+// tagItemModel is a tag tree item representing a single data element.
+type tagItemModel struct {
+	// Element is the represented element of the dataset.
+	Element *dicom.Element
+	// Path addresses Element within the dataset.
+	Path operations.TagPath
+}
+
+func (m tagItemModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m tagItemModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return m, nil
+}
+
+// View renders the element as "(gggg,eeee) Keyword VR [VM] value".
+func (m tagItemModel) View() string {
+	line := fmt.Sprintf("%s [%d]", m.label(), valueMultiplicity(m.Element))
+	if value := formatValue(m.Element); value != "" {
+		line += " " + value
+	}
+	return line
+}
+
+// label returns "(gggg,eeee) Keyword VR" identifying the element.
+func (m tagItemModel) label() string {
+	keyword := "Unknown"
+	if tag.IsPrivate(m.Element.Tag.Group) {
+		keyword = "Private"
+	}
+	if info, err := tag.Find(m.Element.Tag); err == nil {
+		keyword = info.Name
+	}
+	return fmt.Sprintf("%s %s %s", formatTag(m.Element.Tag), keyword, m.Element.RawValueRepresentation)
+}
+
+// isSequence reports whether the element is a sequence.
+func (m tagItemModel) isSequence() bool {
+	return m.Element.Value != nil && m.Element.Value.ValueType() == dicom.Sequences
+}
+
+// sequenceItemModel is a tag tree item representing a single item of a sequence.
+type sequenceItemModel struct {
+	// Index is the zero based position of the item within its sequence.
+	Index int
+	// Elements are the elements contained in the item.
+	Elements []*dicom.Element
+	// Sequence addresses the sequence containing the item.
+	Sequence operations.TagPath
+}
+
+func (m sequenceItemModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m sequenceItemModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return m, nil
+}
+
+func (m sequenceItemModel) View() string {
+	return fmt.Sprintf("Item %d (%d elements)", m.Index+1, len(m.Elements))
+}
+
+// tagTreeModel displays the dataset of the selected file as an expandable tree of elements, with
+// sequences and their items as expandable nodes.
+//
+// While the pane has the keyboard focus, enter opens an editor for the value of the selected
+// element below the tree. The edited value is validated against the VR of the element and written
+// back to the file once it is confirmed with enter. Esc discards the edit.
+//
+// "a" adds an element next to the selected element, or into the selected sequence item. The tag
+// is entered by keyword or number with completion from the data dictionary, followed by the value
+// of the new element. "i" appends an empty item to the selected sequence and delete removes the
+// selected element or item.
+//
+// Inconsistencies of the File Meta Information are listed above the elements, "M" regenerates
+// the File Meta Information from the data set.
+type tagTreeModel struct {
+	pane *treePaneModel
+	file *operations.ParsedDicomFile
+
+	// history performs the edits, so they can be undone.
+	history *operations.History
+	// staging collects the edits instead while it is enabled.
+	staging *stagingArea
+
+	focused bool
+
+	mode editorMode
+	// editing is the element whose value is being edited while mode is editorValue.
+	editing *tagItemModel
+	// adding reports whether editing is a new element, which is not yet part of the dataset.
+	adding bool
+	// addPath returns the path a new element with the given tag is added at while mode is editorTag.
+	addPath func(t tag.Tag) operations.TagPath
+	// suggestions are the keyword completions of the tag input.
+	suggestions []tag.Info
+	input       textinput.Model
+	inputErr    error
+
+	width  int
+	height int
+
+	errorStyle lipgloss.Style
+	helpStyle  lipgloss.Style
+}
+
+// NewTagTreeModel creates an empty tag tree pane performing edits through history, or staging
+// them in staging while it is enabled.
+func NewTagTreeModel(history *operations.History, staging *stagingArea) *tagTreeModel {
+	m := &tagTreeModel{
+		pane:    newTreePaneModel(),
+		history: history,
+		staging: staging,
+		errorStyle: lipgloss.NewStyle().
+			Foreground(defaults.ErrorColor),
+		helpStyle: lipgloss.NewStyle().
+			Foreground(defaults.AccentColor),
+	}
+	m.pane.Reset("No file selected")
+	return m
+}
+
+func (m *tagTreeModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update rebuilds the tag tree whenever the file selection changes and handles the keys of the
+// tree and the editor while the pane has the focus.
+func (m *tagTreeModel) Update(msg tea.Msg) (*tagTreeModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case FileSelectedMsg:
+		m.stopEditing()
+		m.load(msg.File)
+	case elementSavedMsg:
+		return m, m.elementSaved(msg)
+	case stagingChangedMsg:
+		if m.file != nil && slices.Contains(msg.files, m.file.Path) {
+			m.load(m.file)
+		}
+	case tea.KeyMsg:
+		switch m.mode {
+		case editorValue:
+			return m, m.updateEditor(msg)
+		case editorTag:
+			return m, m.updateTagInput(msg)
+		}
+		if !m.focused {
+			return m, nil
+		}
+		if cmd, handled := m.handleKey(msg); handled {
+			return m, cmd
+		}
+		var cmd tea.Cmd
+		m.pane, cmd = m.pane.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// handleKey handles the editing keys of the tree. It reports whether msg has been handled.
+func (m *tagTreeModel) handleKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	if m.file == nil {
+		return nil, false
+	}
+	selected := m.pane.tree.Selected()
+
+	switch msg.String() {
+	case "enter":
+		if selected == nil || selected.HasChildren() {
+			return nil, false
+		}
+		if item, ok := selected.Model.(tagItemModel); ok && !item.isSequence() {
+			return m.startEditing(item, false), true
+		}
+	case "a":
+		return m.startAdding(), true
+	case "M":
+		if m.file.MetaIssues == nil {
+			return statusbar.Message("the file meta information of " + m.file.Path + " is consistent"), true
+		}
+		return m.perform("fix file meta information", operations.ElementChange{
+			Kind: operations.ChangeFileMeta,
+			Path: operations.NewTagPath(tag.FileMetaInformationGroupLength),
+		}), true
+	case "i":
+		if selected == nil {
+			return nil, true
+		}
+		item, ok := selected.Model.(tagItemModel)
+		if !ok || !item.isSequence() {
+			return statusbar.Error(errors.New("select a sequence to add an item to")), true
+		}
+		return m.perform("add item to "+item.Path.String(), operations.ElementChange{
+			Kind: operations.ChangeAddItem,
+			Path: item.Path,
+			Item: valueMultiplicity(item.Element),
+		}), true
+	case "delete":
+		if selected == nil {
+			return nil, true
+		}
+		switch item := selected.Model.(type) {
+		case tagItemModel:
+			return m.perform("remove "+item.Path.String(), operations.ElementChange{
+				Kind: operations.ChangeRemove,
+				Path: item.Path,
+			}), true
+		case sequenceItemModel:
+			return m.perform(fmt.Sprintf("remove item %d of %s", item.Index+1, item.Sequence), operations.ElementChange{
+				Kind: operations.ChangeRemoveItem,
+				Path: item.Sequence,
+				Item: item.Index,
+			}), true
+		}
+	}
+	return nil, false
+}
+
+func (m *tagTreeModel) View() string {
+	if m.mode == editorClosed {
+		return m.pane.View()
+	}
+
+	help := "enter: save  esc: cancel"
+	if m.mode == editorTag {
+		help = "keyword or (gggg,eeee) [VR]  tab: complete  enter: continue  esc: cancel"
+	}
+	status := m.helpStyle.Render(help)
+	if m.inputErr != nil {
+		status = m.errorStyle.Render(m.inputErr.Error())
+	}
+
+	rows := []string{m.pane.View(), m.input.View()}
+	for _, info := range m.suggestions {
+		rows = append(rows, fmt.Sprintf("  %s %s %s", formatTag(info.Tag), info.Name, info.VR))
+	}
+	rows = append(rows, status)
+	return lipgloss.NewStyle().MaxWidth(max(1, m.width)).Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+// SetSize sets the number of terminal columns and rows available to the pane.
+func (m *tagTreeModel) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.layout()
+}
+
+// SetFocused sets whether the pane receives key messages.
+func (m *tagTreeModel) SetFocused(focused bool) {
+	m.focused = focused
+}
+
+// Editing reports whether an editor is open. All key messages are handled by the editor while it
+// is open.
+func (m *tagTreeModel) Editing() bool {
+	return m.mode != editorClosed
+}
+
+// layout distributes the available rows between the tree and the editor.
+func (m *tagTreeModel) layout() {
+	height := m.height
+	if m.mode != editorClosed {
+		height = max(0, height-editorHeight-len(m.suggestions))
+		m.input.Width = max(1, m.width-lipgloss.Width(m.input.Prompt)-1)
+	}
+	m.pane.SetSize(m.width, height)
+}
+
+// startEditing opens the value editor for item, prefilled with its current value. adding reports
+// whether item is a new element, which is added to the dataset once the value is confirmed.
+func (m *tagTreeModel) startEditing(item tagItemModel, adding bool) tea.Cmd {
+	if !operations.IsEditable(item.Element) {
+		return statusbar.Error(fmt.Errorf("%s: %w", formatTag(item.Element.Tag), operations.ErrorElementNotEditable))
+	}
+
+	m.mode = editorValue
+	m.editing = &item
+	m.adding = adding
+	m.suggestions = nil
+	m.inputErr = nil
+	m.input = textinput.New()
+	m.input.Prompt = item.label() + ": "
+	m.input.SetValue(operations.ElementText(item.Element))
+	m.layout()
+	return m.input.Focus()
+}
+
+// startAdding opens the tag input for a new element. The element is added next to the selected
+// element, into the selected sequence item or to the top level if nothing is selected.
+func (m *tagTreeModel) startAdding() tea.Cmd {
+	m.addPath = operations.NewTagPath
+	if selected := m.pane.tree.Selected(); selected != nil {
+		switch item := selected.Model.(type) {
+		case tagItemModel:
+			m.addPath = item.Path.Sibling
+		case sequenceItemModel:
+			m.addPath = func(t tag.Tag) operations.TagPath { return item.Sequence.Child(item.Index, t) }
+		}
+	}
+
+	m.mode = editorTag
+	m.editing = nil
+	m.suggestions = nil
+	m.inputErr = nil
+	m.input = textinput.New()
+	m.input.Prompt = "add tag: "
+	m.layout()
+	return m.input.Focus()
+}
+
+// stopEditing closes the editor.
+func (m *tagTreeModel) stopEditing() {
+	m.mode = editorClosed
+	m.editing = nil
+	m.adding = false
+	m.addPath = nil
+	m.suggestions = nil
+	m.inputErr = nil
+	m.layout()
+}
+
+// updateTagInput handles a key message while the tag input is open.
+//
+// Tab completes the input to the first suggested keyword. Enter parses the tag and continues with
+// the value of the new element. Sequences are added right away, as they have no value to enter.
+func (m *tagTreeModel) updateTagInput(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.stopEditing()
+		return nil
+	case "tab":
+		if len(m.suggestions) > 0 {
+			m.input.SetValue(m.suggestions[0].Name)
+			m.input.CursorEnd()
+			m.updateSuggestions()
+		}
+		return nil
+	case "enter":
+		t, vr, err := operations.ParseTagSpec(m.input.Value())
+		if err != nil {
+			m.inputErr = err
+			return nil
+		}
+		path := m.addPath(t)
+		if _, err := path.Find(m.file.Dataset.Elements); err == nil {
+			m.inputErr = fmt.Errorf("%s already exists", path)
+			return nil
+		}
+
+		if vr == "SQ" {
+			m.stopEditing()
+			return m.perform("add "+path.String(), operations.ElementChange{
+				Kind: operations.ChangeAdd,
+				Path: path,
+				VR:   vr,
+			})
+		}
+
+		elem, err := operations.NewElement(t, vr, "")
+		if err != nil {
+			m.inputErr = err
+			return nil
+		}
+		return m.startEditing(tagItemModel{Element: elem, Path: path}, true)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.inputErr = nil
+	m.updateSuggestions()
+	return cmd
+}
+
+// updateSuggestions completes the keyword of the tag input.
+func (m *tagTreeModel) updateSuggestions() {
+	m.suggestions = nil
+	if fields := strings.Fields(m.input.Value()); len(fields) == 1 && !strings.HasSuffix(m.input.Value(), " ") {
+		m.suggestions = operations.CompleteKeyword(fields[0], maxSuggestions)
+		if len(m.suggestions) == 1 && m.suggestions[0].Name == fields[0] {
+			m.suggestions = nil
+		}
+	}
+	m.layout()
+}
+
+// updateEditor handles a key message while the value editor is open.
+//
+// Enter validates the input and, if it is valid, returns a command writing the new value to the
+// file. Validation errors are displayed below the input and keep the editor open.
+func (m *tagTreeModel) updateEditor(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.stopEditing()
+		return nil
+	case "enter":
+		text := m.input.Value()
+		if _, err := operations.ParseElementText(m.editing.Element, text); err != nil {
+			m.inputErr = err
+			return nil
+		}
+
+		change := operations.ElementChange{
+			Kind: operations.ChangeSet,
+			Path: m.editing.Path,
+			Old:  operations.ElementText(m.editing.Element),
+			New:  text,
+		}
+		description := "set " + change.Path.String()
+		if m.adding {
+			change = operations.ElementChange{
+				Kind: operations.ChangeAdd,
+				Path: m.editing.Path,
+				VR:   m.editing.Element.RawValueRepresentation,
+				New:  text,
+			}
+			description = "add " + change.Path.String()
+		}
+		m.stopEditing()
+		return m.perform(description, change)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.inputErr = nil
+	return cmd
+}
+
+// perform returns a command applying change to the current file through the history. While
+// staging is enabled, the change is staged instead.
+func (m *tagTreeModel) perform(description string, change operations.ElementChange) tea.Cmd {
+	file, history := m.file, m.history
+	change.File = file.Path
+	if m.staging.enabled {
+		return m.stage(description, change)
+	}
+	return func() tea.Msg {
+		_, err := history.Do(description, []operations.ElementChange{change})
+		return elementSavedMsg{file: file, change: change, err: err}
+	}
+}
+
+// stage applies change to the parsed dataset of the current file and adds it to the staging area.
+func (m *tagTreeModel) stage(description string, change operations.ElementChange) tea.Cmd {
+	if err := m.staging.Stage(&m.file.Dataset, change); err != nil {
+		return statusbar.Error(fmt.Errorf("staging failed: %w", err))
+	}
+
+	if change.Kind == operations.ChangeSet {
+		m.pane.Refresh()
+	} else {
+		m.load(m.file)
+		m.selectPath(change.Path)
+	}
+	return tea.Batch(
+		statusbar.Message(fmt.Sprintf("staged %s in %s", description, m.file.Path)),
+		func() tea.Msg { return stagingChangedMsg{} },
+	)
+}
+
+// elementSaved updates the parsed dataset of a file after a change has been written to disk, so
+// the tag tree reflects the file's content, and reports the result in the status bar.
+//
+// Changed values are applied to the parsed dataset directly. Structural changes reload the file
+// and select the changed element.
+func (m *tagTreeModel) elementSaved(msg elementSavedMsg) tea.Cmd {
+	if msg.err != nil {
+		return statusbar.Error(fmt.Errorf("saving %s failed: %w", msg.file.Path, msg.err))
+	}
+	path := msg.change.Path
+
+	if msg.change.Kind != operations.ChangeSet {
+		if err := m.staging.reload(msg.file); err != nil {
+			return statusbar.Error(fmt.Errorf("saved %s but could not reload it: %w", msg.file.Path, err))
+		}
+		if msg.file == m.file {
+			m.load(msg.file)
+			m.selectPath(path)
+		}
+		return statusbar.Message(fmt.Sprintf("saved %s %s in %s", msg.change.Kind, path, msg.file.Path))
+	}
+
+	// The file has been replaced, so an open handle refers to its previous version.
+	msg.file.Close()
+
+	elem, err := path.Find(msg.file.Dataset.Elements)
+	if err == nil {
+		err = operations.SetElementText(elem, msg.change.New)
+	}
+	if err != nil {
+		return statusbar.Error(fmt.Errorf("saved %s but could not update the view: %w", msg.file.Path, err))
+	}
+
+	if msg.file == m.file {
+		m.pane.Refresh()
+	}
+	return statusbar.Message(fmt.Sprintf("saved %s in %s", path, msg.file.Path))
+}
+
+// selectPath expands the sequences leading to the element addressed by path and selects it. If
+// the element no longer exists, the closest existing ancestor is selected.
+func (m *tagTreeModel) selectPath(path operations.TagPath) {
+	node := m.pane.tree.ExpandableTree.Root
+	var found *expandableTree.Node
+	for i, step := range path {
+		child := node.GetChild(formatTag(step.Tag))
+		if child == nil {
+			break
+		}
+		found = child
+		if i == len(path)-1 {
+			break
+		}
+
+		child.IsExpanded = true
+		item := child.GetChild(fmt.Sprintf("item%d", step.Item))
+		if item == nil {
+			break
+		}
+		item.IsExpanded = true
+		found, node = item, item
+	}
+
+	if found != nil {
+		m.pane.tree.Select(found)
+	}
+	m.pane.Refresh()
+}
+
+// load fills the tree with the elements of file's dataset.
+func (m *tagTreeModel) load(file *operations.ParsedDicomFile) {
+	m.file = file
+	if file == nil {
+		m.pane.Reset("No file selected")
+		return
+	}
+
+	m.pane.Reset("Empty dataset")
+	m.addMetaIssues(file.MetaIssues)
+	m.addBurnedInRisk(operations.BurnedInAnnotationRisk(file.Dataset))
+	m.addElements(m.pane.tree.ExpandableTree.Root, file.Dataset.Elements, nil, 0)
+	m.pane.tree.SelectFirst()
+	m.pane.Refresh()
+}
+
+// addMetaIssues adds a node listing the inconsistencies of the File Meta Information, if there
+// are any.
+func (m *tagTreeModel) addMetaIssues(issues error) {
+	if issues == nil {
+		return
+	}
+	errs := []error{issues}
+	var multi *tyroErrors.MultiError
+	if errors.As(issues, &multi) {
+		errs = multi.Errors()
+	}
+
+	tree := m.pane.tree.ExpandableTree
+	text := fmt.Sprintf("⚠ File Meta Information: %d issues, press M to regenerate it", len(errs))
+	node := tree.AddNode(tree.Root, "meta-issues", textItemModel{text: text})
+	for i, err := range errs {
+		tree.AddNode(node, fmt.Sprint(i), textItemModel{text: err.Error()})
+	}
+}
+
+// addBurnedInRisk adds a node naming why the images may show burned-in annotations, if they may.
+func (m *tagTreeModel) addBurnedInRisk(risk string) {
+	if risk == "" {
+		return
+	}
+	tree := m.pane.tree.ExpandableTree
+	text := fmt.Sprintf("▣ Burned-in annotations: %s, blank them with the blank batch edit", risk)
+	tree.AddNode(tree.Root, "burned-in", textItemModel{text: text})
+}
+
+// addElements adds a node for every element below parent. Sequences are added collapsed with one
+// child node per item.
+//
+// sequence is the path of the sequence containing elems and item the index of their item within
+// it. sequence is nil for the top level elements of the dataset.
+func (m *tagTreeModel) addElements(parent *expandableTree.Node, elems []*dicom.Element, sequence operations.TagPath, item int) {
+	tree := m.pane.tree.ExpandableTree
+	for _, elem := range elems {
+		path := operations.NewTagPath(elem.Tag)
+		if sequence != nil {
+			path = sequence.Child(item, elem.Tag)
+		}
+		node := tree.AddNode(parent, formatTag(elem.Tag), tagItemModel{Element: elem, Path: path})
+
+		items, ok := elem.Value.GetValue().([]*dicom.SequenceItemValue)
+		if !ok {
+			continue
+		}
+		node.IsExpanded = false
+		for i, item := range items {
+			itemElems, _ := item.GetValue().([]*dicom.Element)
+			itemNode := tree.AddNode(node, fmt.Sprintf("item%d", i), sequenceItemModel{Index: i, Elements: itemElems, Sequence: path})
+			m.addElements(itemNode, itemElems, path, i)
+		}
+	}
+}
+
+// formatTag formats a tag as "(gggg,eeee)".
+func formatTag(t tag.Tag) string {
+	return fmt.Sprintf("(%04X,%04X)", t.Group, t.Element)
+}
+
+// valueMultiplicity returns the number of values of an element. Sequences report their number of items.
+func valueMultiplicity(elem *dicom.Element) int {
+	if elem.Value == nil {
+		return 0
+	}
+	switch v := elem.Value.GetValue().(type) {
+	case []string:
+		if len(v) == 1 && v[0] == "" {
+			return 0
+		}
+		return len(v)
+	case []int:
+		return len(v)
+	case []float64:
+		return len(v)
+	case []*dicom.SequenceItemValue:
+		return len(v)
+	case []byte:
+		if len(v) == 0 {
+			return 0
+		}
+		return 1
+	default:
+		return 1
+	}
+}
+
+// formatValue returns a single line, truncated representation of the value of an element.
+func formatValue(elem *dicom.Element) string {
+	if elem.Value == nil {
+		return ""
+	}
+
+	var value string
+	switch v := elem.Value.GetValue().(type) {
+	case []string:
+		value = strings.Join(v, "\\")
+	case []int, []float64:
+		value = strings.Trim(fmt.Sprint(v), "[]")
+		value = strings.ReplaceAll(value, " ", "\\")
+	case []byte:
+		return fmt.Sprintf("<%d bytes>", len(v))
+	case []*dicom.SequenceItemValue:
+		return ""
+	case dicom.PixelDataInfo:
+		if v.IntentionallySkipped {
+			return "<pixel data not loaded>"
+		}
+		return fmt.Sprintf("<pixel data, %d frames>", len(v.Frames))
+	default:
+		value = elem.Value.String()
+	}
+
+	value = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' || r == '\t' {
+			return ' '
+		}
+		return r
+	}, value)
+	if len([]rune(value)) > maxTagValueLength {
+		value = string([]rune(value)[:maxTagValueLength-1]) + "…"
+	}
+	return value
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/streimelstefan/tyro/ui/expandableTree"
+)
+
+// treePaneModel displays an expandable tree inside a scrollable viewport that follows the selection.
+type treePaneModel struct {
+	tree     *expandableTree.Model
+	viewport viewport.Model
+
+	// placeholder is displayed instead of the tree while the tree is empty.
+	placeholder string
+}
+
+// newTreePaneModel creates an empty tree pane.
+func newTreePaneModel() *treePaneModel {
+	return &treePaneModel{
+		tree: expandableTree.New(),
+	}
+}
+
+func (m *treePaneModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update forwards key messages to the tree and keeps the selection visible.
+func (m *treePaneModel) Update(msg tea.Msg) (*treePaneModel, tea.Cmd) {
+	if _, ok := msg.(tea.KeyMsg); !ok {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.tree, cmd = m.tree.Update(msg)
+	m.Refresh()
+	return m, cmd
+}
+
+func (m *treePaneModel) View() string {
+	return m.viewport.View()
+}
+
+// SetSize sets the number of terminal columns and rows available to the pane.
+func (m *treePaneModel) SetSize(width, height int) {
+	m.viewport.Width = width
+	m.viewport.Height = height
+	m.Refresh()
+}
+
+// Reset removes all nodes from the tree, shows placeholder instead and scrolls back to the top.
+func (m *treePaneModel) Reset(placeholder string) {
+	m.tree.Clear()
+	m.placeholder = placeholder
+	m.viewport.SetYOffset(0)
+	m.Refresh()
+}
+
+// Refresh re-renders the tree and scrolls the viewport so the selection stays visible.
+func (m *treePaneModel) Refresh() {
+	if !m.tree.ExpandableTree.Root.HasChildren() {
+		m.viewport.SetContent(m.placeholder)
+		return
+	}
+	m.viewport.SetContent(m.tree.View())
+
+	line := m.tree.SelectedLine()
+	if line < 0 {
+		return
+	}
+	if line < m.viewport.YOffset {
+		m.viewport.SetYOffset(line)
+	} else if line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(line - m.viewport.Height + 1)
+	}
+}